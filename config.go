@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log"
+	"me-pague/internal/config"
+	"me-pague/internal/db"
+	"os"
+
+	"gorm.io/gorm/logger"
+)
+
+const configUsage = "usage: me-pague [flags] config print"
+
+// logLevel é o log.level da configuração; os avisos só aparecem abaixo de
+// error.
+var logLevel = "info"
+
+// applyConfig passa a configuração dos logs para o log e o GORM; as demais
+// opções chegam aos handlers por controller.Deps.
+func applyConfig(cfg config.Config) {
+	logLevel = cfg.Log.Level
+	db.Logger = logger.Default.LogMode(gormLogLevel(cfg.Log.Level))
+}
+
+// gormLogLevel leva log.level para o nível do GORM: só em debug o SQL de
+// cada consulta é logado.
+func gormLogLevel(level string) logger.LogLevel {
+	switch level {
+	case "debug":
+		return logger.Info
+	case "error":
+		return logger.Error
+	default:
+		return logger.Warn
+	}
+}
+
+func warn(message string) {
+	if logLevel != "error" {
+		log.Println(message)
+	}
+}
+
+// runConfig trata "me-pague config print", que mostra a configuração
+// efetiva com os segredos mascarados.
+func runConfig(cfg config.Config, args []string) {
+	if len(args) != 1 || args[0] != "print" {
+		log.Fatal(configUsage)
+	}
+	if err := config.Print(os.Stdout, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
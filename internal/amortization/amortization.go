@@ -0,0 +1,111 @@
+// Package amortization monta a tabela de um empréstimo pelos sistemas Price
+// (francês, parcelas fixas) e SAC (amortização constante). As contas são
+// feitas com frações exatas e cada valor é arredondado para o centavo mais
+// próximo, metade para cima (money.HalfUp).
+package amortization
+
+import (
+	"fmt"
+	"math/big"
+	"me-pague/internal/money"
+	"me-pague/internal/recurring"
+	"time"
+)
+
+// Sistemas de amortização.
+const (
+	Price = "price"
+	SAC   = "sac"
+)
+
+// MaxTerm é o prazo máximo, em meses.
+const MaxTerm = 480
+
+// Row é uma linha da tabela: a prestação do mês dividida em juros e
+// amortização, e o saldo devedor depois dela.
+type Row struct {
+	Number    int          `json:"number"`
+	DueDate   time.Time    `json:"due_date"`
+	Payment   money.Amount `json:"payment"`
+	Interest  money.Amount `json:"interest"`
+	Principal money.Amount `json:"principal"`
+	Balance   money.Amount `json:"balance"`
+}
+
+// Schedule monta a tabela de principal, em centavos, à taxa mensal rateBP
+// (pontos-base: 100 = 1% ao mês) em term parcelas mensais a partir de
+// firstDue. A última parcela absorve a diferença dos arredondamentos e zera o
+// saldo.
+func Schedule(system string, principal money.Amount, rateBP int64, term int, firstDue time.Time) ([]Row, error) {
+	if principal <= 0 {
+		return nil, fmt.Errorf("principal must be greater than zero")
+	}
+	if rateBP < 0 {
+		return nil, fmt.Errorf("rate must not be negative")
+	}
+	if term < 1 || term > MaxTerm {
+		return nil, fmt.Errorf("term must be between 1 and %d months", MaxTerm)
+	}
+	if system != Price && system != SAC {
+		return nil, fmt.Errorf("system must be price or sac")
+	}
+
+	rate := big.NewRat(rateBP, 10000)
+	dates := recurring.Rule{Frequency: recurring.Monthly, Interval: 1, Start: firstDue}
+
+	var payment, amortization money.Amount
+	if system == Price {
+		var err error
+		if payment, err = pricePayment(principal, rate, term); err != nil {
+			return nil, err
+		}
+	} else {
+		amortization = principal / money.Amount(term)
+	}
+
+	rows := make([]Row, term)
+	balance := principal
+	for i := range rows {
+		interest, err := money.Round(new(big.Rat).Mul(big.NewRat(int64(balance), 1), rate), money.HalfUp)
+		if err != nil {
+			return nil, err
+		}
+
+		row := Row{Number: i + 1, DueDate: dates.Occurrence(i), Interest: interest}
+		switch {
+		case i == term-1:
+			row.Principal = balance
+		case system == Price:
+			row.Principal = min(payment-interest, balance)
+		default:
+			row.Principal = amortization
+		}
+		if row.Payment, err = row.Principal.Add(row.Interest); err != nil {
+			return nil, err
+		}
+		balance -= row.Principal
+		row.Balance = balance
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// pricePayment é a prestação fixa: P * i / (1 - (1 + i)^-n).
+func pricePayment(principal money.Amount, rate *big.Rat, term int) (money.Amount, error) {
+	if rate.Sign() == 0 {
+		return money.Round(big.NewRat(int64(principal), int64(term)), money.HalfUp)
+	}
+
+	// (1 + i)^n
+	growth := big.NewRat(1, 1)
+	onePlusRate := new(big.Rat).Add(big.NewRat(1, 1), rate)
+	for i := 0; i < term; i++ {
+		growth.Mul(growth, onePlusRate)
+	}
+
+	// P * i * (1 + i)^n / ((1 + i)^n - 1), a mesma fórmula sem expoente negativo.
+	numerator := new(big.Rat).Mul(big.NewRat(int64(principal), 1), rate)
+	numerator.Mul(numerator, growth)
+	denominator := new(big.Rat).Sub(growth, big.NewRat(1, 1))
+	return money.Round(numerator.Quo(numerator, denominator), money.HalfUp)
+}
@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"me-pague/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Ações registradas.
+const (
+	Create  = "create"
+	Reverse = "reverse"
+	Confirm = "confirm"
+	Reject  = "reject"
+	Expire  = "expire"
+	Update  = "update"
+	Delete  = "delete"
+)
+
+// DefaultLimit e MaxLimit limitam quantos eventos uma consulta devolve.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Record grava um evento de auditoria. Deve ser chamado na mesma transação
+// da escrita auditada, para que um não exista sem o outro. before e after
+// são serializados em JSON; nil indica que a entidade não existia antes ou
+// deixou de existir.
+func Record(tx *gorm.DB, meta Meta, action, entityType string, entityID int32, before, after interface{}) error {
+	event := models.AuditEvent{
+		ActorID:    meta.ActorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		RequestID:  meta.RequestID,
+		ClientIP:   meta.ClientIP,
+		CreatedAt:  time.Now(),
+	}
+
+	var err error
+	if event.Before, err = snapshot(before); err != nil {
+		return err
+	}
+	if event.After, err = snapshot(after); err != nil {
+		return err
+	}
+
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("error recording audit event: %w", err)
+	}
+	return nil
+}
+
+func snapshot(value interface{}) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding audit snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// Filter restringe uma consulta aos eventos. Campos zerados não filtram.
+type Filter struct {
+	EntityType string
+	EntityID   int32
+	ActorID    int32
+	From       time.Time
+	To         time.Time
+	Limit      int
+	// ViewerID, quando preenchido, limita a consulta aos eventos escritos
+	// pelo usuário ou sobre entidades de que ele participa.
+	ViewerID int32
+}
+
+// visibleEntities associa cada tipo de entidade auditada à subconsulta que
+// devolve os IDs das entidades de que o usuário @viewer participa.
+var visibleEntities = []struct {
+	entityType string
+	ids        string
+}{
+	{"user", "SELECT id FROM users WHERE id = @viewer"},
+	{"billing", viewerBillings},
+	{"charge", "SELECT id FROM charges WHERE billing_id IN (" + viewerBillings + ")"},
+	{"payment", "SELECT id FROM payments WHERE billing_id IN (" + viewerBillings + ")"},
+	{"installment_plan", "SELECT id FROM installment_plans WHERE billing_id IN (" + viewerBillings + ")"},
+	{"loan", "SELECT id FROM loans WHERE billing_id IN (" + viewerBillings + ")"},
+	{"recurring_billing", "SELECT id FROM recurring_billings WHERE payer_id = @viewer OR receiver_id = @viewer OR created_by = @viewer"},
+	{"group", viewerGroups},
+	{"group_expense", "SELECT id FROM group_expenses WHERE group_id IN (" + viewerGroups + ")"},
+	{"pix_key", "SELECT id FROM pix_keys WHERE user_id = @viewer"},
+	{"webhook_subscription", "SELECT id FROM webhook_subscriptions WHERE owner_id = @viewer"},
+	{"webhook_delivery", "SELECT id FROM webhook_deliveries WHERE subscription_id IN (SELECT id FROM webhook_subscriptions WHERE owner_id = @viewer)"},
+}
+
+const (
+	viewerBillings = "SELECT id FROM billings WHERE payer_id = @viewer OR receiver_id = @viewer"
+	viewerGroups   = "SELECT group_id FROM group_members WHERE user_id = @viewer"
+)
+
+// visibleTo monta a condição que restringe os eventos aos que o usuário pode
+// ver: os que ele mesmo escreveu e os das entidades de que participa.
+func visibleTo() string {
+	conditions := []string{"actor_id = @viewer"}
+	for _, entity := range visibleEntities {
+		conditions = append(conditions, fmt.Sprintf("(entity_type = '%s' AND entity_id IN (%s))", entity.entityType, entity.ids))
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// Query devolve os eventos que atendem ao filtro, do mais antigo para o mais
+// recente.
+func Query(tx *gorm.DB, filter Filter) ([]models.AuditEvent, error) {
+	query := tx.Model(&models.AuditEvent{})
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.ViewerID != 0 {
+		query = query.Where(visibleTo(), sql.Named("viewer", filter.ViewerID))
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at <= ?", filter.To)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	events := []models.AuditEvent{}
+	if err := query.Order("id").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("error loading audit events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,36 @@
+package audit
+
+import (
+	"me-pague/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDKey é a chave do ID da requisição no contexto do gin.
+const RequestIDKey = "request_id"
+
+// Meta identifica quem fez uma escrita e em qual requisição.
+type Meta struct {
+	ActorID   int32
+	RequestID string
+	ClientIP  string
+}
+
+// System é a Meta das escritas feitas fora de uma requisição.
+var System = Meta{}
+
+// SetRequestID grava o ID da requisição no contexto.
+func SetRequestID(c *gin.Context, requestID string) {
+	c.Set(RequestIDKey, requestID)
+}
+
+// FromContext monta a Meta da requisição atual a partir do usuário
+// autenticado, do ID da requisição e do IP do cliente.
+func FromContext(c *gin.Context) Meta {
+	meta := Meta{RequestID: c.GetString(RequestIDKey)}
+	meta.ActorID, _ = auth.UserID(c)
+	if c.Request != nil {
+		meta.ClientIP = c.ClientIP()
+	}
+	return meta
+}
@@ -0,0 +1,22 @@
+package auth
+
+import "github.com/gin-gonic/gin"
+
+// UserIDKey é a chave do contexto do gin onde fica o ID do usuário
+// autenticado.
+const UserIDKey = "user_id"
+
+// SetUserID grava o usuário autenticado no contexto.
+func SetUserID(c *gin.Context, userID int32) {
+	c.Set(UserIDKey, userID)
+}
+
+// UserID devolve o usuário autenticado, se houver.
+func UserID(c *gin.Context) (int32, bool) {
+	value, ok := c.Get(UserIDKey)
+	if !ok {
+		return 0, false
+	}
+	userID, ok := value.(int32)
+	return userID, ok && userID != 0
+}
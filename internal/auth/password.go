@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength é o tamanho mínimo aceito para uma senha.
+const MinPasswordLength = 8
+
+// MaxPasswordLength é o tamanho máximo de uma senha, em bytes: o bcrypt não
+// aceita mais do que isso.
+const MaxPasswordLength = 72
+
+var (
+	// ErrPasswordTooShort indica uma senha com menos de MinPasswordLength
+	// caracteres.
+	ErrPasswordTooShort = fmt.Errorf("password must have at least %d characters", MinPasswordLength)
+	// ErrPasswordTooLong indica uma senha com mais de MaxPasswordLength
+	// bytes.
+	ErrPasswordTooLong = fmt.Errorf("password must have at most %d bytes", MaxPasswordLength)
+)
+
+// HashPassword gera o hash bcrypt da senha.
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", ErrPasswordTooShort
+	}
+	if len(password) > MaxPasswordLength {
+		return "", ErrPasswordTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword confere a senha contra o hash gravado.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
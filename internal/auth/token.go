@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tipos de token. O de acesso autentica as requisições; o de renovação só
+// serve para obter um novo par.
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Options configura a emissão e a verificação de tokens. Sem Secret, nenhum
+// token é emitido nem aceito.
+type Options struct {
+	Secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// DefaultOptions devolve as validades padrão, sem segredo.
+func DefaultOptions() Options {
+	return Options{
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 30 * 24 * time.Hour,
+	}
+}
+
+// Claims é o conteúdo de um token.
+type Claims struct {
+	Subject   int32  `json:"sub"`
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TokenPair é o resultado de um login ou de uma renovação.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// jwtHeader é o cabeçalho fixo dos tokens: JWT assinado com HMAC-SHA256.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// IssuePair emite um token de acesso e um de renovação para o usuário.
+func (o Options) IssuePair(userID int32, now time.Time) (TokenPair, error) {
+	access, err := o.Sign(Claims{Subject: userID, Type: AccessToken, IssuedAt: now.Unix(), ExpiresAt: now.Add(o.AccessTTL).Unix()})
+	if err != nil {
+		return TokenPair{}, err
+	}
+	refresh, err := o.Sign(Claims{Subject: userID, Type: RefreshToken, IssuedAt: now.Unix(), ExpiresAt: now.Add(o.RefreshTTL).Unix()})
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(o.AccessTTL / time.Second),
+	}, nil
+}
+
+// Sign serializa e assina as claims no formato JWT (HS256).
+func (o Options) Sign(claims Claims) (string, error) {
+	if len(o.Secret) == 0 {
+		return "", errors.New("auth secret is not configured")
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("error encoding token: %w", err)
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + o.signature(unsigned), nil
+}
+
+// Verify confere a assinatura, o tipo e a validade do token.
+func (o Options) Verify(token, tokenType string, now time.Time) (Claims, error) {
+	var claims Claims
+	if len(o.Secret) == 0 {
+		return claims, errors.New("auth secret is not configured")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return claims, ErrInvalidToken
+	}
+
+	expected := o.signature(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return claims, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, ErrInvalidToken
+	}
+
+	if claims.Type != tokenType || claims.Subject == 0 {
+		return claims, ErrInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return claims, ErrExpiredToken
+	}
+	return claims, nil
+}
+
+func (o Options) signature(unsigned string) string {
+	mac := hmac.New(sha256.New, o.Secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
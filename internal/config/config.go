@@ -0,0 +1,359 @@
+// Package config monta a configuração da aplicação. Cada opção tem uma
+// chave, como "server.addr", e pode vir, da menor para a maior precedência,
+// do valor padrão, de um arquivo YAML ou TOML, de uma variável de ambiente
+// (ME_PAGUE_SERVER_ADDR) ou de uma flag (-server.addr). Valores inválidos
+// são todos reportados juntos, antes de a aplicação subir.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"me-pague/internal/auth"
+	"me-pague/internal/controller"
+	"me-pague/internal/middleware"
+	"me-pague/internal/penalty"
+	"me-pague/internal/webhook"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix é o prefixo das variáveis de ambiente.
+const EnvPrefix = "ME_PAGUE_"
+
+// Config é a configuração da aplicação.
+type Config struct {
+	Server     Server
+	Database   Database
+	Log        Log
+	Auth       Auth
+	Payments   Payments
+	Webhooks   Webhooks
+	Penalty    penalty.Policy
+	Jobs       Jobs
+	Features   Features
+	Correction Correction
+	Currency   Currency
+}
+
+// Server configura o servidor HTTP.
+type Server struct {
+	Addr         string
+	GinMode      string
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+}
+
+// Database configura o banco SQLite.
+type Database struct {
+	DSN string
+}
+
+// Log configura o nível dos logs.
+type Log struct {
+	// Level é debug, info, warn ou error. Em debug o SQL é logado.
+	Level string
+}
+
+// Auth configura os tokens de acesso. Sem Secret, um segredo aleatório é
+// gerado e os tokens deixam de valer quando o servidor reinicia.
+type Auth struct {
+	Secret     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// Payments configura a confirmação dos pagamentos e a retenção das chaves
+// de idempotência.
+type Payments struct {
+	RequireConfirmation  bool
+	PendingTTL           time.Duration
+	IdempotencyRetention time.Duration
+}
+
+// Webhooks configura as entregas de webhooks.
+type Webhooks struct {
+	webhook.Options
+	// Interval é o intervalo entre as rodadas do worker de entrega.
+	Interval time.Duration
+}
+
+// Jobs configura as rotinas periódicas: expiração dos pagamentos pendentes,
+// cobranças recorrentes e parcelas de empréstimos.
+type Jobs struct {
+	Interval time.Duration
+}
+
+// Features liga e desliga partes da aplicação.
+type Features struct {
+	Swagger       bool
+	WebhookWorker bool
+	Jobs          bool
+}
+
+// Correction configura a correção monetária.
+type Correction struct {
+	// IndexFile é o CSV com as taxas dos índices; sem ele não há correção.
+	IndexFile string
+}
+
+// Currency configura o câmbio.
+type Currency struct {
+	// ExchangeRatesFile é o CSV com as cotações; sem ele pagamentos em
+	// outra moeda são recusados.
+	ExchangeRatesFile string
+}
+
+// Default devolve a configuração padrão, com as opções padrão de cada
+// pacote.
+func Default() Config {
+	authOptions := auth.DefaultOptions()
+	payments := controller.DefaultPaymentOptions()
+	return Config{
+		Server: Server{
+			Addr:         ":8080",
+			GinMode:      "debug",
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  time.Minute,
+		},
+		Database: Database{DSN: "payments.db"},
+		Log:      Log{Level: "info"},
+		Auth:     Auth{AccessTTL: authOptions.AccessTTL, RefreshTTL: authOptions.RefreshTTL},
+		Payments: Payments{
+			RequireConfirmation:  payments.RequireConfirmation,
+			PendingTTL:           payments.PendingTTL,
+			IdempotencyRetention: middleware.DefaultIdempotencyRetention,
+		},
+		Webhooks: Webhooks{Options: webhook.DefaultOptions(), Interval: 5 * time.Second},
+		Penalty:  penalty.DefaultPolicy(),
+		Jobs:     Jobs{Interval: time.Minute},
+		Features: Features{Swagger: true, WebhookWorker: true, Jobs: true},
+	}
+}
+
+// option é uma opção configurável, ligada a um campo de Config.
+type option struct {
+	key    string
+	usage  string
+	value  interface{}
+	env    string
+	secret bool
+}
+
+// envName é a variável de ambiente da opção.
+func (o option) envName() string {
+	if o.env != "" {
+		return o.env
+	}
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(o.key, ".", "_"))
+}
+
+// options lista as opções de c, na ordem em que são impressas.
+func (c *Config) options() []option {
+	return []option{
+		{key: "server.addr", usage: "endereço em que o servidor escuta, host:porta", value: &c.Server.Addr},
+		{key: "server.gin_mode", usage: "modo do gin: debug, release ou test", value: &c.Server.GinMode},
+		{key: "server.read_timeout", usage: "tempo máximo para ler uma requisição", value: &c.Server.ReadTimeout},
+		{key: "server.write_timeout", usage: "tempo máximo para escrever uma resposta", value: &c.Server.WriteTimeout},
+		{key: "server.idle_timeout", usage: "tempo máximo de uma conexão ociosa", value: &c.Server.IdleTimeout},
+		{key: "database.dsn", usage: "arquivo do banco SQLite", value: &c.Database.DSN},
+		{key: "log.level", usage: "nível dos logs: debug, info, warn ou error", value: &c.Log.Level},
+		{key: "auth.secret", usage: "segredo de assinatura dos tokens", value: &c.Auth.Secret, secret: true},
+		{key: "auth.access_ttl", usage: "validade do token de acesso", value: &c.Auth.AccessTTL},
+		{key: "auth.refresh_ttl", usage: "validade do token de renovação", value: &c.Auth.RefreshTTL},
+		{key: "payments.require_confirmation", usage: "deixa os pagamentos pendentes até o recebedor confirmar", value: &c.Payments.RequireConfirmation},
+		{key: "payments.pending_ttl", usage: "tempo até um pagamento pendente expirar", value: &c.Payments.PendingTTL},
+		{key: "payments.idempotency_retention", usage: "por quanto tempo uma chave de idempotência é lembrada", value: &c.Payments.IdempotencyRetention},
+		{key: "webhooks.max_attempts", usage: "tentativas de uma entrega antes de desistir", value: &c.Webhooks.MaxAttempts},
+		{key: "webhooks.base_delay", usage: "espera depois da primeira falha de entrega", value: &c.Webhooks.BaseDelay},
+		{key: "webhooks.max_delay", usage: "espera máxima entre tentativas de entrega", value: &c.Webhooks.MaxDelay},
+		{key: "webhooks.timeout", usage: "tempo máximo de cada requisição ao assinante", value: &c.Webhooks.Timeout},
+		{key: "webhooks.batch_size", usage: "entregas enviadas por rodada do worker", value: &c.Webhooks.BatchSize},
+		{key: "webhooks.interval", usage: "intervalo entre as rodadas do worker de entrega", value: &c.Webhooks.Interval},
+		{key: "webhooks.allow_private_networks", usage: "aceita assinantes em endereços de loopback, privados ou link-local", value: &c.Webhooks.AllowPrivateNetworks},
+		{key: "penalty.late_fee_bp", usage: "multa por atraso, em pontos-base", value: &c.Penalty.LateFeeBP},
+		{key: "penalty.monthly_interest_bp", usage: "juros de mora ao mês, em pontos-base", value: &c.Penalty.MonthlyInterestBP},
+		{key: "penalty.cap_bp", usage: "teto de multa e juros, em pontos-base; 0 deixa sem teto", value: &c.Penalty.CapBP},
+		{key: "jobs.interval", usage: "intervalo das rotinas de expiração, recorrência e empréstimos", value: &c.Jobs.Interval},
+		{key: "features.swagger", usage: "serve a documentação em /swagger", value: &c.Features.Swagger},
+		{key: "features.webhook_worker", usage: "roda o worker de entrega de webhooks", value: &c.Features.WebhookWorker},
+		{key: "features.jobs", usage: "roda as rotinas periódicas", value: &c.Features.Jobs},
+		{key: "correction.index_file", usage: "CSV com as taxas dos índices de correção", value: &c.Correction.IndexFile, env: "ME_PAGUE_INDEX_FILE"},
+		{key: "currency.exchange_rates_file", usage: "CSV com as cotações de câmbio", value: &c.Currency.ExchangeRatesFile, env: "ME_PAGUE_EXCHANGE_RATES_FILE"},
+	}
+}
+
+// Load monta a configuração a partir das flags em args e das variáveis de
+// ambiente lidas por lookupEnv, sobre o arquivo indicado por -config ou
+// ME_PAGUE_CONFIG, se houver. Devolve também os argumentos que sobram
+// depois das flags. Com -h, devolve flag.ErrHelp depois de imprimir as
+// opções em output.
+func Load(args []string, lookupEnv func(string) (string, bool), output io.Writer) (Config, []string, error) {
+	cfg := Default()
+	options := cfg.options()
+
+	fs := flag.NewFlagSet("me-pague", flag.ContinueOnError)
+	fs.SetOutput(output)
+	path := fs.String("config", "", "arquivo de configuração YAML ou TOML (ou "+EnvPrefix+"CONFIG)")
+	flags := make(map[string]string)
+	for _, o := range options {
+		key := o.key
+		fs.Func(key, o.usage+" ("+o.envName()+")", func(value string) error {
+			flags[key] = value
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+
+	if *path == "" {
+		*path, _ = lookupEnv(EnvPrefix + "CONFIG")
+	}
+	var errs []error
+	if *path != "" {
+		values, err := readFile(*path)
+		if err != nil {
+			return cfg, nil, err
+		}
+		errs = append(errs, apply(options, values, *path)...)
+	}
+
+	env := make(map[string]string)
+	for _, o := range options {
+		if value, ok := lookupEnv(o.envName()); ok {
+			env[o.key] = value
+		}
+	}
+	errs = append(errs, apply(options, env, "environment")...)
+	errs = append(errs, apply(options, flags, "flags")...)
+	errs = append(errs, cfg.Validate())
+
+	if err := errors.Join(errs...); err != nil {
+		return cfg, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// apply grava em cada opção o valor dela em values, se houver. Chaves que
+// não são opções também são erro, para que um erro de digitação não passe
+// despercebido.
+func apply(options []option, values map[string]string, source string) []error {
+	var errs []error
+	known := make(map[string]bool, len(options))
+	for _, o := range options {
+		known[o.key] = true
+		raw, ok := values[o.key]
+		if !ok {
+			continue
+		}
+		if err := set(o.value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value for %s: %w", source, o.key, err))
+		}
+	}
+	for key := range values {
+		if !known[key] {
+			errs = append(errs, fmt.Errorf("%s: unknown option %s", source, key))
+		}
+	}
+	return errs
+}
+
+func set(target interface{}, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch v := target.(type) {
+	case *string:
+		*v = raw
+	case *bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		*v = parsed
+	case *int:
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		*v = parsed
+	case *int64:
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		*v = parsed
+	case *time.Duration:
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s or 15m", raw)
+		}
+		*v = parsed
+	default:
+		return fmt.Errorf("unsupported option type %T", target)
+	}
+	return nil
+}
+
+func format(value interface{}) string {
+	switch v := value.(type) {
+	case *string:
+		return *v
+	case *bool:
+		return strconv.FormatBool(*v)
+	case *int:
+		return strconv.Itoa(*v)
+	case *int64:
+		return strconv.FormatInt(*v, 10)
+	case *time.Duration:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// Validate confere os valores que não dependem de onde vieram.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(c.Server.Addr)
+	check(err == nil, "server.addr must be host:port, like :8080, got %q", c.Server.Addr)
+	check(oneOf(c.Server.GinMode, "debug", "release", "test"), "server.gin_mode must be debug, release or test, got %q", c.Server.GinMode)
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be greater than zero")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be greater than zero")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be greater than zero")
+	check(c.Database.DSN != "", "database.dsn is required")
+	check(oneOf(c.Log.Level, "debug", "info", "warn", "error"), "log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	check(c.Auth.AccessTTL > 0, "auth.access_ttl must be greater than zero")
+	check(c.Auth.RefreshTTL > c.Auth.AccessTTL, "auth.refresh_ttl must be longer than auth.access_ttl")
+	check(c.Payments.PendingTTL > 0, "payments.pending_ttl must be greater than zero")
+	check(c.Payments.IdempotencyRetention > 0, "payments.idempotency_retention must be greater than zero")
+	check(c.Webhooks.MaxAttempts >= 1, "webhooks.max_attempts must be at least 1")
+	check(c.Webhooks.BaseDelay > 0, "webhooks.base_delay must be greater than zero")
+	check(c.Webhooks.MaxDelay >= c.Webhooks.BaseDelay, "webhooks.max_delay must not be shorter than webhooks.base_delay")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout must be greater than zero")
+	check(c.Webhooks.BatchSize >= 1, "webhooks.batch_size must be at least 1")
+	check(c.Webhooks.Interval > 0, "webhooks.interval must be greater than zero")
+	check(c.Penalty.LateFeeBP >= 0, "penalty.late_fee_bp must not be negative")
+	check(c.Penalty.MonthlyInterestBP >= 0, "penalty.monthly_interest_bp must not be negative")
+	check(c.Penalty.CapBP >= 0, "penalty.cap_bp must not be negative")
+	check(c.Jobs.Interval > 0, "jobs.interval must be greater than zero")
+	return errors.Join(errs...)
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// mask substitui os segredos na saída de Print.
+const mask = "********"
+
+// readFile lê um arquivo YAML (.yaml ou .yml) ou TOML (.toml) com uma seção
+// por grupo de opções e devolve os valores pelas chaves das opções, como
+// "server.addr".
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var tree map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &tree)
+	case ".toml":
+		err = toml.Unmarshal(content, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s must end in .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", tree, values); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) error {
+	for name, value := range tree {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(key, v, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("%s must be a single value, not a list", key)
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// Print escreve a configuração em YAML, no formato aceito pelo arquivo de
+// configuração, com os segredos mascarados.
+func Print(w io.Writer, cfg Config) error {
+	section := ""
+	for _, o := range cfg.options() {
+		group, name, _ := strings.Cut(o.key, ".")
+		if group != section {
+			if section != "" {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s:\n", group)
+			section = group
+		}
+
+		value := format(o.value)
+		if o.secret && value != "" {
+			value = mask
+		}
+		switch o.value.(type) {
+		case *string, *time.Duration:
+			value = strconv.Quote(value)
+		}
+		if _, err := fmt.Fprintf(w, "  %s: %s\n", name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/response"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAuditEvents godoc
+// @Summary Lista os eventos de auditoria
+// @Description Cada escrita gera um evento com o autor, a ação, a entidade, os estados antes e depois, o ID da requisição e o IP do cliente. Só aparecem os eventos escritos pelo usuário autenticado ou sobre entidades de que ele participa. from e to aceitam RFC 3339 ou YYYY-MM-DD.
+// @Tags Auditoria
+// @Produce json
+// @Param entity_type query string false "Tipo da entidade (user, billing, charge, payment, group, group_expense)"
+// @Param entity_id query int false "ID da entidade"
+// @Param actor_id query int false "ID do usuário que fez a escrita"
+// @Param from query string false "Início do período"
+// @Param to query string false "Fim do período"
+// @Param limit query int false "Máximo de eventos (padrão 100, até 1000)"
+// @Success 200 {array} models.AuditEvent
+// @Failure 400 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /audit [get]
+func (h *Handlers) ListAuditEvents(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	filter, err := auditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	filter.ViewerID = userID
+
+	events, err := h.repos.Audit.Query(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+func auditFilter(c *gin.Context) (audit.Filter, error) {
+	filter := audit.Filter{EntityType: c.Query("entity_type")}
+
+	ids := []struct {
+		name   string
+		target *int32
+	}{{"entity_id", &filter.EntityID}, {"actor_id", &filter.ActorID}}
+	for _, id := range ids {
+		if raw := c.Query(id.name); raw != "" {
+			ID, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", id.name)
+			}
+			*id.target = int32(ID)
+		}
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(c.Query("from"), false); err != nil {
+		return filter, fmt.Errorf("invalid from: %w", err)
+	}
+	if filter.To, err = parseAuditTime(c.Query("to"), true); err != nil {
+		return filter, fmt.Errorf("invalid to: %w", err)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return filter, fmt.Errorf("to must not be before from")
+	}
+	return filter, nil
+}
+
+// parseAuditTime aceita RFC 3339 ou uma data YYYY-MM-DD. Uma data no fim do
+// período cobre o dia inteiro.
+func parseAuditTime(raw string, endOfDay bool) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("use RFC 3339 or YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
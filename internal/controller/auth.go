@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"errors"
+	"me-pague/internal/auth"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrNotAuthenticated = errors.New("authentication required")
+	ErrNotBillingParty  = errors.New("you are not a party to this billing")
+)
+
+// Login godoc
+// @Summary Autentica um usuário e devolve os tokens de acesso e renovação
+// @Tags Autenticação
+// @Accept json
+// @Produce json
+// @Param credentials body request.LoginInput true "Credenciais"
+// @Success 200 {object} auth.TokenPair
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Router /auth/login [post]
+func (h *Handlers) Login(c *gin.Context) {
+	var input request.LoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	user, err := h.users.Authenticate(input.Name, input.Password)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	tokens, err := h.auth.IssuePair(user.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Refresh godoc
+// @Summary Troca um token de renovação por um novo par de tokens
+// @Tags Autenticação
+// @Accept json
+// @Produce json
+// @Param token body request.RefreshInput true "Token de renovação"
+// @Success 200 {object} auth.TokenPair
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Router /auth/refresh [post]
+func (h *Handlers) Refresh(c *gin.Context) {
+	var input request.RefreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	now := time.Now()
+	claims, err := h.auth.Verify(input.RefreshToken, auth.RefreshToken, now)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, err := h.users.Get(claims.Subject); err != nil {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "User not found"})
+		return
+	}
+
+	tokens, err := h.auth.IssuePair(claims.Subject, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// currentUser devolve o usuário autenticado ou responde 401.
+func currentUser(c *gin.Context) (int32, bool) {
+	userID, ok := auth.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: ErrNotAuthenticated.Error()})
+	}
+	return userID, ok
+}
+
+// requireBillingParty garante que o usuário autenticado é o pagador ou o
+// recebedor da cobrança, respondendo 401 ou 403 caso contrário.
+func requireBillingParty(c *gin.Context, billing models.Billing) (int32, bool) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return 0, false
+	}
+	if userID != billing.PayerID && userID != billing.ReceiverID {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: ErrNotBillingParty.Error()})
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"me-pague/internal/controller/response"
+	"me-pague/internal/models"
+	"me-pague/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBalance godoc
+// @Summary Obtém o saldo líquido entre dois usuários
+// @Description Soma as cobranças nos dois sentidos: em cada uma, o que foi lançado e ainda não foi pago é devido pelo pagador, e o que foi pago além disso é crédito dele.
+// @Tags Cobranças
+// @Produce json
+// @Param user_a query int true "ID do primeiro usuário"
+// @Param user_b query int true "ID do segundo usuário"
+// @Param currency query string false "Moeda das cobranças somadas (padrão: BRL)"
+// @Success 200 {object} response.BalanceResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /balance [get]
+func (h *Handlers) GetBalance(c *gin.Context) {
+	userA, _ := strconv.Atoi(c.Query("user_a"))
+	userB, _ := strconv.Atoi(c.Query("user_b"))
+
+	input, err := h.billings.Validate(service.BillingKey{PayerID: int32(userA), ReceiverID: int32(userB), Currency: c.Query("currency")})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, ok := requireBillingParty(c, models.Billing{PayerID: int32(userA), ReceiverID: int32(userB)}); !ok {
+		return
+	}
+
+	balance, err := h.billings.PairBalance(int32(userA), int32(userB), input.Currency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, balanceResponse(balance))
+}
+
+// balanceResponse monta a resposta de GET /balance a partir do saldo
+// calculado pelo serviço.
+func balanceResponse(balance service.Balance) response.BalanceResponse {
+	return response.BalanceResponse{
+		UserA:      balance.UserA,
+		UserB:      balance.UserB,
+		Currency:   balance.Currency,
+		Net:        balance.Net,
+		DebtorID:   balance.DebtorID,
+		CreditorID: balance.CreditorID,
+		Billings:   balance.Billings,
+		Payments:   balance.Payments,
+	}
+}
@@ -1,83 +1,105 @@
 package controller
 import (
-	"me-pague/internal/db"
+	"me-pague/internal/audit"
 	"me-pague/internal/models"
+	"me-pague/internal/repository"
 	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/service"
 	"net/http"
 	"time"
 	"strconv"
 	"github.com/gin-gonic/gin"
 	"fmt"
+	"errors"
 )
 
 // GetBilling godoc
 // @Summary Obtém ou cria uma cobrança entre dois usuários
+// @Description Os lançamentos vencidos e não pagos sofrem multa e juros de mora pro rata por dia, limitados a um teto, calculados na data as_of.
 // @Tags Cobranças
 // @Accept json
 // @Produce json
 // @Param payer_id query string true "ID do pagador"
 // @Param receiver_id query string true "ID do recebedor"
-// @Success 200 {object} models.Billing
+// @Param currency query string false "Moeda da cobrança, ISO 4217 (padrão: BRL); cada par de usuários tem uma cobrança por moeda"
+// @Param as_of query string false "Data do cálculo dos encargos, YYYY-MM-DD (padrão: hoje)"
+// @Success 200 {object} response.BillingResponse
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Security BearerAuth
 // @Router /billing [get]
-func GetBilling(c *gin.Context) {
-	var billingInput request.BillingInput
+func (h *Handlers) GetBilling(c *gin.Context) {
+	var key service.BillingKey
 	payerID, _ := strconv.Atoi(c.Query("payer_id"))
 	receiverID, _ := strconv.Atoi(c.Query("receiver_id"))
-	billingInput.PayerID = int32(payerID)
-	billingInput.ReceiverID = int32(receiverID)
+	key.PayerID = int32(payerID)
+	key.ReceiverID = int32(receiverID)
+	key.Currency = c.Query("currency")
 
-	_, err := validationError(billingInput)	
+	key, err := h.billings.Validate(key)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	billing, err := GetOrCreateBilling(billingInput)
+	if _, ok := requireBillingParty(c, models.Billing{PayerID: key.PayerID, ReceiverID: key.ReceiverID}); !ok {
+		return
+	}
+
+	asOf := time.Now()
+	if raw := c.Query("as_of"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be in the YYYY-MM-DD format"})
+			return
+		}
+		asOf = parsed
+	}
+
+	billing, err := h.billings.GetOrCreate(key, audit.FromContext(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, billing)
+	statement, err := h.billings.WithPenalties(billing, asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, billingResponse(statement))
 }
 
+// billingKey leva o pedido da API para a chave da cobrança no serviço.
+func billingKey(input request.BillingInput) service.BillingKey {
+	return service.BillingKey{PayerID: input.PayerID, ReceiverID: input.ReceiverID, Currency: input.Currency}
+}
 
-func GetOrCreateBilling(billingInput request.BillingInput) (models.Billing, error) {
-    var billing models.Billing
-	db.DB.Where("payer_id = ? AND receiver_id = ?", billingInput.PayerID, billingInput.ReceiverID).First(&billing)
-	
-	if billing.ID == 0 {
-		billing = models.Billing{
-			PayerID: billingInput.PayerID,
-			ReceiverID: billingInput.ReceiverID,
-			Amount:    0,
-			CreatedAt: time.Now(),
-		}
-		db.DB.Create(&billing)
+// billingResponse monta a resposta de GET /billing a partir do extrato
+// calculado pelo serviço.
+func billingResponse(statement service.Statement) response.BillingResponse {
+	return response.BillingResponse{
+		Billing:        statement.Billing,
+		AsOf:           statement.AsOf.Format("2006-01-02"),
+		OriginalAmount: statement.OriginalAmount,
+		LateFee:        statement.LateFee,
+		Interest:       statement.Interest,
+		Penalties:      statement.Penalties,
+		TotalDue:       statement.TotalDue,
+		Charges:        statement.Charges,
 	}
-	return billing, nil
 }
 
-func getBillingByID(id int32) (models.Billing, error) {
-	var billing models.Billing
-	if err := db.DB.Where("id = ?", id).First(&billing).Error; err != nil {
+func (h *Handlers) getBillingByID(id int32) (models.Billing, error) {
+	billing, err := h.billings.Get(id)
+	if errors.Is(err, repository.ErrNotFound) {
 		return billing, fmt.Errorf("Billing not found")
 	}
-	return billing, nil
+	return billing, err
 }
 
-func validationError(billingInput request.BillingInput) (request.BillingInput, error) {
-	if billingInput.PayerID == billingInput.ReceiverID {
-		return billingInput, fmt.Errorf("payer_id and receiver_id cannot be the same")
-	}
-
-	if db.DB.Where("id = ?", billingInput.PayerID).First(&models.User{}).Error != nil {
-        return billingInput, fmt.Errorf("Person 1 not found")
-    }
-    if db.DB.Where("id = ?", billingInput.ReceiverID).First(&models.User{}).Error != nil {
-        return billingInput, fmt.Errorf("Person 2 not found")
-    }
-
-	return billingInput, nil
-}
+// ErrBillingConflict indica que a cobrança foi alterada por outra requisição
+// entre a leitura e a gravação.
+var ErrBillingConflict = repository.ErrBillingConflict
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"fmt"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/currency"
+	"me-pague/internal/models"
+	"me-pague/internal/repository"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCharge godoc
+// @Summary Lança um valor devido em uma cobrança
+// @Description O valor é na menor unidade da moeda da cobrança; currency, se informada, precisa ser a mesma da cobrança.
+// @Tags Cobranças
+// @Accept json
+// @Produce json
+// @Param id path int true "ID da cobrança"
+// @Param charge body request.ChargeInput true "Dados do lançamento"
+// @Success 201 {object} models.Charge
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /billing/{id}/charge [post]
+func (h *Handlers) CreateCharge(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid billing ID"})
+		return
+	}
+
+	var input request.ChargeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	billing, err := h.getBillingByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, ok := requireBillingParty(c, billing); !ok {
+		return
+	}
+
+	charge, err := createCharge(h.repos.Billings, billing, input, audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, charge)
+}
+
+// ListCharges godoc
+// @Summary Lista os valores lançados em uma cobrança
+// @Tags Cobranças
+// @Produce json
+// @Param id path int true "ID da cobrança"
+// @Success 200 {array} models.Charge
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /billing/{id}/charges [get]
+func (h *Handlers) ListCharges(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid billing ID"})
+		return
+	}
+
+	billing, err := h.getBillingByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, ok := requireBillingParty(c, billing); !ok {
+		return
+	}
+
+	charges, err := h.repos.Billings.Charges(billing.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error loading charges: " + err.Error()})
+		return
+	}
+	sort.SliceStable(charges, func(i, j int) bool { return charges[i].Date.Before(charges[j].Date) })
+
+	c.JSON(http.StatusOK, charges)
+}
+
+// createCharge valida o lançamento pedido e o grava em billings, que dentro
+// de uma transação é repository.GormBillings sobre ela.
+func createCharge(billings repository.BillingRepository, billing models.Billing, input request.ChargeInput, meta audit.Meta) (models.Charge, error) {
+	amount, err := input.Amount.In(billing.Currency)
+	if err != nil {
+		return models.Charge{}, err
+	}
+	if amount <= 0 {
+		return models.Charge{}, fmt.Errorf("amount must be greater than zero")
+	}
+	if input.Currency != "" {
+		code, err := currency.Normalize(input.Currency)
+		if err != nil {
+			return models.Charge{}, err
+		}
+		if code != billing.Currency {
+			return models.Charge{}, fmt.Errorf("charge currency %s does not match the billing currency %s", code, billing.Currency)
+		}
+	}
+
+	date := time.Now()
+	if input.Date != "" {
+		parsed, err := time.Parse("2006-01-02", input.Date)
+		if err != nil {
+			return models.Charge{}, fmt.Errorf("date must be in the YYYY-MM-DD format")
+		}
+		date = parsed
+	}
+
+	charge := models.Charge{
+		BillingID:   billing.ID,
+		Amount:      amount,
+		Currency:    billing.Currency,
+		Description: input.Description,
+		Date:        date,
+		CreatedAt:   time.Now(),
+	}
+	if input.DueDate != "" {
+		dueDate, err := time.Parse("2006-01-02", input.DueDate)
+		if err != nil {
+			return models.Charge{}, fmt.Errorf("due_date must be in the YYYY-MM-DD format")
+		}
+		if input.Date != "" && dueDate.Before(date) {
+			return models.Charge{}, fmt.Errorf("due_date must not be before the charge date")
+		}
+		charge.DueDate = &dueDate
+	}
+	if err := billings.AddCharge(&charge, billing, meta); err != nil {
+		return charge, fmt.Errorf("error creating charge: %w", err)
+	}
+	return charge, nil
+}
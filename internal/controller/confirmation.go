@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/recurring"
+	"me-pague/internal/repository"
+	"me-pague/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentOptions controla o fluxo de confirmação dos pagamentos.
+type PaymentOptions struct {
+	// RequireConfirmation faz os novos pagamentos ficarem pendentes até o
+	// recebedor confirmar.
+	RequireConfirmation bool
+	// PendingTTL é por quanto tempo um pagamento pode ficar pendente antes
+	// de expirar.
+	PendingTTL time.Duration
+}
+
+// DefaultPaymentOptions devolve as opções padrão: os pagamentos esperam a
+// confirmação do recebedor por até 72 horas.
+func DefaultPaymentOptions() PaymentOptions {
+	return PaymentOptions{
+		RequireConfirmation: true,
+		PendingTTL:          72 * time.Hour,
+	}
+}
+
+// ErrPaymentNotPending indica que o pagamento já foi confirmado, rejeitado
+// ou expirou.
+var ErrPaymentNotPending = repository.ErrPaymentNotPending
+
+// ConfirmPayment godoc
+// @Summary Confirma o recebimento de um pagamento pendente
+// @Description Só pagamentos confirmados contam para a cobrança.
+// @Tags Pagamentos
+// @Produce json
+// @Param id path int true "ID do pagamento"
+// @Success 200 {object} models.Payment
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /payment/{id}/confirm [post]
+func (h *Handlers) ConfirmPayment(c *gin.Context) {
+	h.resolvePayment(c, models.PaymentConfirmed)
+}
+
+// RejectPayment godoc
+// @Summary Rejeita um pagamento pendente que não foi recebido
+// @Tags Pagamentos
+// @Produce json
+// @Param id path int true "ID do pagamento"
+// @Success 200 {object} models.Payment
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /payment/{id}/reject [post]
+func (h *Handlers) RejectPayment(c *gin.Context) {
+	h.resolvePayment(c, models.PaymentRejected)
+}
+
+func (h *Handlers) resolvePayment(c *gin.Context, status string) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid payment ID"})
+		return
+	}
+
+	if _, err := h.ExpirePendingPayments(time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	payment, err := h.getPaymentByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	billing, err := h.getBillingByID(payment.BillingID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if userID != billing.ReceiverID {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: "only the receiver can confirm or reject a payment"})
+		return
+	}
+
+	payment, err = h.setPaymentStatus(payment, billing, status, audit.FromContext(c))
+	if errors.Is(err, ErrPaymentNotPending) || errors.Is(err, ErrBillingConflict) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// setPaymentStatus tira o pagamento de pendente. Ao confirmar, o pagamento
+// passa a contar para a cobrança na mesma operação.
+func (h *Handlers) setPaymentStatus(payment models.Payment, billing models.Billing, status string, meta audit.Meta) (models.Payment, error) {
+	if payment.Status != models.PaymentPending {
+		return payment, fmt.Errorf("%w: it is %s", ErrPaymentNotPending, payment.Status)
+	}
+
+	var credit money.Amount
+	if status == models.PaymentConfirmed {
+		credit = service.CreditFor(billing, payment.Amount)
+	}
+	resolved := payment
+	if err := h.repos.Payments.Resolve(&resolved, status, credit, billing, meta); err != nil {
+		return payment, err
+	}
+	return resolved, nil
+}
+
+// ExpirePendingPayments marca como expirados os pagamentos pendentes há mais
+// tempo que o PendingTTL de Deps.Payments, registrando cada um na auditoria
+// como feito pelo sistema.
+func (h *Handlers) ExpirePendingPayments(now time.Time) (int64, error) {
+	expired, err := h.repos.Payments.ExpirePending(now.Add(-h.options.PendingTTL), now)
+	if err != nil {
+		return 0, fmt.Errorf("error expiring pending payments: %w", err)
+	}
+	return expired, nil
+}
+
+// ExpirePendingPaymentsEvery roda ExpirePendingPayments periodicamente até o
+// processo terminar, com o horário lido de clock.
+func (h *Handlers) ExpirePendingPaymentsEvery(clock recurring.Clock, interval time.Duration) {
+	for range time.Tick(interval) {
+		if _, err := h.ExpirePendingPayments(clock.Now()); err != nil {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"fmt"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/correction"
+	"me-pague/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCorrectedBilling godoc
+// @Summary Corrige o saldo da cobrança por um índice de inflação
+// @Description Atualiza o saldo em aberto pelo índice (IPCA, SELIC ou outro carregado na tabela), mês a mês, desde a data de cada lançamento e pagamento até date.
+// @Description A taxa de cada mês incide sobre o saldo do início do mês; o que entra num mês só é corrigido a partir do mês seguinte, e o mês de date não é corrigido.
+// @Tags Cobranças
+// @Produce json
+// @Param id path int true "ID da cobrança"
+// @Param index query string true "Índice, como IPCA ou SELIC"
+// @Param date query string false "Data de referência, YYYY-MM-DD (padrão: hoje)"
+// @Success 200 {object} response.CorrectedBillingResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /billing/{id}/corrected [get]
+func (h *Handlers) GetCorrectedBilling(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid billing ID"})
+		return
+	}
+
+	index := c.Query("index")
+	if index == "" {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "index is required"})
+		return
+	}
+	date := time.Now()
+	if raw := c.Query("date"); raw != "" {
+		date, err = time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "date must be in the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	billing, err := h.getBillingByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, ok := requireBillingParty(c, billing); !ok {
+		return
+	}
+
+	entries, err := h.billingEntries(billing)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	result, err := correction.Correct(h.indexes, index, entries, date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.CorrectedBillingResponse{BillingID: billing.ID, Result: result})
+}
+
+// billingEntries lista os lançamentos da cobrança na data de cada um, os
+// pagamentos confirmados na data do pagamento e os estornos na data do
+// estorno.
+func (h *Handlers) billingEntries(billing models.Billing) ([]correction.Entry, error) {
+	charges, err := h.repos.Billings.Charges(billing.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading charges: %w", err)
+	}
+	payments, err := h.repos.Payments.ListConfirmed(billing.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading payments: %w", err)
+	}
+
+	entries := make([]correction.Entry, 0, len(charges)+len(payments))
+	for _, charge := range charges {
+		entries = append(entries, correction.Entry{Date: charge.Date, Amount: charge.Amount})
+	}
+	for _, payment := range payments {
+		entries = append(entries, correction.Entry{Date: payment.CreatedAt, Amount: -payment.Amount})
+		for _, reversal := range payment.Reversals {
+			entries = append(entries, correction.Entry{Date: reversal.CreatedAt, Amount: reversal.Amount})
+		}
+	}
+	return entries, nil
+}
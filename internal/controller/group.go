@@ -0,0 +1,274 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/currency"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/repository"
+	"me-pague/internal/split"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateGroup godoc
+// @Summary Cria um grupo de usuários para dividir despesas
+// @Tags Grupos
+// @Accept json
+// @Produce json
+// @Param group body request.CreateGroupInput true "Dados do grupo"
+// @Success 201 {object} models.Group
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /group [post]
+func (h *Handlers) CreateGroup(c *gin.Context) {
+	var input request.CreateGroupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if !slices.Contains(input.MemberIDs, userID) {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: "you must be a member of the group you create"})
+		return
+	}
+
+	group, err := h.createGroup(input, audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// GetGroup godoc
+// @Summary Obtém um grupo e seus membros
+// @Tags Grupos
+// @Produce json
+// @Param id path int true "ID do grupo"
+// @Success 200 {object} models.Group
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /group/{id} [get]
+func (h *Handlers) GetGroup(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid group ID"})
+		return
+	}
+
+	group, err := h.getGroupByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if !requireGroupMember(c, group) {
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// CreateGroupExpense godoc
+// @Summary Registra uma despesa do grupo e divide entre os membros
+// @Description Estratégias: equal, percentage (pontos-base, 10000 = 100%), shares (pesos) e exact (centavos).
+// @Tags Grupos
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do grupo"
+// @Param expense body request.GroupExpenseInput true "Dados da despesa"
+// @Success 201 {object} models.GroupExpense
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /group/{id}/expense [post]
+func (h *Handlers) CreateGroupExpense(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid group ID"})
+		return
+	}
+
+	var input request.GroupExpenseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	group, err := h.getGroupByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if !requireGroupMember(c, group) {
+		return
+	}
+
+	expense, err := h.createGroupExpense(group, input, audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, expense)
+}
+
+func (h *Handlers) createGroup(input request.CreateGroupInput, meta audit.Meta) (models.Group, error) {
+	if len(input.MemberIDs) < 2 {
+		return models.Group{}, fmt.Errorf("a group needs at least two members")
+	}
+
+	group := models.Group{Name: input.Name, CreatedAt: time.Now()}
+	seen := make(map[int32]bool, len(input.MemberIDs))
+	for _, userID := range input.MemberIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		if _, err := h.users.Get(userID); err != nil {
+			return models.Group{}, fmt.Errorf("User %d not found", userID)
+		}
+		group.Members = append(group.Members, models.GroupMember{UserID: userID})
+	}
+
+	if err := h.repos.Groups.Create(&group, meta); err != nil {
+		return models.Group{}, fmt.Errorf("error creating group: %w", err)
+	}
+	return group, nil
+}
+
+// requireGroupMember garante que o usuário autenticado faz parte do grupo,
+// respondendo 401 ou 403 caso contrário.
+func requireGroupMember(c *gin.Context, group models.Group) bool {
+	userID, ok := currentUser(c)
+	if !ok {
+		return false
+	}
+	for _, member := range group.Members {
+		if member.UserID == userID {
+			return true
+		}
+	}
+	c.JSON(http.StatusForbidden, response.ErrorResponse{Error: "you are not a member of this group"})
+	return false
+}
+
+func (h *Handlers) getGroupByID(id int32) (models.Group, error) {
+	group, err := h.repos.Groups.Get(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return group, fmt.Errorf("Group not found")
+	}
+	return group, err
+}
+
+// createGroupExpense divide a despesa e lança a parte de cada membro que
+// não pagou na cobrança membro -> pagador.
+func (h *Handlers) createGroupExpense(group models.Group, input request.GroupExpenseInput, meta audit.Meta) (models.GroupExpense, error) {
+	members := make(map[int32]bool, len(group.Members))
+	for _, member := range group.Members {
+		members[member.UserID] = true
+	}
+	if !members[input.PayerID] {
+		return models.GroupExpense{}, fmt.Errorf("payer %d is not a member of the group", input.PayerID)
+	}
+
+	parts := make([]split.Part, 0, len(group.Members))
+	if len(input.Shares) == 0 {
+		if input.Split != split.Equal {
+			return models.GroupExpense{}, fmt.Errorf("shares are required for the %q split", input.Split)
+		}
+		for _, member := range group.Members {
+			parts = append(parts, split.Part{UserID: member.UserID})
+		}
+	} else {
+		for _, share := range input.Shares {
+			if !members[share.UserID] {
+				return models.GroupExpense{}, fmt.Errorf("user %d is not a member of the group", share.UserID)
+			}
+			parts = append(parts, split.Part{UserID: share.UserID, Value: share.Value})
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].UserID < parts[j].UserID })
+
+	total, err := input.Total.In(currency.Default)
+	if err != nil {
+		return models.GroupExpense{}, err
+	}
+	amounts, err := split.Split(total, input.Split, parts)
+	if err != nil {
+		return models.GroupExpense{}, err
+	}
+
+	expense := models.GroupExpense{
+		GroupID:     group.ID,
+		PayerID:     input.PayerID,
+		Description: input.Description,
+		Total:       total,
+		SplitType:   input.Split,
+		CreatedAt:   time.Now(),
+	}
+
+	err = h.repos.Transaction(func(tx repository.Repositories) error {
+		for i, part := range parts {
+			share := models.ExpenseShare{UserID: part.UserID, Amount: amounts[i]}
+			if part.UserID != input.PayerID && amounts[i] > 0 {
+				billing, charge, err := chargePairBilling(tx.Billings, part.UserID, input.PayerID, amounts[i], input.Description, meta)
+				if err != nil {
+					return err
+				}
+				share.BillingID, share.ChargeID = billing.ID, charge.ID
+			}
+			expense.Shares = append(expense.Shares, share)
+		}
+		return tx.Groups.AddExpense(&expense, meta)
+	})
+	if err != nil {
+		return models.GroupExpense{}, fmt.Errorf("error creating expense: %w", err)
+	}
+
+	return expense, nil
+}
+
+// chargePairBilling lança amount na cobrança payerID -> receiverID,
+// criando a cobrança se ela ainda não existir.
+func chargePairBilling(billings repository.BillingRepository, payerID, receiverID int32, amount money.Amount, description string, meta audit.Meta) (models.Billing, models.Charge, error) {
+	billing, err := pairBilling(billings, payerID, receiverID, meta)
+	if err != nil {
+		return billing, models.Charge{}, err
+	}
+
+	charge, err := createCharge(billings, billing, request.ChargeInput{Amount: money.Minor(amount), Description: description}, meta)
+	return billing, charge, err
+}
+
+// pairBilling devolve a cobrança em moeda padrão entre pagador e recebedor,
+// com os totais, criando-a se ainda não existir.
+func pairBilling(billings repository.BillingRepository, payerID, receiverID int32, meta audit.Meta) (models.Billing, error) {
+	billing, err := billings.Find(payerID, receiverID, currency.Default)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return billing, err
+	}
+
+	billing = models.Billing{PayerID: payerID, ReceiverID: receiverID, Currency: currency.Default, CreatedAt: time.Now()}
+	err = billings.Create(&billing, meta)
+	return billing, err
+}
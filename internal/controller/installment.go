@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"fmt"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/installment"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateInstallmentPlan godoc
+// @Summary Parcela o valor em aberto da cobrança
+// @Description Divide amount (padrão: todo o valor em aberto) em count parcelas mensais a partir de first_due_date.
+// @Description Os pagamentos feitos depois disso abatem as parcelas da mais antiga para a mais nova. Só pode haver um plano em aberto por cobrança.
+// @Tags Cobranças
+// @Accept json
+// @Produce json
+// @Param id path int true "ID da cobrança"
+// @Param plan body request.InstallmentPlanInput true "Dados do parcelamento"
+// @Success 201 {object} models.InstallmentPlan
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /billing/{id}/installments [post]
+func (h *Handlers) CreateInstallmentPlan(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid billing ID"})
+		return
+	}
+
+	var input request.InstallmentPlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	billing, err := h.getBillingByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, ok := requireBillingParty(c, billing); !ok {
+		return
+	}
+
+	plan, err := h.createInstallmentPlan(billing, input, audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// ListInstallmentPlans godoc
+// @Summary Lista os parcelamentos da cobrança com a situação de cada parcela
+// @Description Situações: open, partial, paid e overdue (vencida e não quitada).
+// @Tags Cobranças
+// @Produce json
+// @Param id path int true "ID da cobrança"
+// @Param as_of query string false "Data da situação, YYYY-MM-DD (padrão: hoje)"
+// @Success 200 {array} models.InstallmentPlan
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /billing/{id}/installments [get]
+func (h *Handlers) ListInstallmentPlans(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid billing ID"})
+		return
+	}
+
+	asOf := time.Now()
+	if raw := c.Query("as_of"); raw != "" {
+		asOf, err = time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "as_of must be in the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	billing, err := h.getBillingByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, ok := requireBillingParty(c, billing); !ok {
+		return
+	}
+
+	plans, err := h.loadInstallmentPlans(billing, asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+func (h *Handlers) createInstallmentPlan(billing models.Billing, input request.InstallmentPlanInput, meta audit.Meta) (models.InstallmentPlan, error) {
+	plans, err := h.loadInstallmentPlans(billing, time.Now())
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+	for _, plan := range plans {
+		if plan.Paid < plan.Total {
+			return models.InstallmentPlan{}, fmt.Errorf("billing already has an open installment plan")
+		}
+	}
+
+	if billing.Outstanding <= 0 {
+		return models.InstallmentPlan{}, fmt.Errorf("billing has no outstanding amount")
+	}
+	amount, err := input.Amount.In(billing.Currency)
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+	if amount < 0 {
+		return models.InstallmentPlan{}, fmt.Errorf("amount must not be negative")
+	}
+	if amount == 0 {
+		amount = billing.Outstanding
+	}
+	if amount > billing.Outstanding {
+		return models.InstallmentPlan{}, fmt.Errorf("amount exceeds the outstanding balance of %d", billing.Outstanding)
+	}
+
+	firstDue, err := time.Parse("2006-01-02", input.FirstDueDate)
+	if err != nil {
+		return models.InstallmentPlan{}, fmt.Errorf("first_due_date must be in the YYYY-MM-DD format")
+	}
+	split, err := installment.Split(amount, int(input.Count), firstDue)
+	if err != nil {
+		return models.InstallmentPlan{}, err
+	}
+
+	plan := models.InstallmentPlan{
+		BillingID:  billing.ID,
+		Total:      amount,
+		Count:      input.Count,
+		PaidBefore: billing.TotalPaid,
+		CreatedBy:  meta.ActorID,
+		CreatedAt:  time.Now(),
+	}
+	for _, item := range split {
+		plan.Installments = append(plan.Installments, models.Installment{
+			Number:  int32(item.Number),
+			Amount:  item.Amount,
+			DueDate: item.DueDate,
+		})
+	}
+
+	if err := h.repos.Installments.Create(&plan, meta); err != nil {
+		return models.InstallmentPlan{}, fmt.Errorf("error creating installment plan: %w", err)
+	}
+
+	allocateInstallments(&plan, 0, time.Now())
+	return plan, nil
+}
+
+// loadInstallmentPlans carrega os planos da cobrança com o pago e a situação
+// de cada parcela na data asOf.
+func (h *Handlers) loadInstallmentPlans(billing models.Billing, asOf time.Time) ([]models.InstallmentPlan, error) {
+	plans, err := h.repos.Installments.ListByBilling(billing.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading installment plans: %w", err)
+	}
+	if len(plans) == 0 {
+		return plans, nil
+	}
+	payments, err := h.repos.Payments.ListConfirmed(billing.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading payments: %w", err)
+	}
+
+	paid := paidThrough(payments, asOf)
+	for i := range plans {
+		allocateInstallments(&plans[i], max(paid-plans[i].PaidBefore, 0), asOf)
+	}
+	return plans, nil
+}
+
+// paidThrough soma o que foi pago na cobrança até o fim do dia asOf, em
+// UTC: cada pagamento conta a partir da confirmação e cada estorno o reduz
+// a partir do dia em que foi feito.
+func paidThrough(payments []models.Payment, asOf time.Time) money.Amount {
+	year, month, day := asOf.UTC().Date()
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+
+	var paid money.Amount
+	for _, payment := range payments {
+		counted := payment.CreatedAt
+		if payment.ResolvedAt != nil {
+			counted = *payment.ResolvedAt
+		}
+		if counted.Before(end) {
+			paid += payment.Amount
+		}
+		for _, reversal := range payment.Reversals {
+			if reversal.CreatedAt.Before(end) {
+				paid -= reversal.Amount
+			}
+		}
+	}
+	return paid
+}
+
+// allocateInstallments abate das parcelas paid, o que foi pago na cobrança
+// desde a criação do plano, e classifica cada uma na data asOf.
+func allocateInstallments(plan *models.InstallmentPlan, paid money.Amount, asOf time.Time) {
+	items := make([]installment.Installment, len(plan.Installments))
+	for i, item := range plan.Installments {
+		items[i] = installment.Installment{Number: int(item.Number), Amount: item.Amount, DueDate: item.DueDate}
+	}
+
+	installment.Allocate(items, paid, asOf)
+
+	plan.Paid = 0
+	for i := range items {
+		plan.Installments[i].Paid = items[i].Paid
+		plan.Installments[i].Status = items[i].Status
+		plan.Paid += items[i].Paid
+	}
+}
@@ -0,0 +1,406 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"me-pague/internal/amortization"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/currency"
+	"me-pague/internal/installment"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/recurring"
+	"me-pague/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateLoan godoc
+// @Summary Cria um empréstimo com juros entre tomador e credor
+// @Description Monta a tabela de amortização de principal à taxa mensal monthly_rate_bp (100 = 1% ao mês) em term parcelas mensais a partir de first_due_date.
+// @Description Sistemas: price (parcelas fixas) e sac (amortização constante). Cada parcela é lançada na cobrança entre pagador (tomador) e recebedor (credor) no vencimento, e os pagamentos da cobrança quitam os lançamentos do vencimento mais antigo para o mais novo.
+// @Tags Empréstimos
+// @Accept json
+// @Produce json
+// @Param loan body request.LoanInput true "Dados do empréstimo"
+// @Success 201 {object} models.Loan
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /loans [post]
+func (h *Handlers) CreateLoan(c *gin.Context) {
+	var input request.LoanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if userID != input.PayerID && userID != input.ReceiverID {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: ErrNotBillingParty.Error()})
+		return
+	}
+
+	loan, err := h.createLoan(input, audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, loan)
+}
+
+// GetLoan godoc
+// @Summary Mostra a tabela de amortização do empréstimo com a situação de cada parcela
+// @Description Situações: open, partial, paid e overdue. payoff_amount é o valor para quitar o empréstimo na data: as parcelas lançadas e não pagas mais o saldo devedor, sem os juros futuros.
+// @Tags Empréstimos
+// @Produce json
+// @Param id path int true "ID do empréstimo"
+// @Param as_of query string false "Data da situação, YYYY-MM-DD (padrão: hoje)"
+// @Success 200 {object} models.Loan
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /loans/{id} [get]
+func (h *Handlers) GetLoan(c *gin.Context) {
+	asOf := time.Now()
+	if raw := c.Query("as_of"); raw != "" {
+		var err error
+		asOf, err = time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "as_of must be in the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	loan, billing, ok := h.requireLoanParty(c)
+	if !ok {
+		return
+	}
+
+	if err := h.allocateLoan(&loan, billing, asOf); err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, loan)
+}
+
+// PayOffLoan godoc
+// @Summary Quita o empréstimo antecipadamente
+// @Description Lança na cobrança o saldo devedor depois da última parcela lançada, sem os juros das parcelas futuras, que deixam a tabela. Só o tomador pode quitar.
+// @Tags Empréstimos
+// @Produce json
+// @Param id path int true "ID do empréstimo"
+// @Success 200 {object} models.Loan
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /loans/{id}/payoff [post]
+func (h *Handlers) PayOffLoan(c *gin.Context) {
+	loan, billing, ok := h.requireLoanParty(c)
+	if !ok {
+		return
+	}
+	// A quitação antecipa o saldo devedor para hoje: só quem deve pode
+	// escolher fazer isso.
+	if userID, _ := currentUser(c); userID != billing.PayerID {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: "only the borrower can pay off a loan"})
+		return
+	}
+
+	loan, err := h.payOffLoan(loan, time.Now(), audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	billing, err = h.getBillingByID(loan.BillingID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := h.allocateLoan(&loan, billing, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, loan)
+}
+
+func (h *Handlers) createLoan(input request.LoanInput, meta audit.Meta) (models.Loan, error) {
+	if input.PayerID == input.ReceiverID {
+		return models.Loan{}, fmt.Errorf("payer and receiver must be different users")
+	}
+	for _, ID := range []int32{input.PayerID, input.ReceiverID} {
+		if _, err := h.users.Get(ID); err != nil {
+			return models.Loan{}, fmt.Errorf("user %d not found", ID)
+		}
+	}
+
+	firstDue, err := time.Parse("2006-01-02", input.FirstDueDate)
+	if err != nil {
+		return models.Loan{}, fmt.Errorf("first_due_date must be in the YYYY-MM-DD format")
+	}
+	principal, err := input.Principal.In(currency.Default)
+	if err != nil {
+		return models.Loan{}, err
+	}
+	rows, err := amortization.Schedule(input.System, principal, int64(input.MonthlyRateBP), int(input.Term), firstDue)
+	if err != nil {
+		return models.Loan{}, err
+	}
+	total := money.Amount(0)
+	for _, row := range rows {
+		if total, err = total.Add(row.Payment); err != nil {
+			return models.Loan{}, err
+		}
+	}
+
+	// A cobrança, o empréstimo e as parcelas são gravados na mesma transação,
+	// para que uma falha no empréstimo não deixe uma cobrança solta.
+	var billing models.Billing
+	var loan models.Loan
+	err = h.repos.Transaction(func(tx repository.Repositories) error {
+		var err error
+		if billing, err = pairBilling(tx.Billings, input.PayerID, input.ReceiverID, meta); err != nil {
+			return fmt.Errorf("error creating billing: %w", err)
+		}
+
+		loan = models.Loan{
+			BillingID:     billing.ID,
+			Principal:     principal,
+			MonthlyRateBP: input.MonthlyRateBP,
+			Term:          input.Term,
+			System:        input.System,
+			FirstDueDate:  firstDue,
+			Status:        models.LoanActive,
+			CreatedBy:     meta.ActorID,
+			CreatedAt:     time.Now(),
+		}
+		for _, row := range rows {
+			loan.Installments = append(loan.Installments, models.LoanInstallment{
+				Number:    int32(row.Number),
+				DueDate:   row.DueDate,
+				Payment:   row.Payment,
+				Interest:  row.Interest,
+				Principal: row.Principal,
+				Balance:   row.Balance,
+			})
+		}
+
+		if err := tx.Loans.Create(&loan, meta); err != nil {
+			return fmt.Errorf("error creating loan: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Loan{}, err
+	}
+
+	if err := h.allocateLoan(&loan, billing, time.Now()); err != nil {
+		return models.Loan{}, err
+	}
+	return loan, nil
+}
+
+// payOffLoan encerra o empréstimo: as parcelas ainda não lançadas saem da
+// tabela e dão lugar a uma parcela de quitação com o saldo devedor, lançada
+// na cobrança com vencimento em now.
+func (h *Handlers) payOffLoan(loan models.Loan, now time.Time, meta audit.Meta) (models.Loan, error) {
+	if loan.Status != models.LoanActive {
+		return loan, repository.ErrLoanNotActive
+	}
+
+	balance, number := loan.Principal, int32(0)
+	for _, item := range loan.Installments {
+		if item.ChargeID != nil {
+			balance, number = item.Balance, item.Number
+		}
+	}
+
+	paidOff := loan
+	err := h.repos.Transaction(func(tx repository.Repositories) error {
+		var payoff *models.LoanInstallment
+		if balance > 0 {
+			billing, err := tx.Billings.Get(loan.BillingID)
+			if err != nil {
+				return err
+			}
+			charge, err := createCharge(tx.Billings, billing, request.ChargeInput{
+				Amount:      money.Minor(balance),
+				Description: fmt.Sprintf("Empréstimo %d: quitação antecipada", loan.ID),
+				Date:        now.Format("2006-01-02"),
+				DueDate:     now.Format("2006-01-02"),
+			}, meta)
+			if err != nil {
+				return err
+			}
+
+			payoff = &models.LoanInstallment{
+				Number:    number + 1,
+				DueDate:   time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+				Payment:   balance,
+				Principal: balance,
+				ChargeID:  &charge.ID,
+			}
+		}
+		return tx.Loans.PayOff(&paidOff, payoff, now, meta)
+	})
+	if errors.Is(err, repository.ErrLoanNotActive) {
+		return loan, err
+	}
+	if err != nil {
+		return loan, fmt.Errorf("error paying off loan: %w", err)
+	}
+	return paidOff, nil
+}
+
+// PostDueLoanInstallments lança na cobrança as parcelas de empréstimos ativos
+// vencidas até now e devolve quantas foram lançadas. Cada lançamento tem a
+// data e o vencimento da parcela. Um erro numa parcela não impede as outras.
+func (h *Handlers) PostDueLoanInstallments(now time.Time) (int, error) {
+	due, err := h.repos.Loans.DueInstallments(now)
+	if err != nil {
+		return 0, fmt.Errorf("error loading loan installments: %w", err)
+	}
+
+	posted := 0
+	var errs []error
+	for _, item := range due {
+		err := h.postLoanInstallment(item)
+		if errors.Is(err, repository.ErrInstallmentPosted) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error posting installment %d of loan %d: %w", item.Number, item.LoanID, err))
+			continue
+		}
+		posted++
+	}
+	return posted, errors.Join(errs...)
+}
+
+// PostDueLoanInstallmentsEvery roda PostDueLoanInstallments na partida e
+// depois a cada intervalo, com a data de referência lida de clock.
+func (h *Handlers) PostDueLoanInstallmentsEvery(clock recurring.Clock, interval time.Duration) {
+	run := func() {
+		if _, err := h.PostDueLoanInstallments(clock.Now()); err != nil {
+			log.Println(err)
+		}
+	}
+
+	run()
+	for range time.Tick(interval) {
+		run()
+	}
+}
+
+// postLoanInstallment lança a parcela numa transação. O ChargeID só é gravado
+// se a parcela continua sem lançamento e o empréstimo ativo; senão tudo é
+// desfeito.
+func (h *Handlers) postLoanInstallment(item models.LoanInstallment) error {
+	return h.repos.Transaction(func(tx repository.Repositories) error {
+		loan, err := tx.Loans.Get(item.LoanID)
+		if err != nil {
+			return err
+		}
+		if loan.Status != models.LoanActive {
+			return repository.ErrInstallmentPosted
+		}
+		billing, err := tx.Billings.Get(loan.BillingID)
+		if err != nil {
+			return err
+		}
+
+		charge, err := createCharge(tx.Billings, billing, request.ChargeInput{
+			Amount:      money.Minor(item.Payment),
+			Description: fmt.Sprintf("Empréstimo %d: parcela %d/%d", loan.ID, item.Number, loan.Term),
+			Date:        item.DueDate.Format("2006-01-02"),
+			DueDate:     item.DueDate.Format("2006-01-02"),
+		}, audit.System)
+		if err != nil {
+			return err
+		}
+		return tx.Loans.SetInstallmentCharge(item.ID, charge.ID)
+	})
+}
+
+// allocateLoan abate das parcelas lançadas o que os pagamentos da cobrança
+// feitos até asOf quitaram dos lançamentos delas e calcula o valor de
+// quitação. Os pagamentos quitam os lançamentos da cobrança do vencimento
+// mais antigo para o mais novo, como nos encargos por atraso, então cada
+// empréstimo só recebe o que coube às suas parcelas.
+func (h *Handlers) allocateLoan(loan *models.Loan, billing models.Billing, asOf time.Time) error {
+	statement, err := h.billings.WithPenalties(billing, asOf)
+	if err != nil {
+		return err
+	}
+	unpaid := make(map[int32]money.Amount, len(statement.Charges))
+	for _, charge := range statement.Charges {
+		unpaid[charge.ChargeID] = charge.Unpaid
+	}
+
+	items := make([]installment.Installment, len(loan.Installments))
+	var posted, paid money.Amount
+	remaining := loan.Principal
+	for i, item := range loan.Installments {
+		items[i] = installment.Installment{Number: int(item.Number), Amount: item.Payment, DueDate: item.DueDate}
+		if item.ChargeID == nil {
+			continue
+		}
+		if charge, ok := unpaid[*item.ChargeID]; ok {
+			items[i].Paid = item.Payment - charge
+		}
+		if posted, err = posted.Add(item.Payment); err != nil {
+			return err
+		}
+		if paid, err = paid.Add(items[i].Paid); err != nil {
+			return err
+		}
+		remaining = item.Balance
+	}
+	installment.Classify(items, asOf)
+
+	for i := range items {
+		loan.Installments[i].Paid = items[i].Paid
+		loan.Installments[i].Status = items[i].Status
+	}
+	loan.Paid = paid
+	loan.PayoffAmount = max(posted+remaining-paid, 0)
+	return nil
+}
+
+// requireLoanParty carrega o empréstimo do parâmetro id, com a tabela e a
+// cobrança, e garante que o usuário autenticado é o tomador ou o credor.
+func (h *Handlers) requireLoanParty(c *gin.Context) (models.Loan, models.Billing, bool) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid loan ID"})
+		return models.Loan{}, models.Billing{}, false
+	}
+
+	loan, err := h.repos.Loans.Get(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Loan not found"})
+		return loan, models.Billing{}, false
+	}
+
+	billing, err := h.getBillingByID(loan.BillingID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return loan, billing, false
+	}
+	if _, ok := requireBillingParty(c, billing); !ok {
+		return loan, billing, false
+	}
+	return loan, billing, true
+}
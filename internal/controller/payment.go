@@ -1,66 +1,147 @@
 package controller
 
 import (
+	"me-pague/internal/audit"
 	"me-pague/internal/controller/request"
 	"me-pague/internal/controller/response"
-	"me-pague/internal/db"
+	"me-pague/internal/currency"
 	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/pix"
+	"me-pague/internal/service"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 	"github.com/gin-gonic/gin"
 )
 
 
 // CreatePayment godoc
 // @Summary Registra um novo pagamento e atualiza o saldo
+// @Description Com apply_to_net, o pagamento é lançado do devedor líquido para o credor entre as duas partes da cobrança e não pode passar do saldo líquido.
+// @Description O que passar do valor em aberto fica registrado como crédito, a menos que reject_overpayment seja informado.
+// @Description Quando a confirmação pelo recebedor está ativa, o pagamento registrado pelo pagador fica pendente e só conta depois de confirmado.
+// @Description Com pix_payload, o BR Code colado identifica a cobrança pelo TXID e traz o valor; billing_id e amount, se informados, precisam bater com ele.
+// @Description Com currency diferente da moeda da cobrança, amount é convertido pela cotação do dia; o valor original, a cotação e o arredondamento ficam no pagamento.
 // @Tags Pagamentos
 // @Accept json
 // @Produce json
 // @Param payment body request.PaymentInput true "Dados do pagamento"
 // @Success 200 {object} request.PaymentInput
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Security BearerAuth
 // @Router /payment [post]
-func CreatePayment(c *gin.Context) {
+func (h *Handlers) CreatePayment(c *gin.Context) {
 	var input request.PaymentInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	billing, err := getBillingByID(input.BillingID)
+	var brCode pix.Parsed
+	if input.PixPayload != "" {
+		var err error
+		input, brCode, err = applyPixPayload(input)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	billing, err := h.billings.Get(input.BillingID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Billing not found"})
 		return
 	}
 
-	payment, err := createPayment(input, billing)
+	if _, ok := requireBillingParty(c, billing); !ok {
+		return
+	}
+
+	if input.PixPayload != "" {
+		if err := h.checkPixReceiver(brCode, billing); err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	var conversion *currency.Conversion
+	input, conversion, err = h.convertPayment(input, billing, time.Now())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Error creating payment: " + err.Error()})
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	billing.Amount += payment.Amount
-	if err := db.DB.Save(&billing).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error updating billing amount: " + err.Error()})
+	meta := audit.FromContext(c)
+	if input.ApplyToNet {
+		amount, err := input.Amount.In(billing.Currency)
+		if err == nil {
+			billing, err = h.netBilling(billing, amount, meta)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	payment, err := h.payments.Create(service.NewPayment{Amount: input.Amount, RejectOverpayment: input.RejectOverpayment}, billing, conversion, meta)
+	if errors.Is(err, ErrBillingConflict) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Error creating payment: " + err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, payment)
 }
 
-func createPayment(input request.PaymentInput, billing models.Billing) (models.Payment, error) {
-	if input.Amount <= 0 {
-		return models.Payment{}, fmt.Errorf("amount must be greater than zero")
+// convertPayment leva o valor pago em outra moeda para a moeda da cobrança,
+// pela cotação da data. Sem currency, ou com a mesma da cobrança, devolve o
+// pedido como veio e conversion nula.
+func (h *Handlers) convertPayment(input request.PaymentInput, billing models.Billing, date time.Time) (request.PaymentInput, *currency.Conversion, error) {
+	code, err := currency.Normalize(input.Currency)
+	if err != nil {
+		return input, nil, err
+	}
+	if input.Currency == "" || code == billing.Currency {
+		input.Currency = billing.Currency
+		return input, nil, nil
+	}
+	amount, err := input.Amount.In(code)
+	if err != nil {
+		return input, nil, err
+	}
+	if amount <= 0 {
+		return input, nil, fmt.Errorf("amount must be greater than zero")
 	}
 
-	var payment models.Payment
-	payment.PayerID = billing.PayerID
-	payment.BillingID = billing.ID
-	payment.Amount = input.Amount
+	conversion, err := currency.Convert(h.rates, amount, code, billing.Currency, date)
+	if err != nil {
+		return input, nil, err
+	}
+	input.Amount, input.Currency = money.Minor(conversion.Converted), billing.Currency
+	return input, &conversion, nil
+}
+
+// netBilling devolve a cobrança do devedor líquido para o credor entre as
+// partes de billing, desde que amount caiba no saldo líquido.
+func (h *Handlers) netBilling(billing models.Billing, amount money.Amount, meta audit.Meta) (models.Billing, error) {
+	balance, err := h.billings.PairBalance(billing.PayerID, billing.ReceiverID, billing.Currency)
+	if err != nil {
+		return billing, err
+	}
 
-	if err := db.DB.Create(&payment).Error; err != nil {
-		return payment, fmt.Errorf("error creating payment: %w", err)
+	if balance.Net == 0 {
+		return billing, fmt.Errorf("there is no net balance to settle between users %d and %d", billing.PayerID, billing.ReceiverID)
+	}
+	if amount > balance.Net {
+		return billing, fmt.Errorf("amount exceeds the net balance of %d", balance.Net)
 	}
 
-	return payment, nil
+	return h.billings.GetOrCreate(service.BillingKey{PayerID: balance.DebtorID, ReceiverID: balance.CreditorID, Currency: billing.Currency}, meta)
 }
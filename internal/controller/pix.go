@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/currency"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/pix"
+	"me-pague/internal/qr"
+	"me-pague/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errPixCurrency indica uma cobrança em outra moeda: o BR Code é sempre em
+// reais.
+var errPixCurrency = fmt.Errorf("pix is only available for %s billings", currency.Default)
+
+// pixTxIDPrefix antecede o ID da cobrança no TXID dos códigos gerados aqui.
+const pixTxIDPrefix = "MEPAGUE"
+
+// defaultQRScale é quantos pixels cada módulo do QR Code ocupa no PNG.
+const defaultQRScale = 8
+
+// GetBillingPix godoc
+// @Summary Gera o Pix copia e cola do valor em aberto da cobrança
+// @Description O BR Code estático leva a chave Pix do recebedor, o valor em aberto, o nome e a cidade do recebedor e um TXID que identifica a cobrança.
+// @Description Com format=png ou format=svg, devolve o QR Code do mesmo código.
+// @Tags Cobranças
+// @Produce json
+// @Produce png
+// @Produce image/svg+xml
+// @Param id path int true "ID da cobrança"
+// @Param key query string false "Chave Pix do recebedor (padrão: chave padrão do recebedor)"
+// @Param city query string true "Cidade do recebedor"
+// @Param name query string false "Nome do recebedor (padrão: nome do usuário)"
+// @Param format query string false "json (padrão), png ou svg"
+// @Param scale query int false "Pixels por módulo no PNG (padrão 8)"
+// @Success 200 {object} response.PixResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /billing/{id}/pix [get]
+func (h *Handlers) GetBillingPix(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid billing ID"})
+		return
+	}
+
+	billing, err := h.getBillingByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, ok := requireBillingParty(c, billing); !ok {
+		return
+	}
+
+	if billing.Currency != currency.Default {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: errPixCurrency.Error()})
+		return
+	}
+	if billing.Outstanding <= 0 {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "billing has no outstanding amount"})
+		return
+	}
+
+	name := c.Query("name")
+	if name == "" {
+		receiver, err := h.users.Get(billing.ReceiverID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Receiver not found"})
+			return
+		}
+		name = receiver.Name
+	}
+
+	key := c.Query("key")
+	if key == "" {
+		defaultKey, err := h.repos.PixKeys.Default(billing.ReceiverID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "receiver has no default pix key; inform the key"})
+			return
+		}
+		key = defaultKey.Key
+	}
+
+	result := response.PixResponse{BillingID: billing.ID, TxID: billingTxID(billing.ID), Amount: billing.Outstanding}
+	result.Payload, err = pix.Payload{
+		Key:          key,
+		MerchantName: name,
+		MerchantCity: c.Query("city"),
+		Amount:       billing.Outstanding,
+		TxID:         result.TxID,
+	}.Encode()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	switch format := c.DefaultQuery("format", "json"); format {
+	case "json":
+		c.JSON(http.StatusOK, result)
+	case "png", "svg":
+		renderPixQRCode(c, result.Payload, format)
+	default:
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "format must be json, png or svg"})
+	}
+}
+
+func renderPixQRCode(c *gin.Context, payload, format string) {
+	code, err := qr.Encode([]byte(payload), qr.M)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if format == "svg" {
+		c.Data(http.StatusOK, "image/svg+xml", []byte(code.SVG()))
+		return
+	}
+
+	scale := defaultQRScale
+	if raw := c.Query("scale"); raw != "" {
+		scale, err = strconv.Atoi(raw)
+		if err != nil || scale < 1 || scale > 32 {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "scale must be between 1 and 32"})
+			return
+		}
+	}
+
+	image, err := code.PNG(scale)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", image)
+}
+
+// billingTxID é o TXID do BR Code de uma cobrança.
+func billingTxID(billingID int32) string {
+	return fmt.Sprintf("%s%d", pixTxIDPrefix, billingID)
+}
+
+// parseBillingTxID lê o ID da cobrança de um TXID gerado por billingTxID.
+func parseBillingTxID(txid string) (int32, bool) {
+	digits, ok := strings.CutPrefix(strings.ToUpper(txid), pixTxIDPrefix)
+	if !ok {
+		return 0, false
+	}
+	ID, err := strconv.ParseUint(digits, 10, 31)
+	if err != nil || ID == 0 {
+		return 0, false
+	}
+	return int32(ID), true
+}
+
+// applyPixPayload lê o BR Code do pagamento e completa a cobrança e o valor a
+// partir dele. O que vier preenchido no pedido precisa bater com o código.
+func applyPixPayload(input request.PaymentInput) (request.PaymentInput, pix.Parsed, error) {
+	brCode, err := pix.Parse(input.PixPayload)
+	if err != nil {
+		return input, brCode, err
+	}
+
+	billingID, ok := parseBillingTxID(brCode.TxID)
+	if !ok {
+		return input, brCode, fmt.Errorf("pix payload TXID %q does not identify a billing", brCode.TxID)
+	}
+	if input.BillingID != 0 && input.BillingID != billingID {
+		return input, brCode, fmt.Errorf("pix payload is for billing %d, not %d", billingID, input.BillingID)
+	}
+	input.BillingID = billingID
+
+	amount, err := input.Amount.In(currency.Default)
+	if err != nil {
+		return input, brCode, err
+	}
+	switch {
+	case brCode.Amount == 0 && amount == 0:
+		return input, brCode, fmt.Errorf("pix payload has no amount; inform the amount paid")
+	case brCode.Amount == 0:
+	case amount == 0:
+		input.Amount = money.Minor(brCode.Amount)
+	case amount != brCode.Amount:
+		return input, brCode, fmt.Errorf("amount %d does not match the pix payload amount %d", amount, brCode.Amount)
+	}
+
+	// Aplicar ao líquido poderia mudar a cobrança que o TXID identifica.
+	if input.ApplyToNet {
+		return input, brCode, fmt.Errorf("apply_to_net cannot be used with a pix payload")
+	}
+	if code, err := currency.Normalize(input.Currency); err != nil || code != currency.Default {
+		return input, brCode, errPixCurrency
+	}
+	return input, brCode, nil
+}
+
+// checkPixReceiver confere se o BR Code paga o recebedor da cobrança. Uma
+// chave cadastrada precisa ser dele; sem cadastro, vale o nome como ele sai
+// no campo 59.
+func (h *Handlers) checkPixReceiver(brCode pix.Parsed, billing models.Billing) error {
+	if billing.Currency != currency.Default {
+		return errPixCurrency
+	}
+
+	key, err := h.repos.PixKeys.GetByKey(brCode.Key)
+	if err == nil {
+		if key.UserID != billing.ReceiverID {
+			return fmt.Errorf("pix payload key belongs to another user, not the billing receiver")
+		}
+		return nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	receiver, err := h.users.Get(billing.ReceiverID)
+	if err != nil {
+		return fmt.Errorf("receiver not found")
+	}
+	if !strings.EqualFold(brCode.MerchantName, pix.MerchantName(receiver.Name)) {
+		return fmt.Errorf("pix payload receiver %q does not match the billing receiver", brCode.MerchantName)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/models"
+	"me-pague/internal/pix"
+	"me-pague/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrPixKeyTaken indica que a chave já está cadastrada, pelo mesmo usuário ou
+// por outro.
+var ErrPixKeyTaken = repository.ErrPixKeyTaken
+
+// CreatePixKey godoc
+// @Summary Cadastra uma chave Pix do usuário
+// @Description Tipos: cpf, cnpj, email, phone (E.164) e evp (UUID). CPF e CNPJ têm os dígitos verificadores conferidos.
+// @Description A primeira chave do usuário vira a padrão; com default, a nova chave passa a ser a padrão no lugar da anterior.
+// @Tags Chaves Pix
+// @Accept json
+// @Produce json
+// @Param key body request.PixKeyInput true "Dados da chave"
+// @Success 201 {object} models.PixKey
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /pix-keys [post]
+func (h *Handlers) CreatePixKey(c *gin.Context) {
+	var input request.PixKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.createPixKey(userID, input, audit.FromContext(c))
+	if errors.Is(err, ErrPixKeyTaken) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListPixKeys godoc
+// @Summary Lista as chaves Pix do usuário, a padrão primeiro
+// @Tags Chaves Pix
+// @Produce json
+// @Success 200 {array} models.PixKey
+// @Security BearerAuth
+// @Router /pix-keys [get]
+func (h *Handlers) ListPixKeys(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.repos.PixKeys.ListByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error loading pix keys: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// SetDefaultPixKey godoc
+// @Summary Torna a chave Pix a padrão do usuário
+// @Tags Chaves Pix
+// @Produce json
+// @Param id path int true "ID da chave"
+// @Success 200 {object} models.PixKey
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /pix-keys/{id}/default [post]
+func (h *Handlers) SetDefaultPixKey(c *gin.Context) {
+	key, ok := h.requirePixKeyOwner(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.setDefaultPixKey(key, audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// DeletePixKey godoc
+// @Summary Remove uma chave Pix do usuário
+// @Description Se a chave removida era a padrão, a mais antiga das restantes assume.
+// @Tags Chaves Pix
+// @Param id path int true "ID da chave"
+// @Success 204
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /pix-keys/{id} [delete]
+func (h *Handlers) DeletePixKey(c *gin.Context) {
+	key, ok := h.requirePixKeyOwner(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deletePixKey(key, audit.FromContext(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) createPixKey(userID int32, input request.PixKeyInput, meta audit.Meta) (models.PixKey, error) {
+	normalized, err := pix.NormalizeKey(input.Type, input.Key)
+	if err != nil {
+		return models.PixKey{}, err
+	}
+
+	key := models.PixKey{UserID: userID, Type: input.Type, Key: normalized, IsDefault: input.Default, CreatedAt: time.Now()}
+	if err := h.repos.PixKeys.Create(&key, meta); err != nil {
+		return models.PixKey{}, err
+	}
+	return key, nil
+}
+
+func (h *Handlers) setDefaultPixKey(key models.PixKey, meta audit.Meta) (models.PixKey, error) {
+	if err := h.repos.PixKeys.SetDefault(&key, meta); err != nil {
+		return models.PixKey{}, fmt.Errorf("error updating pix key: %w", err)
+	}
+	return key, nil
+}
+
+func (h *Handlers) deletePixKey(key models.PixKey, meta audit.Meta) error {
+	if err := h.repos.PixKeys.Delete(key, meta); err != nil {
+		return fmt.Errorf("error deleting pix key: %w", err)
+	}
+	return nil
+}
+
+// requirePixKeyOwner carrega a chave do parâmetro id e garante que ela é do
+// usuário autenticado.
+func (h *Handlers) requirePixKeyOwner(c *gin.Context) (models.PixKey, bool) {
+	var key models.PixKey
+
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid pix key ID"})
+		return key, false
+	}
+
+	userID, ok := currentUser(c)
+	if !ok {
+		return key, false
+	}
+
+	key, err = h.repos.PixKeys.Get(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Pix key not found"})
+		return key, false
+	}
+	if key.UserID != userID {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: "you do not own this pix key"})
+		return key, false
+	}
+	return key, true
+}
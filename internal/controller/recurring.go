@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/currency"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/recurring"
+	"me-pague/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateRecurringBilling godoc
+// @Summary Cria uma cobrança recorrente
+// @Description A cada período (daily, weekly ou monthly, a cada interval unidades) o agendador lança amount na cobrança entre pagador e recebedor, de start_date até end_date, se informada.
+// @Description Na regra mensal o dia é o de start_date, ou o último do mês quando ele não existe.
+// @Description start_date pode estar no passado, com até 366 períodos já vencidos, que saem na próxima rodada do agendador.
+// @Tags Cobranças recorrentes
+// @Accept json
+// @Produce json
+// @Param recurring body request.RecurringBillingInput true "Dados da recorrência"
+// @Success 201 {object} models.RecurringBilling
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /recurring-billings [post]
+func (h *Handlers) CreateRecurringBilling(c *gin.Context) {
+	var input request.RecurringBillingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if userID != input.PayerID && userID != input.ReceiverID {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: ErrNotBillingParty.Error()})
+		return
+	}
+
+	template, err := h.createRecurringBilling(input, audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// ListRecurringBillings godoc
+// @Summary Lista as cobranças recorrentes das quais o usuário faz parte
+// @Tags Cobranças recorrentes
+// @Produce json
+// @Success 200 {array} models.RecurringBilling
+// @Security BearerAuth
+// @Router /recurring-billings [get]
+func (h *Handlers) ListRecurringBillings(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	templates, err := h.repos.Recurring.ListByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error loading recurring billings: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// ListRecurringRuns godoc
+// @Summary Lista os períodos já lançados de uma cobrança recorrente
+// @Tags Cobranças recorrentes
+// @Produce json
+// @Param id path int true "ID da recorrência"
+// @Success 200 {array} models.RecurringRun
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /recurring-billings/{id}/runs [get]
+func (h *Handlers) ListRecurringRuns(c *gin.Context) {
+	template, ok := h.requireRecurringParty(c)
+	if !ok {
+		return
+	}
+
+	runs, err := h.repos.Recurring.Runs(template.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error loading runs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// CancelRecurringBilling godoc
+// @Summary Cancela uma cobrança recorrente
+// @Description Os períodos já lançados continuam na cobrança; nenhum outro é criado.
+// @Tags Cobranças recorrentes
+// @Produce json
+// @Param id path int true "ID da recorrência"
+// @Success 200 {object} models.RecurringBilling
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /recurring-billings/{id}/cancel [post]
+func (h *Handlers) CancelRecurringBilling(c *gin.Context) {
+	template, ok := h.requireRecurringParty(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.cancelRecurringBilling(template, time.Now(), audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (h *Handlers) createRecurringBilling(input request.RecurringBillingInput, meta audit.Meta) (models.RecurringBilling, error) {
+	if input.PayerID == input.ReceiverID {
+		return models.RecurringBilling{}, fmt.Errorf("payer and receiver must be different users")
+	}
+	amount, err := input.Amount.In(currency.Default)
+	if err != nil {
+		return models.RecurringBilling{}, err
+	}
+	if amount <= 0 {
+		return models.RecurringBilling{}, fmt.Errorf("amount must be greater than zero")
+	}
+	for _, ID := range []int32{input.PayerID, input.ReceiverID} {
+		if _, err := h.users.Get(ID); err != nil {
+			return models.RecurringBilling{}, fmt.Errorf("user %d not found", ID)
+		}
+	}
+
+	template := models.RecurringBilling{
+		PayerID:     input.PayerID,
+		ReceiverID:  input.ReceiverID,
+		Amount:      amount,
+		Description: input.Description,
+		Frequency:   input.Frequency,
+		Interval:    max(input.Interval, 1),
+		CreatedBy:   meta.ActorID,
+		CreatedAt:   time.Now(),
+	}
+
+	template.StartDate, err = time.Parse(recurring.DateLayout, input.StartDate)
+	if err != nil {
+		return models.RecurringBilling{}, fmt.Errorf("start_date must be in the YYYY-MM-DD format")
+	}
+	if input.EndDate != "" {
+		end, err := time.Parse(recurring.DateLayout, input.EndDate)
+		if err != nil {
+			return models.RecurringBilling{}, fmt.Errorf("end_date must be in the YYYY-MM-DD format")
+		}
+		template.EndDate = &end
+	}
+
+	rule := recurringRule(template)
+	if err := rule.Validate(); err != nil {
+		return models.RecurringBilling{}, err
+	}
+	if err := rule.CheckStart(template.CreatedAt); err != nil {
+		return models.RecurringBilling{}, err
+	}
+	first := rule.Occurrence(0)
+	template.NextDate = &first
+
+	if err := h.repos.Recurring.Create(&template, meta); err != nil {
+		return models.RecurringBilling{}, fmt.Errorf("error creating recurring billing: %w", err)
+	}
+	return template, nil
+}
+
+func (h *Handlers) cancelRecurringBilling(template models.RecurringBilling, now time.Time, meta audit.Meta) (models.RecurringBilling, error) {
+	if template.CanceledAt != nil {
+		return template, nil
+	}
+
+	canceled := template
+	if err := h.repos.Recurring.Cancel(&canceled, now, meta); err != nil {
+		return template, fmt.Errorf("error canceling recurring billing: %w", err)
+	}
+	return canceled, nil
+}
+
+// RunRecurringBillings lança os períodos vencidos até now e devolve quantos
+// foram lançados. Depois de uma parada, os atrasados saem de uma vez, cada um
+// com a data do seu período, até recurring.MaxCatchUp por recorrência; o
+// resto sai nas rodadas seguintes. Um erro numa recorrência não impede as
+// outras.
+func (h *Handlers) RunRecurringBillings(now time.Time) (int, error) {
+	due, err := h.repos.Recurring.Due(now)
+	if err != nil {
+		return 0, fmt.Errorf("error loading recurring billings: %w", err)
+	}
+
+	created := 0
+	var errs []error
+	for _, template := range due {
+		n, err := h.runRecurringBilling(template, now)
+		created += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error running recurring billing %d: %w", template.ID, err))
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+// RunRecurringBillingsEvery roda RunRecurringBillings na partida, para pôr
+// em dia o que venceu com o servidor parado, e depois a cada intervalo.
+func (h *Handlers) RunRecurringBillingsEvery(clock recurring.Clock, interval time.Duration) {
+	run := func() {
+		if _, err := h.RunRecurringBillings(clock.Now()); err != nil {
+			log.Println(err)
+		}
+	}
+
+	run()
+	for range time.Tick(interval) {
+		run()
+	}
+}
+
+// runRecurringBilling lança os períodos vencidos de uma recorrência, um por
+// transação. O avanço de NextPeriod só acontece se ninguém o mudou desde a
+// leitura, e o índice único de RecurringRun barra o que escapar disso.
+func (h *Handlers) runRecurringBilling(template models.RecurringBilling, now time.Time) (int, error) {
+	rule := recurringRule(template)
+
+	created := 0
+	for _, n := range rule.Due(int(template.NextPeriod), now) {
+		date := rule.Occurrence(n)
+		var next *time.Time
+		if !rule.Ended(n + 1) {
+			nextDate := rule.Occurrence(n + 1)
+			next = &nextDate
+		}
+
+		err := h.repos.Transaction(func(tx repository.Repositories) error {
+			if err := tx.Recurring.Advance(template.ID, n, next); err != nil {
+				return err
+			}
+
+			billing, err := pairBilling(tx.Billings, template.PayerID, template.ReceiverID, audit.System)
+			if err != nil {
+				return err
+			}
+			charge, err := createCharge(tx.Billings, billing, request.ChargeInput{
+				Amount:      money.Minor(template.Amount),
+				Description: template.Description,
+				Date:        date.Format(recurring.DateLayout),
+				DueDate:     date.Format(recurring.DateLayout),
+			}, audit.System)
+			if err != nil {
+				return err
+			}
+
+			return tx.Recurring.AddRun(&models.RecurringRun{
+				RecurringBillingID: template.ID,
+				Period:             int32(n),
+				PeriodDate:         date,
+				BillingID:          billing.ID,
+				ChargeID:           charge.ID,
+				CreatedAt:          now,
+			})
+		})
+		if errors.Is(err, repository.ErrPeriodTaken) {
+			return created, nil
+		}
+		if err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+func recurringRule(template models.RecurringBilling) recurring.Rule {
+	return recurring.Rule{
+		Frequency: template.Frequency,
+		Interval:  int(template.Interval),
+		Start:     template.StartDate,
+		End:       template.EndDate,
+	}
+}
+
+// requireRecurringParty carrega a recorrência do parâmetro id e garante que
+// o usuário autenticado é o pagador ou o recebedor.
+func (h *Handlers) requireRecurringParty(c *gin.Context) (models.RecurringBilling, bool) {
+	var template models.RecurringBilling
+
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid recurring billing ID"})
+		return template, false
+	}
+
+	userID, ok := currentUser(c)
+	if !ok {
+		return template, false
+	}
+
+	template, err = h.repos.Recurring.Get(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Recurring billing not found"})
+		return template, false
+	}
+	if userID != template.PayerID && userID != template.ReceiverID {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: ErrNotBillingParty.Error()})
+		return template, false
+	}
+	return template, true
+}
@@ -1,6 +1,7 @@
 package request
 
 type BillingInput struct {
-	PayerID    int32 `json:"payer_id" example:"1"`
-	ReceiverID int32 `json:"receiver_id" example:"2"`
-}
\ No newline at end of file
+	PayerID    int32  `json:"payer_id" example:"1"`
+	ReceiverID int32  `json:"receiver_id" example:"2"`
+	Currency   string `json:"currency" example:"BRL"`
+}
@@ -0,0 +1,11 @@
+package request
+
+import "me-pague/internal/money"
+
+type ChargeInput struct {
+	Amount      money.Input `json:"amount" swaggertype:"string" example:"15.00"`
+	Currency    string      `json:"currency" example:"BRL"`
+	Description string      `json:"description" example:"Conta de luz"`
+	Date        string      `json:"date" example:"2025-06-01"`
+	DueDate     string      `json:"due_date" example:"2025-06-10"`
+}
@@ -1,5 +1,6 @@
 package request
 
 type CreateUserInput struct {
-	Name string `json:"name" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required"`
 }
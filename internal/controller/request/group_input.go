@@ -0,0 +1,21 @@
+package request
+
+import "me-pague/internal/money"
+
+type CreateGroupInput struct {
+	Name      string  `json:"name" binding:"required" example:"Viagem"`
+	MemberIDs []int32 `json:"member_ids" binding:"required" example:"1,2,3"`
+}
+
+type ExpenseShareInput struct {
+	UserID int32 `json:"user_id" example:"2"`
+	Value  int64 `json:"value" example:"1"`
+}
+
+type GroupExpenseInput struct {
+	PayerID     int32               `json:"payer_id" example:"1"`
+	Total       money.Input         `json:"total" swaggertype:"string" example:"10.00"`
+	Description string              `json:"description" example:"Jantar"`
+	Split       string              `json:"split" example:"equal"`
+	Shares      []ExpenseShareInput `json:"shares"`
+}
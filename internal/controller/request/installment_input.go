@@ -0,0 +1,9 @@
+package request
+
+import "me-pague/internal/money"
+
+type InstallmentPlanInput struct {
+	Count        int32       `json:"count" binding:"required" example:"6"`
+	FirstDueDate string      `json:"first_due_date" binding:"required" example:"2025-07-10"`
+	Amount       money.Input `json:"amount" swaggertype:"string" example:"1200.00"`
+}
@@ -0,0 +1,13 @@
+package request
+
+import "me-pague/internal/money"
+
+type LoanInput struct {
+	PayerID       int32       `json:"payer_id" binding:"required" example:"1"`
+	ReceiverID    int32       `json:"receiver_id" binding:"required" example:"2"`
+	Principal     money.Input `json:"principal" swaggertype:"string" example:"1000.00"`
+	MonthlyRateBP int32       `json:"monthly_rate_bp" example:"100"`
+	Term          int32       `json:"term" binding:"required" example:"12"`
+	System        string      `json:"system" binding:"required" example:"price"`
+	FirstDueDate  string      `json:"first_due_date" binding:"required" example:"2025-07-10"`
+}
@@ -0,0 +1,10 @@
+package request
+
+type LoginInput struct {
+	Name     string `json:"name" binding:"required" example:"Antonio"`
+	Password string `json:"password" binding:"required" example:"s3nh4-f0rt3"`
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
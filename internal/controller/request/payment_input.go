@@ -1,6 +1,12 @@
 package request
 
+import "me-pague/internal/money"
+
 type PaymentInput struct {
-	BillingID    int32  `json:"billing_id" example:"2"`
-	Amount       int32  `json:"amount" example:"50"`
+	BillingID         int32       `json:"billing_id" example:"2"`
+	Amount            money.Input `json:"amount" swaggertype:"string" example:"0.50"`
+	Currency          string      `json:"currency" example:"USD"`
+	ApplyToNet        bool        `json:"apply_to_net" example:"false"`
+	RejectOverpayment bool        `json:"reject_overpayment" example:"false"`
+	PixPayload        string      `json:"pix_payload"`
 }
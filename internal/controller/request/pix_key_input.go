@@ -0,0 +1,7 @@
+package request
+
+type PixKeyInput struct {
+	Type    string `json:"type" binding:"required" example:"email"`
+	Key     string `json:"key" binding:"required" example:"ana@example.com"`
+	Default bool   `json:"default" example:"true"`
+}
@@ -0,0 +1,14 @@
+package request
+
+import "me-pague/internal/money"
+
+type RecurringBillingInput struct {
+	PayerID     int32       `json:"payer_id" binding:"required" example:"1"`
+	ReceiverID  int32       `json:"receiver_id" binding:"required" example:"2"`
+	Amount      money.Input `json:"amount" swaggertype:"string" example:"1500.00"`
+	Description string      `json:"description" example:"Aluguel"`
+	Frequency   string      `json:"frequency" binding:"required" example:"monthly"`
+	Interval    int32       `json:"interval" example:"1"`
+	StartDate   string      `json:"start_date" binding:"required" example:"2025-06-05"`
+	EndDate     string      `json:"end_date" example:"2026-05-05"`
+}
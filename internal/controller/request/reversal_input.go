@@ -0,0 +1,8 @@
+package request
+
+import "me-pague/internal/money"
+
+type ReversalInput struct {
+	Amount money.Input `json:"amount" swaggertype:"string" example:"0.50"`
+	Reason string      `json:"reason" binding:"required" example:"Pagamento lançado na cobrança errada"`
+}
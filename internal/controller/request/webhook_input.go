@@ -0,0 +1,7 @@
+package request
+
+type WebhookInput struct {
+	URL        string   `json:"url" binding:"required" example:"https://bot.example.com/me-pague"`
+	Secret     string   `json:"secret" binding:"required" example:"s3gr3d0-d0-b0t"`
+	EventTypes []string `json:"event_types" binding:"required" example:"payment.created,billing.created"`
+}
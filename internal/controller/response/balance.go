@@ -0,0 +1,19 @@
+package response
+
+import (
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+)
+
+// BalanceResponse é o saldo líquido entre dois usuários. Quando Net é zero,
+// DebtorID e CreditorID ficam vazios.
+type BalanceResponse struct {
+	UserA      int32            `json:"user_a"`
+	UserB      int32            `json:"user_b"`
+	Currency   string           `json:"currency"`
+	Net        money.Amount     `json:"net"`
+	DebtorID   int32            `json:"debtor_id,omitempty"`
+	CreditorID int32            `json:"creditor_id,omitempty"`
+	Billings   []models.Billing `json:"billings"`
+	Payments   []models.Payment `json:"payments"`
+}
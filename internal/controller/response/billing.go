@@ -0,0 +1,21 @@
+package response
+
+import (
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/penalty"
+)
+
+// BillingResponse é a cobrança com os encargos por atraso calculados na data
+// as_of. OriginalAmount é o principal em aberto; Penalties soma multa e
+// juros; TotalDue é o que o pagador deve naquela data.
+type BillingResponse struct {
+	models.Billing
+	AsOf           string               `json:"as_of"`
+	OriginalAmount money.Amount         `json:"original_amount"`
+	LateFee        money.Amount         `json:"late_fee"`
+	Interest       money.Amount         `json:"interest"`
+	Penalties      money.Amount         `json:"penalties"`
+	TotalDue       money.Amount         `json:"total_due"`
+	Charges        []penalty.Assessment `json:"charges"`
+}
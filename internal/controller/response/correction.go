@@ -0,0 +1,10 @@
+package response
+
+import "me-pague/internal/correction"
+
+// CorrectedBillingResponse é o saldo da cobrança corrigido por um índice, com
+// a conta mês a mês.
+type CorrectedBillingResponse struct {
+	BillingID int32 `json:"billing_id"`
+	correction.Result
+}
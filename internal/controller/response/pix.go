@@ -0,0 +1,12 @@
+package response
+
+import "me-pague/internal/money"
+
+// PixResponse é o BR Code ("copia e cola") do valor em aberto de uma
+// cobrança.
+type PixResponse struct {
+	BillingID int32        `json:"billing_id"`
+	TxID      string       `json:"txid"`
+	Amount    money.Amount `json:"amount"`
+	Payload   string       `json:"payload"`
+}
@@ -0,0 +1,10 @@
+package response
+
+import "me-pague/internal/settlement"
+
+type SettlementPlanResponse struct {
+	GroupID   int32                 `json:"group_id,omitempty"`
+	Currency  string                `json:"currency"`
+	Balances  []settlement.Balance  `json:"balances"`
+	Transfers []settlement.Transfer `json:"transfers"`
+}
@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrAlreadyReversed indica que o pagamento já foi estornado por completo.
+var ErrAlreadyReversed = errors.New("payment has already been fully reversed")
+
+// ReversePayment godoc
+// @Summary Estorna um pagamento, total ou parcialmente
+// @Description Sem amount, estorna o que ainda resta do pagamento. A soma dos estornos nunca passa do valor pago. Só o recebedor pode estornar.
+// @Description O estorno devolve primeiro o crédito que o pagamento deixou na cobrança (credit) e depois o valor que ele abateu.
+// @Tags Pagamentos
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do pagamento"
+// @Param reversal body request.ReversalInput true "Dados do estorno"
+// @Success 201 {object} models.PaymentReversal
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /payment/{id}/reverse [post]
+func (h *Handlers) ReversePayment(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid payment ID"})
+		return
+	}
+
+	var input request.ReversalInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	payment, err := h.getPaymentByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	billing, err := h.getBillingByID(payment.BillingID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	userID, ok := requireBillingParty(c, billing)
+	if !ok {
+		return
+	}
+	// Só confirmados são estornados, e a confirmação é do recebedor: o
+	// pagador não pode desfazê-la.
+	if userID != billing.ReceiverID {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: "only the receiver can reverse a payment"})
+		return
+	}
+
+	reversal, err := h.reversePayment(payment, billing, input, audit.FromContext(c))
+	if errors.Is(err, ErrAlreadyReversed) || errors.Is(err, ErrBillingConflict) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reversal)
+}
+
+// ListPayments godoc
+// @Summary Lista os pagamentos de uma cobrança com seus estornos
+// @Tags Pagamentos
+// @Produce json
+// @Param id path int true "ID da cobrança"
+// @Success 200 {array} models.Payment
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /billing/{id}/payments [get]
+func (h *Handlers) ListPayments(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid billing ID"})
+		return
+	}
+
+	billing, err := h.getBillingByID(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, ok := requireBillingParty(c, billing); !ok {
+		return
+	}
+
+	payments, err := h.repos.Payments.ListByBillings([]int32{billing.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error loading payments: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+func (h *Handlers) getPaymentByID(id int32) (models.Payment, error) {
+	payment, err := h.repos.Payments.Get(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return payment, fmt.Errorf("Payment not found")
+	}
+	return payment, err
+}
+
+// reversePayment valida o estorno e o grava pelo repositório, que reduz o
+// valor pago da cobrança e o crédito do pagamento na mesma operação. O
+// estorno consome primeiro o crédito do pagamento que a cobrança ainda tem.
+func (h *Handlers) reversePayment(payment models.Payment, billing models.Billing, input request.ReversalInput, meta audit.Meta) (models.PaymentReversal, error) {
+	if payment.Status != models.PaymentConfirmed {
+		return models.PaymentReversal{}, fmt.Errorf("only confirmed payments can be reversed, this one is %s", payment.Status)
+	}
+
+	var reversed money.Amount
+	for _, reversal := range payment.Reversals {
+		reversed += reversal.Amount
+	}
+	remaining := payment.Amount - reversed
+	if remaining <= 0 {
+		return models.PaymentReversal{}, ErrAlreadyReversed
+	}
+
+	amount, err := input.Amount.In(billing.Currency)
+	if err != nil {
+		return models.PaymentReversal{}, err
+	}
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount < 0 {
+		return models.PaymentReversal{}, fmt.Errorf("amount must be greater than zero")
+	}
+	if amount > remaining {
+		return models.PaymentReversal{}, fmt.Errorf("amount exceeds the %d left to reverse on this payment", remaining)
+	}
+
+	reversal := models.PaymentReversal{
+		PaymentID: payment.ID,
+		Amount:    amount,
+		Reason:    input.Reason,
+		CreatedAt: time.Now(),
+	}
+	credit := min(amount, payment.Credit, billing.Credit)
+	err = h.repos.Payments.Reverse(&reversal, payment, billing, credit, meta)
+	if errors.Is(err, ErrBillingConflict) {
+		return models.PaymentReversal{}, err
+	}
+	if err != nil {
+		return models.PaymentReversal{}, fmt.Errorf("error reversing payment: %w", err)
+	}
+
+	return reversal, nil
+}
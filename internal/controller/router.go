@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"me-pague/internal/auth"
+	"me-pague/internal/correction"
+	"me-pague/internal/currency"
+	"me-pague/internal/middleware"
+	"me-pague/internal/penalty"
+	"me-pague/internal/repository"
+	"me-pague/internal/service"
+	"me-pague/internal/webhook"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
+)
+
+// Deps são as dependências dos handlers.
+type Deps struct {
+	Repositories repository.Repositories
+	// DB guarda as chaves de idempotência de POST /payment. É obrigatório:
+	// NewRouter entra em pânico sem ele.
+	DB       *gorm.DB
+	Payments PaymentOptions
+	// Auth assina e verifica os tokens de acesso.
+	Auth auth.Options
+	// Webhooks limita a verificação do endereço dos assinantes.
+	Webhooks webhook.Options
+	// Penalty é a política de multa e juros das cobranças vencidas.
+	Penalty penalty.Policy
+	// Indexes são as taxas da correção monetária; nil deixa a tabela vazia.
+	Indexes *correction.Table
+	// Rates são as cotações de câmbio; nil recusa pagamentos em outra moeda.
+	Rates *currency.Rates
+	// IdempotencyRetention é por quanto tempo as chaves de idempotência de
+	// POST /payment são lembradas; zero usa
+	// middleware.DefaultIdempotencyRetention.
+	IdempotencyRetention time.Duration
+	// Swagger serve a documentação em /swagger.
+	Swagger bool
+}
+
+// Handlers são os handlers da API e as rotinas periódicas, sobre os
+// serviços e os repositórios de Deps.
+type Handlers struct {
+	repos    repository.Repositories
+	options  PaymentOptions
+	auth     auth.Options
+	webhooks webhook.Options
+	indexes  *correction.Table
+	rates    *currency.Rates
+	users    service.UserService
+	billings service.BillingService
+	payments service.PaymentService
+}
+
+// NewHandlers monta os serviços sobre os repositórios de deps.
+func NewHandlers(deps Deps) *Handlers {
+	repos := deps.Repositories
+	indexes := deps.Indexes
+	if indexes == nil {
+		indexes = correction.NewTable()
+	}
+	rates := deps.Rates
+	if rates == nil {
+		rates = currency.NewRates()
+	}
+	return &Handlers{
+		repos:    repos,
+		options:  deps.Payments,
+		auth:     deps.Auth,
+		webhooks: deps.Webhooks,
+		indexes:  indexes,
+		rates:    rates,
+		users:    service.UserService{Users: repos.Users},
+		billings: service.BillingService{Users: repos.Users, Billings: repos.Billings, Payments: repos.Payments, Penalty: deps.Penalty},
+		payments: service.PaymentService{Payments: repos.Payments, RequireConfirmation: deps.Payments.RequireConfirmation},
+	}
+}
+
+// NewRouter monta o roteador da API sobre deps.
+func NewRouter(deps Deps) *gin.Engine {
+	if deps.DB == nil {
+		panic("controller: Deps.DB is required")
+	}
+	h := NewHandlers(deps)
+
+	r := gin.Default()
+	r.Use(middleware.RequestID())
+
+	if deps.Swagger {
+		r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
+
+	r.POST("/user", h.CreateUser)
+	r.POST("/auth/login", h.Login)
+	r.POST("/auth/refresh", h.Refresh)
+
+	api := r.Group("/", middleware.Auth(deps.Auth))
+
+	api.GET("/user/:id", h.GetUser)
+
+	api.GET("/billing", h.GetBilling)
+	api.POST("/billing/:id/charge", h.CreateCharge)
+	api.GET("/billing/:id/charges", h.ListCharges)
+	api.GET("/billing/:id/pix", h.GetBillingPix)
+	api.GET("/balance", h.GetBalance)
+
+	retention := deps.IdempotencyRetention
+	if retention == 0 {
+		retention = middleware.DefaultIdempotencyRetention
+	}
+	api.POST("/payment", middleware.Idempotency(deps.DB, retention), h.CreatePayment)
+	api.POST("/payment/:id/reverse", h.ReversePayment)
+	api.POST("/payment/:id/confirm", h.ConfirmPayment)
+	api.POST("/payment/:id/reject", h.RejectPayment)
+
+	api.POST("/pix-keys", h.CreatePixKey)
+	api.GET("/pix-keys", h.ListPixKeys)
+	api.POST("/pix-keys/:id/default", h.SetDefaultPixKey)
+	api.DELETE("/pix-keys/:id", h.DeletePixKey)
+
+	api.GET("/billing/:id/payments", h.ListPayments)
+	api.GET("/billing/:id/corrected", h.GetCorrectedBilling)
+	api.POST("/billing/:id/installments", h.CreateInstallmentPlan)
+	api.GET("/billing/:id/installments", h.ListInstallmentPlans)
+
+	api.POST("/group", h.CreateGroup)
+	api.GET("/group/:id", h.GetGroup)
+	api.POST("/group/:id/expense", h.CreateGroupExpense)
+
+	api.POST("/recurring-billings", h.CreateRecurringBilling)
+	api.GET("/recurring-billings", h.ListRecurringBillings)
+	api.GET("/recurring-billings/:id/runs", h.ListRecurringRuns)
+	api.POST("/recurring-billings/:id/cancel", h.CancelRecurringBilling)
+
+	api.POST("/loans", h.CreateLoan)
+	api.GET("/loans/:id", h.GetLoan)
+	api.POST("/loans/:id/payoff", h.PayOffLoan)
+
+	api.GET("/settlements/plan", h.GetSettlementPlan)
+
+	api.GET("/audit", h.ListAuditEvents)
+
+	api.POST("/webhooks", h.CreateWebhook)
+	api.GET("/webhooks", h.ListWebhooks)
+	api.GET("/webhooks/:id/deliveries", h.ListWebhookDeliveries)
+	api.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", h.RedeliverWebhook)
+
+	return r
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"me-pague/internal/controller/response"
+	"me-pague/internal/currency"
+	"me-pague/internal/models"
+	"me-pague/internal/settlement"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSettlementPlan godoc
+// @Summary Calcula o menor conjunto de transferências que quita todas as cobranças
+// @Description Sem group_id considera apenas as cobranças em que o usuário autenticado é pagador ou recebedor; com group_id, as cobranças entre membros do grupo, desde que o usuário seja membro. Só entram as cobranças na moeda currency.
+// @Tags Acertos
+// @Produce json
+// @Param group_id query int false "ID do grupo"
+// @Param currency query string false "Moeda das cobranças (padrão: BRL)"
+// @Success 200 {object} response.SettlementPlanResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /settlements/plan [get]
+func (h *Handlers) GetSettlementPlan(c *gin.Context) {
+	code, err := currency.Normalize(c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var groupID int32
+	var billings []models.Billing
+	if raw := c.Query("group_id"); raw != "" {
+		ID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid group ID"})
+			return
+		}
+
+		group, err := h.getGroupByID(int32(ID))
+		if err != nil {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if !requireGroupMember(c, group) {
+			return
+		}
+
+		memberIDs := make([]int32, 0, len(group.Members))
+		for _, member := range group.Members {
+			memberIDs = append(memberIDs, member.UserID)
+		}
+		groupID = group.ID
+		billings, err = h.repos.Billings.Among(memberIDs, code)
+	} else {
+		// Sem grupo o plano fica restrito às cobranças do próprio usuário,
+		// para não expor saldos de terceiros.
+		billings, err = h.repos.Billings.OfUser(userID, code)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error loading billings: " + err.Error()})
+		return
+	}
+
+	balances, err := settlement.NetBalances(billings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	transfers, err := settlement.Plan(balances)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response.SettlementPlanResponse{
+		GroupID:   groupID,
+		Currency:  code,
+		Balances:  balances,
+		Transfers: transfers,
+	})
+}
@@ -1,8 +1,10 @@
 package controller
 import (
-	"me-pague/internal/db"
-	"me-pague/internal/models"
+	"me-pague/internal/audit"
+	"me-pague/internal/auth"
 	"me-pague/internal/controller/request"
+	"me-pague/internal/service"
+	"errors"
 	"net/http"
 	"strconv"
 	"github.com/gin-gonic/gin"
@@ -16,15 +18,16 @@ import (
 // @Param id path int true "ID do usuário"
 // @Success 200 {object} models.User
 // @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
 // @Router /user/{id} [get]
-func GetUser(c *gin.Context) {
-	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+func (h *Handlers) GetUser(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 31)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	user, err := getUserByID(uint(ID))
+	user, err := h.users.Get(int32(ID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -43,49 +46,22 @@ func GetUser(c *gin.Context) {
 // @Success 201 {object} request.CreateUserInput
 // @Failure 400 {object} response.ErrorResponse
 // @Router /user [post]
-func CreateUser(c *gin.Context) {
+func (h *Handlers) CreateUser(c *gin.Context) {
 	var input request.CreateUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
-	
-	_, err := getUserByName(input.Name)
-	if err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User already exists"})
+
+	newUser, err := h.users.Create(input.Name, input.Password, audit.FromContext(c))
+	if errors.Is(err, service.ErrUserExists) || errors.Is(err, auth.ErrPasswordTooShort) || errors.Is(err, auth.ErrPasswordTooLong) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	newUser, err := CreateUserHandler(input.Name)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, newUser) 	
-}
-
-
-func getUserByID(ID uint) (models.User, error) {
-	var user models.User
-	if err := db.DB.First(&user, ID).Error; err != nil {
-		return models.User{}, err
-	}
-	return user, nil
+	c.JSON(http.StatusCreated, newUser)
 }
-
-func getUserByName(name string) (models.User, error) {
-	var user models.User
-	if err := db.DB.Where("name = ?", name).First(&user).Error; err != nil {
-		return models.User{}, err
-	}
-	return user, nil
-}
-
-func CreateUserHandler(name string) (models.User, error) {
-	newUser := models.User{Name: name}
-	if err := db.DB.Create(&newUser).Error; err != nil {
-		return models.User{}, err
-	}
-	return newUser, nil
-}
\ No newline at end of file
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/models"
+	"me-pague/internal/webhook"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// minWebhookSecretLength é o tamanho mínimo do segredo de uma assinatura.
+const minWebhookSecretLength = 16
+
+// CreateWebhook godoc
+// @Summary Assina eventos por webhook
+// @Description Os eventos das cobranças das quais o usuário faz parte são enviados por POST, com o corpo assinado em X-MePague-Signature (t=<unix>,v1=<HMAC-SHA256 de "<t>.<corpo>">).
+// @Description Tipos: billing.created, payment.created e payment.confirmed. A URL não pode apontar para endereços de loopback, privados, link-local ou não especificados.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body request.WebhookInput true "Dados da assinatura"
+// @Success 201 {object} models.WebhookSubscription
+// @Failure 400 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /webhooks [post]
+func (h *Handlers) CreateWebhook(c *gin.Context) {
+	var input request.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.createWebhook(userID, input, audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// ListWebhooks godoc
+// @Summary Lista as assinaturas de webhook do usuário
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} models.WebhookSubscription
+// @Security BearerAuth
+// @Router /webhooks [get]
+func (h *Handlers) ListWebhooks(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.repos.Webhooks.ListByOwner(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error loading webhooks: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// ListWebhookDeliveries godoc
+// @Summary Lista as entregas de uma assinatura, da mais recente para a mais antiga
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "ID da assinatura"
+// @Param status query string false "pending, delivered ou dead"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handlers) ListWebhookDeliveries(c *gin.Context) {
+	subscription, ok := h.requireWebhookOwner(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.repos.Webhooks.Deliveries(subscription.ID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error loading deliveries: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RedeliverWebhook godoc
+// @Summary Agenda o reenvio de uma entrega
+// @Description Vale também para entregas já feitas ou em dead; a entrega volta ao início do ciclo de tentativas, pendente e com a próxima tentativa para agora, e o worker a envia na próxima rodada.
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "ID da assinatura"
+// @Param delivery_id path int true "ID da entrega"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *Handlers) RedeliverWebhook(c *gin.Context) {
+	subscription, ok := h.requireWebhookOwner(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := h.repos.Webhooks.GetDelivery(subscription.ID, int32(deliveryID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Delivery not found"})
+		return
+	}
+
+	if err := h.repos.Webhooks.Redeliver(&delivery, time.Now(), audit.FromContext(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func (h *Handlers) createWebhook(ownerID int32, input request.WebhookInput, meta audit.Meta) (models.WebhookSubscription, error) {
+	parsed, err := url.Parse(input.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return models.WebhookSubscription{}, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if len(input.Secret) < minWebhookSecretLength {
+		return models.WebhookSubscription{}, fmt.Errorf("secret must have at least %d characters", minWebhookSecretLength)
+	}
+	if len(input.EventTypes) == 0 {
+		return models.WebhookSubscription{}, fmt.Errorf("at least one event type is required")
+	}
+
+	eventTypes := make([]string, 0, len(input.EventTypes))
+	for _, eventType := range input.EventTypes {
+		if !slices.Contains(webhook.EventTypes, eventType) {
+			return models.WebhookSubscription{}, fmt.Errorf("unknown event type %q", eventType)
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	// A resolução do nome fica por último por ser a verificação mais cara.
+	ctx, cancel := context.WithTimeout(context.Background(), h.webhooks.Timeout)
+	defer cancel()
+	if err := h.webhooks.CheckHost(ctx, parsed.Hostname()); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	subscription := models.WebhookSubscription{
+		OwnerID:    ownerID,
+		URL:        input.URL,
+		Secret:     input.Secret,
+		EventTypes: eventTypes,
+		CreatedAt:  time.Now(),
+	}
+	if err := h.repos.Webhooks.Create(&subscription, meta); err != nil {
+		return models.WebhookSubscription{}, fmt.Errorf("error creating webhook: %w", err)
+	}
+	return subscription, nil
+}
+
+// requireWebhookOwner carrega a assinatura do parâmetro id e garante que ela
+// é do usuário autenticado.
+func (h *Handlers) requireWebhookOwner(c *gin.Context) (models.WebhookSubscription, bool) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Invalid webhook ID"})
+		return models.WebhookSubscription{}, false
+	}
+
+	userID, ok := currentUser(c)
+	if !ok {
+		return models.WebhookSubscription{}, false
+	}
+
+	subscription, err := h.repos.Webhooks.Get(int32(ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: "Webhook not found"})
+		return subscription, false
+	}
+	if subscription.OwnerID != userID {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: "you do not own this webhook"})
+		return subscription, false
+	}
+	return subscription, true
+}
@@ -0,0 +1,124 @@
+// Package correction atualiza monetariamente valores antigos por índices de
+// inflação ou juros, como IPCA e SELIC, a partir de uma tabela de taxas
+// mensais.
+//
+// O saldo é corrigido mês a mês: a taxa de cada mês incide sobre o saldo do
+// início do mês, e os lançamentos e pagamentos de um mês só passam a ser
+// corrigidos no mês seguinte. O mês da data de referência não é corrigido,
+// porque a taxa dele em geral ainda não saiu. A correção de cada mês é
+// arredondada para o centavo, metade para longe do zero (money.HalfUp), e o
+// saldo segue com o valor arredondado, para que a conta mostrada feche.
+package correction
+
+import (
+	"fmt"
+	"math/big"
+	"me-pague/internal/money"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry é um valor que entra no saldo na data: positivo para lançamentos,
+// negativo para pagamentos.
+type Entry struct {
+	Date   time.Time
+	Amount money.Amount
+}
+
+// Month é uma linha da conta: o saldo de abertura, a correção do mês pela
+// taxa Rate (em percentual), o que entrou no mês e o saldo de fechamento.
+type Month struct {
+	Month      string       `json:"month"`
+	Opening    money.Amount `json:"opening"`
+	Rate       string       `json:"rate,omitempty"`
+	Correction money.Amount `json:"correction"`
+	Charges    money.Amount `json:"charges"`
+	Payments   money.Amount `json:"payments"`
+	Closing    money.Amount `json:"closing"`
+}
+
+// Result é o saldo corrigido até Date. Original é o saldo sem correção.
+type Result struct {
+	Index      string       `json:"index"`
+	Date       string       `json:"date"`
+	Original   money.Amount `json:"original"`
+	Correction money.Amount `json:"correction"`
+	Corrected  money.Amount `json:"corrected"`
+	Months     []Month      `json:"months"`
+}
+
+// Correct corrige pelo índice o saldo dos lançamentos até a data date. Os
+// lançamentos posteriores a date ficam de fora. Falta de taxa num mês do
+// período é erro.
+func Correct(table *Table, index string, entries []Entry, date time.Time) (Result, error) {
+	index = strings.ToUpper(index)
+	result := Result{Index: index, Date: date.Format("2006-01-02"), Months: []Month{}}
+	if !table.Has(index) {
+		return result, fmt.Errorf("no rates loaded for index %s", index)
+	}
+
+	end := day(date)
+	included := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if !day(entry.Date).After(end) {
+			included = append(included, entry)
+		}
+	}
+	if len(included) == 0 {
+		return result, nil
+	}
+	sort.SliceStable(included, func(i, j int) bool { return included[i].Date.Before(included[j].Date) })
+
+	first, last := month(included[0].Date), month(end)
+	var balance money.Amount
+	next := 0
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		row := Month{Month: m.Format(MonthLayout), Opening: balance}
+		if m.After(first) && m.Before(last) {
+			r, ok := table.rate(index, m)
+			if !ok {
+				return result, fmt.Errorf("no %s rate for %s", index, row.Month)
+			}
+			row.Rate = r.text
+			correction, err := money.Round(new(big.Rat).Mul(big.NewRat(int64(balance), 1), r.value), money.HalfUp)
+			if err != nil {
+				return result, err
+			}
+			row.Correction = correction
+			if balance, err = balance.Add(correction); err != nil {
+				return result, err
+			}
+		}
+
+		for ; next < len(included) && month(included[next].Date).Equal(m); next++ {
+			amount := included[next].Amount
+			if amount >= 0 {
+				row.Charges += amount
+			} else {
+				row.Payments -= amount
+			}
+			var err error
+			if balance, err = balance.Add(amount); err != nil {
+				return result, err
+			}
+			result.Original += amount
+		}
+
+		row.Closing = balance
+		result.Correction += row.Correction
+		result.Months = append(result.Months, row)
+	}
+	result.Corrected = balance
+	return result, nil
+}
+
+func day(t time.Time) time.Time {
+	year, m, d := t.UTC().Date()
+	return time.Date(year, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func month(t time.Time) time.Time {
+	year, m, _ := t.UTC().Date()
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
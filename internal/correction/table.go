@@ -0,0 +1,133 @@
+package correction
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MonthLayout é o formato dos meses na tabela e no cálculo.
+const MonthLayout = "2006-01"
+
+// Table guarda as taxas mensais de cada índice, em percentual.
+type Table struct {
+	rates map[string]map[string]rate
+}
+
+var ratePattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+type rate struct {
+	value *big.Rat
+	text  string
+}
+
+// NewTable cria uma tabela vazia.
+func NewTable() *Table {
+	return &Table{rates: map[string]map[string]rate{}}
+}
+
+// LoadFile lê a tabela de um arquivo CSV; veja LoadCSV.
+func LoadFile(path string) (*Table, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadCSV(file)
+}
+
+// LoadCSV lê uma tabela no formato
+//
+//	index,month,rate
+//	IPCA,2024-01,0.42
+//
+// com o mês em YYYY-MM e a taxa do mês em percentual, com ponto decimal. Os
+// nomes dos índices não diferenciam maiúsculas. Um mês repetido é erro.
+func LoadCSV(r io.Reader) (*Table, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("index table is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(strings.Join(header, ",")) != "index,month,rate" {
+		return nil, fmt.Errorf("index table header must be index,month,rate")
+	}
+
+	table := NewTable()
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return table, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if err := table.Set(record[0], record[1], record[2]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// Set grava a taxa percentual do índice no mês.
+func (t *Table) Set(index, month, percent string) error {
+	index = strings.ToUpper(strings.TrimSpace(index))
+	if index == "" {
+		return fmt.Errorf("index name is empty")
+	}
+	parsed, err := time.Parse(MonthLayout, strings.TrimSpace(month))
+	if err != nil {
+		return fmt.Errorf("month must be in the YYYY-MM format")
+	}
+	percent = strings.TrimSpace(percent)
+	if !ratePattern.MatchString(percent) {
+		return fmt.Errorf("invalid rate %q", percent)
+	}
+	value, _ := new(big.Rat).SetString(percent)
+	if value.Cmp(big.NewRat(-100, 1)) <= 0 {
+		return fmt.Errorf("rate must be greater than -100%%")
+	}
+
+	key := parsed.Format(MonthLayout)
+	if t.rates[index] == nil {
+		t.rates[index] = map[string]rate{}
+	}
+	if _, ok := t.rates[index][key]; ok {
+		return fmt.Errorf("duplicate %s rate for %s", index, key)
+	}
+	t.rates[index][key] = rate{value: value.Quo(value, big.NewRat(100, 1)), text: percent}
+	return nil
+}
+
+// Names lista os índices carregados, em ordem alfabética.
+func (t *Table) Names() []string {
+	names := make([]string, 0, len(t.rates))
+	for name := range t.rates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has indica se o índice tem alguma taxa carregada.
+func (t *Table) Has(index string) bool {
+	return len(t.rates[strings.ToUpper(index)]) > 0
+}
+
+func (t *Table) rate(index string, month time.Time) (rate, bool) {
+	r, ok := t.rates[strings.ToUpper(index)][month.Format(MonthLayout)]
+	return r, ok
+}
@@ -0,0 +1,87 @@
+// Package currency trata os códigos de moeda (ISO 4217), as casas decimais de
+// cada uma e a conversão entre elas por uma tabela de câmbio histórica.
+// Valores são sempre inteiros na menor unidade da moeda, como centavos.
+package currency
+
+import (
+	"fmt"
+	"math/big"
+	"me-pague/internal/money"
+	"strings"
+	"time"
+)
+
+// Default é a moeda das cobranças que não informam outra.
+const Default = "BRL"
+
+// ConversionRounding é a regra de arredondamento das conversões.
+const ConversionRounding = money.HalfUp
+
+// Normalize devolve o código em maiúsculas, ou Default se vier vazio.
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return Default, nil
+	}
+	if _, ok := money.MinorUnits(code); !ok {
+		return "", fmt.Errorf("unsupported currency %q", code)
+	}
+	return code, nil
+}
+
+// Conversion é um valor convertido de From para To pela taxa Rate. Exact é o
+// resultado sem arredondamento, na menor unidade de To; Converted é o
+// arredondado por ConversionRounding, e Rounding é Converted - Exact.
+type Conversion struct {
+	From      string
+	To        string
+	Amount    money.Amount
+	Converted money.Amount
+	Rate      Rate
+	Exact     *big.Rat
+	Rounding  *big.Rat
+}
+
+// Convert converte amount, na menor unidade de from, para to pela taxa de
+// câmbio da data.
+func Convert(rates *Rates, amount money.Amount, from, to string, date time.Time) (Conversion, error) {
+	rate, err := rates.Rate(from, to, date)
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	fromUnits, _ := money.MinorUnits(from)
+	toUnits, _ := money.MinorUnits(to)
+	exact := new(big.Rat).Mul(big.NewRat(int64(amount), 1), rate.Value)
+	exact.Mul(exact, new(big.Rat).SetFrac(pow10(toUnits), pow10(fromUnits)))
+	converted, err := money.Round(exact, ConversionRounding)
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	return Conversion{
+		From:      from,
+		To:        to,
+		Amount:    amount,
+		Converted: converted,
+		Rate:      rate,
+		Exact:     exact,
+		Rounding:  new(big.Rat).Sub(big.NewRat(int64(converted), 1), exact),
+	}, nil
+}
+
+// Decimal escreve r com até places casas decimais, sem zeros à direita.
+func Decimal(r *big.Rat, places int) string {
+	s := r.FloatString(places)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,153 @@
+package currency
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxRateAge é quantos dias antes da data uma cotação ainda vale, para cobrir
+// fins de semana e feriados sem cotação.
+const MaxRateAge = 7
+
+var ratePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// Rate é a cotação de uma unidade de Base em Quote na data Date.
+type Rate struct {
+	Base  string
+	Quote string
+	Date  time.Time
+	Value *big.Rat
+	Text  string
+}
+
+// Rates é a tabela de câmbio histórica, com as cotações de cada par em ordem
+// de data.
+type Rates struct {
+	pairs map[string][]Rate
+}
+
+// NewRates cria uma tabela vazia.
+func NewRates() *Rates {
+	return &Rates{pairs: map[string][]Rate{}}
+}
+
+// LoadRatesFile lê a tabela de um arquivo CSV; veja LoadRates.
+func LoadRatesFile(path string) (*Rates, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadRates(file)
+}
+
+// LoadRates lê uma tabela no formato
+//
+//	date,base,quote,rate
+//	2024-07-01,USD,BRL,5.5561
+//
+// em que rate é quanto uma unidade de base vale em quote, com ponto decimal.
+// Uma cotação repetida para o mesmo par e data é erro.
+func LoadRates(r io.Reader) (*Rates, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("exchange rate table is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(strings.Join(header, ",")) != "date,base,quote,rate" {
+		return nil, fmt.Errorf("exchange rate table header must be date,base,quote,rate")
+	}
+
+	rates := NewRates()
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if err := rates.Set(record[0], record[1], record[2], record[3]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// Set grava a cotação de base em quote na data.
+func (r *Rates) Set(date, base, quote, value string) error {
+	parsed, err := time.Parse("2006-01-02", strings.TrimSpace(date))
+	if err != nil {
+		return fmt.Errorf("date must be in the YYYY-MM-DD format")
+	}
+	if base, err = Normalize(base); err != nil {
+		return err
+	}
+	if quote, err = Normalize(quote); err != nil {
+		return err
+	}
+	if base == quote {
+		return fmt.Errorf("base and quote must be different currencies")
+	}
+	value = strings.TrimSpace(value)
+	if !ratePattern.MatchString(value) {
+		return fmt.Errorf("invalid rate %q", value)
+	}
+	rat, _ := new(big.Rat).SetString(value)
+	if rat.Sign() == 0 {
+		return fmt.Errorf("rate must be greater than zero")
+	}
+
+	key := base + "/" + quote
+	list := r.pairs[key]
+	i := sort.Search(len(list), func(i int) bool { return !list[i].Date.Before(parsed) })
+	if i < len(list) && list[i].Date.Equal(parsed) {
+		return fmt.Errorf("duplicate %s rate for %s", key, parsed.Format("2006-01-02"))
+	}
+	rate := Rate{Base: base, Quote: quote, Date: parsed, Value: rat, Text: value}
+	r.pairs[key] = append(list[:i], append([]Rate{rate}, list[i:]...)...)
+	return nil
+}
+
+// Rate é a cotação de from em to mais recente até a data, com no máximo
+// MaxRateAge dias. Sem o par direto, usa o inverso da cotação de to em from.
+func (r *Rates) Rate(from, to string, date time.Time) (Rate, error) {
+	year, month, day := date.UTC().Date()
+	date = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	if rate, ok := r.latest(from+"/"+to, date); ok {
+		return rate, nil
+	}
+	if rate, ok := r.latest(to+"/"+from, date); ok {
+		inverse := new(big.Rat).Inv(rate.Value)
+		return Rate{Base: from, Quote: to, Date: rate.Date, Value: inverse, Text: Decimal(inverse, 10)}, nil
+	}
+	return Rate{}, fmt.Errorf("no %s/%s exchange rate for %s", from, to, date.Format("2006-01-02"))
+}
+
+func (r *Rates) latest(key string, date time.Time) (Rate, bool) {
+	list := r.pairs[key]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Date.After(date) })
+	if i == 0 {
+		return Rate{}, false
+	}
+	rate := list[i-1]
+	if date.Sub(rate.Date) > MaxRateAge*24*time.Hour {
+		return Rate{}, false
+	}
+	return rate, true
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"fmt"
+	"me-pague/internal/db/migrations"
+	"me-pague/internal/migrate"
+
+	"gorm.io/gorm"
+)
+
+// legacyColumns são as colunas que 0001_initial_schema cria nas tabelas que
+// o AutoMigrate da versão sem migrações já criava. Num banco dessa versão as
+// tabelas existem, o CREATE TABLE IF NOT EXISTS não faz nada e os índices
+// da migração falhariam por falta das colunas.
+var legacyColumns = []struct {
+	table, column, definition string
+}{
+	{"users", "password_hash", "text"},
+	{"billings", "currency", "text NOT NULL DEFAULT 'BRL'"},
+	{"billings", "version", "integer NOT NULL DEFAULT 0"},
+	{"payments", "currency", "text NOT NULL DEFAULT 'BRL'"},
+	{"payments", "credit", "integer DEFAULT 0"},
+	{"payments", "status", "text NOT NULL DEFAULT 'confirmed'"},
+	{"payments", "resolved_at", "datetime"},
+	{"payments", "original_amount", "integer DEFAULT 0"},
+	{"payments", "original_currency", "text"},
+	{"payments", "exchange_rate", "text"},
+	{"payments", "rate_date", "datetime"},
+	{"payments", "rounding", "text"},
+}
+
+// Migrate aplica as migrações pendentes e devolve as que aplicou. Se a
+// primeira ainda não foi aplicada, antes adota um banco criado pelo
+// AutoMigrate: as tabelas existentes ganham as colunas novas, com os
+// mesmos padrões da migração, e as linhas delas são mantidas.
+func Migrate(database *gorm.DB) ([]migrate.Migration, error) {
+	states, err := migrate.Status(database, migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+	if len(states) > 0 && !states[0].Applied {
+		if err := adoptLegacySchema(database); err != nil {
+			return nil, err
+		}
+	}
+	return migrate.Up(database, migrations.FS)
+}
+
+func adoptLegacySchema(database *gorm.DB) error {
+	return database.Transaction(func(tx *gorm.DB) error {
+		for _, c := range legacyColumns {
+			if !tx.Migrator().HasTable(c.table) || tx.Migrator().HasColumn(c.table, c.column) {
+				continue
+			}
+			err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)).Error
+			if err != nil {
+				return fmt.Errorf("error adding %s.%s to the existing schema: %w", c.table, c.column, err)
+			}
+		}
+		return nil
+	})
+}
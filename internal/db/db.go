@@ -1,19 +1,42 @@
 package db
 
 import (
-	"me-pague/internal/models"
+	"fmt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
+// Logger é o logger do GORM usado pelos bancos abertos com Open.
+var Logger = logger.Default
 
-func Init() {
-	database, err := gorm.Open(sqlite.Open("payments.db"), &gorm.Config{})
+// Init abre o banco em dsn e aplica as migrações pendentes.
+func Init(dsn string) *gorm.DB {
+	database, err := Open(dsn)
 	if err != nil {
 		panic("failed to connect database")
 	}
 
-	database.AutoMigrate(&models.User{}, &models.Payment{}, &models.Billing{})
-	DB = database
+	if _, err := Migrate(database); err != nil {
+		panic(fmt.Sprintf("failed to migrate database: %v", err))
+	}
+	return database
+}
+
+// Open abre o banco SQLite em dsn sem aplicar migrações.
+func Open(dsn string) (*gorm.DB, error) {
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: Logger})
+	if err != nil {
+		return nil, err
+	}
+
+	// O SQLite aceita um único escritor por vez; com uma conexão só, as
+	// transações concorrentes esperam a vez em vez de falhar com "database
+	// is locked".
+	sqlDB, err := database.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return database, nil
 }
@@ -0,0 +1,24 @@
+// Package dbtest monta bancos para os testes com o mesmo esquema da
+// aplicação, aplicando as migrações.
+package dbtest
+
+import (
+	"fmt"
+	"me-pague/internal/db"
+
+	"gorm.io/gorm"
+)
+
+// New abre um banco SQLite em memória, novo a cada chamada, com todas as
+// migrações aplicadas. Como em db.Init, há uma conexão só: no SQLite cada
+// conexão com ":memory:" tem o seu próprio banco.
+func New() *gorm.DB {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		panic(fmt.Sprintf("failed to open test database: %v", err))
+	}
+	if _, err := db.Migrate(database); err != nil {
+		panic(fmt.Sprintf("failed to migrate test database: %v", err))
+	}
+	return database
+}
@@ -0,0 +1,24 @@
+DROP TABLE IF EXISTS loan_installments;
+DROP TABLE IF EXISTS loans;
+DROP TABLE IF EXISTS installments;
+DROP TABLE IF EXISTS installment_plans;
+DROP TABLE IF EXISTS recurring_runs;
+DROP TABLE IF EXISTS recurring_billings;
+DROP TABLE IF EXISTS pix_keys;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS account_balances;
+DROP TABLE IF EXISTS postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+DROP TABLE IF EXISTS expense_shares;
+DROP TABLE IF EXISTS group_expenses;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS payment_reversals;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS charges;
+DROP TABLE IF EXISTS billings;
+DROP TABLE IF EXISTS users;
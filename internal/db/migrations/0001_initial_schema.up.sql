@@ -0,0 +1,279 @@
+-- Esquema inicial, igual ao que o AutoMigrate criava. Usa IF NOT EXISTS para
+-- que um banco criado pelo AutoMigrate passe a ser controlado pelas migrações
+-- sem perder dados.
+
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    name text,
+    password_hash text,
+    CONSTRAINT uni_users_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS billings (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    payer_id integer,
+    receiver_id integer,
+    amount integer,
+    currency text NOT NULL DEFAULT 'BRL',
+    created_at datetime,
+    version integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS charges (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    billing_id integer,
+    amount integer,
+    currency text NOT NULL DEFAULT 'BRL',
+    description text,
+    date datetime,
+    due_date datetime,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_charges_billing_id ON charges (billing_id);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    payer_id integer,
+    billing_id integer,
+    amount integer,
+    currency text NOT NULL DEFAULT 'BRL',
+    credit integer,
+    status text NOT NULL DEFAULT 'confirmed',
+    created_at datetime,
+    resolved_at datetime,
+    original_amount integer,
+    original_currency text,
+    exchange_rate text,
+    rate_date datetime,
+    rounding text
+);
+CREATE INDEX IF NOT EXISTS idx_payments_status ON payments (status);
+
+CREATE TABLE IF NOT EXISTS payment_reversals (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    payment_id integer,
+    amount integer,
+    reason text,
+    created_at datetime,
+    CONSTRAINT fk_payments_reversals FOREIGN KEY (payment_id) REFERENCES payments (id)
+);
+CREATE INDEX IF NOT EXISTS idx_payment_reversals_payment_id ON payment_reversals (payment_id);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    name text,
+    created_at datetime
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    group_id integer,
+    user_id integer,
+    CONSTRAINT fk_groups_members FOREIGN KEY (group_id) REFERENCES groups (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_group_member ON group_members (group_id, user_id);
+
+CREATE TABLE IF NOT EXISTS group_expenses (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    group_id integer,
+    payer_id integer,
+    description text,
+    total integer,
+    split_type text,
+    created_at datetime
+);
+
+CREATE TABLE IF NOT EXISTS expense_shares (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    expense_id integer,
+    user_id integer,
+    amount integer,
+    billing_id integer,
+    charge_id integer,
+    CONSTRAINT fk_group_expenses_shares FOREIGN KEY (expense_id) REFERENCES group_expenses (id)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    code text,
+    created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_code ON ledger_accounts (code);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    kind text,
+    reference text,
+    description text,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_reference ON journal_entries (reference);
+
+CREATE TABLE IF NOT EXISTS postings (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    entry_id integer,
+    account_id integer,
+    side text,
+    amount integer,
+    CONSTRAINT fk_journal_entries_postings FOREIGN KEY (entry_id) REFERENCES journal_entries (id)
+);
+CREATE INDEX IF NOT EXISTS idx_postings_account_id ON postings (account_id);
+CREATE INDEX IF NOT EXISTS idx_postings_entry_id ON postings (entry_id);
+
+CREATE TABLE IF NOT EXISTS account_balances (
+    account_id integer PRIMARY KEY,
+    debit integer,
+    credit integer
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key text,
+    fingerprint text NOT NULL,
+    status_code integer NOT NULL DEFAULT 0,
+    content_type text,
+    response_body blob,
+    created_at datetime,
+    expires_at datetime,
+    PRIMARY KEY (key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    actor_id integer,
+    action text,
+    entity_type text,
+    entity_id integer,
+    before text,
+    after text,
+    request_id text,
+    client_ip text,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_events (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events (actor_id);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    owner_id integer,
+    url text NOT NULL,
+    secret text NOT NULL,
+    event_types text,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_owner_id ON webhook_subscriptions (owner_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    subscription_id integer,
+    event_id text,
+    event_type text,
+    payload text,
+    status text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at datetime,
+    response_status integer,
+    last_error text,
+    delivered_at datetime,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+
+CREATE TABLE IF NOT EXISTS pix_keys (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer,
+    type text NOT NULL,
+    key text NOT NULL,
+    is_default numeric NOT NULL DEFAULT false,
+    created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pix_keys_key ON pix_keys (key);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pix_keys_default ON pix_keys (user_id) WHERE is_default;
+CREATE INDEX IF NOT EXISTS idx_pix_keys_user_id ON pix_keys (user_id);
+
+CREATE TABLE IF NOT EXISTS recurring_billings (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    payer_id integer,
+    receiver_id integer,
+    amount integer,
+    description text,
+    frequency text NOT NULL,
+    interval integer NOT NULL DEFAULT 1,
+    start_date datetime,
+    end_date datetime,
+    next_period integer NOT NULL DEFAULT 0,
+    next_date datetime,
+    created_by integer,
+    created_at datetime,
+    canceled_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_recurring_billings_next_date ON recurring_billings (next_date);
+CREATE INDEX IF NOT EXISTS idx_recurring_billings_receiver_id ON recurring_billings (receiver_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_billings_payer_id ON recurring_billings (payer_id);
+
+CREATE TABLE IF NOT EXISTS recurring_runs (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    recurring_billing_id integer,
+    period integer,
+    period_date datetime,
+    billing_id integer,
+    charge_id integer,
+    created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recurring_runs_period ON recurring_runs (recurring_billing_id, period);
+
+CREATE TABLE IF NOT EXISTS installment_plans (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    billing_id integer,
+    total integer,
+    count integer,
+    paid_before integer,
+    created_by integer,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_installment_plans_billing_id ON installment_plans (billing_id);
+
+CREATE TABLE IF NOT EXISTS installments (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    plan_id integer,
+    number integer,
+    amount integer,
+    due_date datetime,
+    CONSTRAINT fk_installment_plans_installments FOREIGN KEY (plan_id) REFERENCES installment_plans (id)
+);
+CREATE INDEX IF NOT EXISTS idx_installments_plan_id ON installments (plan_id);
+
+CREATE TABLE IF NOT EXISTS loans (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    billing_id integer,
+    principal integer,
+    monthly_rate_bp integer,
+    term integer,
+    system text,
+    first_due_date datetime,
+    status text,
+    paid_off_at datetime,
+    paid_before integer,
+    created_by integer,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_loans_billing_id ON loans (billing_id);
+
+CREATE TABLE IF NOT EXISTS loan_installments (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    loan_id integer,
+    number integer,
+    due_date datetime,
+    payment integer,
+    interest integer,
+    principal integer,
+    balance integer,
+    charge_id integer,
+    CONSTRAINT fk_loans_installments FOREIGN KEY (loan_id) REFERENCES loans (id)
+);
+CREATE INDEX IF NOT EXISTS idx_loan_installments_due_date ON loan_installments (due_date);
+CREATE INDEX IF NOT EXISTS idx_loan_installments_loan_id ON loan_installments (loan_id);
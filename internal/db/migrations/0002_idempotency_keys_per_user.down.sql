@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS idempotency_keys;
+
+CREATE TABLE idempotency_keys (
+    key text,
+    fingerprint text NOT NULL,
+    status_code integer NOT NULL DEFAULT 0,
+    content_type text,
+    response_body blob,
+    created_at datetime,
+    expires_at datetime,
+    PRIMARY KEY (key)
+);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,18 @@
+-- As chaves de idempotência passam a valer por usuário: a mesma chave usada
+-- por outro usuário é outra requisição. As chaves guardadas são descartadas;
+-- elas só valem pelo prazo de retenção.
+
+DROP TABLE IF EXISTS idempotency_keys;
+
+CREATE TABLE idempotency_keys (
+    user_id integer NOT NULL,
+    key text NOT NULL,
+    fingerprint text NOT NULL,
+    status_code integer NOT NULL DEFAULT 0,
+    content_type text,
+    response_body blob,
+    created_at datetime,
+    expires_at datetime,
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
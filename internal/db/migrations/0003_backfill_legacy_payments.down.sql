@@ -0,0 +1,8 @@
+DELETE FROM postings WHERE entry_id IN (
+    SELECT id FROM journal_entries WHERE kind = 'payment' AND description = 'pagamento anterior ao razão'
+);
+DELETE FROM journal_entries WHERE kind = 'payment' AND description = 'pagamento anterior ao razão';
+
+UPDATE account_balances SET
+    debit = (SELECT COALESCE(SUM(amount), 0) FROM postings WHERE postings.account_id = account_balances.account_id AND side = 'debit'),
+    credit = (SELECT COALESCE(SUM(amount), 0) FROM postings WHERE postings.account_id = account_balances.account_id AND side = 'credit');
@@ -0,0 +1,41 @@
+-- Lança no razão os pagamentos confirmados que ainda não têm lançamento: os
+-- feitos antes do razão existir, quando só o valor pago da cobrança era
+-- guardado. Sem isso os totais, que vêm do razão, ignorariam esses
+-- pagamentos. Os lançamentos ficam na data do pagamento e com a descrição
+-- abaixo, que o down usa para achá-los.
+
+CREATE TEMP TABLE legacy_payments AS
+SELECT payments.id, payments.billing_id, billings.receiver_id, payments.amount, payments.created_at
+FROM payments
+JOIN billings ON billings.id = payments.billing_id
+WHERE payments.status = 'confirmed' AND payments.amount > 0
+  AND NOT EXISTS (SELECT 1 FROM journal_entries WHERE journal_entries.reference = 'payment:' || payments.id);
+
+INSERT OR IGNORE INTO ledger_accounts (code, created_at)
+SELECT DISTINCT 'billing:' || billing_id, CURRENT_TIMESTAMP FROM legacy_payments
+UNION
+SELECT DISTINCT 'user:' || receiver_id, CURRENT_TIMESTAMP FROM legacy_payments;
+
+INSERT OR IGNORE INTO account_balances (account_id, debit, credit)
+SELECT id, 0, 0 FROM ledger_accounts;
+
+INSERT INTO journal_entries (kind, reference, description, created_at)
+SELECT 'payment', 'payment:' || id, 'pagamento anterior ao razão', created_at FROM legacy_payments ORDER BY id;
+
+INSERT INTO postings (entry_id, account_id, side, amount)
+SELECT journal_entries.id, ledger_accounts.id, 'debit', legacy_payments.amount
+FROM legacy_payments
+JOIN journal_entries ON journal_entries.reference = 'payment:' || legacy_payments.id
+JOIN ledger_accounts ON ledger_accounts.code = 'user:' || legacy_payments.receiver_id;
+
+INSERT INTO postings (entry_id, account_id, side, amount)
+SELECT journal_entries.id, ledger_accounts.id, 'credit', legacy_payments.amount
+FROM legacy_payments
+JOIN journal_entries ON journal_entries.reference = 'payment:' || legacy_payments.id
+JOIN ledger_accounts ON ledger_accounts.code = 'billing:' || legacy_payments.billing_id;
+
+UPDATE account_balances SET
+    debit = (SELECT COALESCE(SUM(amount), 0) FROM postings WHERE postings.account_id = account_balances.account_id AND side = 'debit'),
+    credit = (SELECT COALESCE(SUM(amount), 0) FROM postings WHERE postings.account_id = account_balances.account_id AND side = 'credit');
+
+DROP TABLE legacy_payments;
@@ -0,0 +1,9 @@
+// Package migrations guarda as migrações do banco, embutidas no binário.
+package migrations
+
+import "embed"
+
+// FS são os arquivos NNNN_nome.up.sql e NNNN_nome.down.sql desta pasta.
+//
+//go:embed *.sql
+var FS embed.FS
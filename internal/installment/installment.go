@@ -0,0 +1,90 @@
+// Package installment divide uma dívida em parcelas mensais e distribui os
+// pagamentos entre elas, da mais antiga para a mais nova.
+package installment
+
+import (
+	"fmt"
+	"me-pague/internal/money"
+	"me-pague/internal/recurring"
+	"time"
+)
+
+// MaxCount é o número máximo de parcelas de um plano.
+const MaxCount = 120
+
+// Situações de uma parcela.
+const (
+	Open    = "open"
+	Partial = "partial"
+	Paid    = "paid"
+	Overdue = "overdue"
+)
+
+// Installment é uma parcela com o que já foi pago dela.
+type Installment struct {
+	Number  int
+	Amount  money.Amount
+	DueDate time.Time
+	Paid    money.Amount
+	Status  string
+}
+
+// Split divide total em count parcelas mensais a partir de firstDue. Os
+// centavos que sobram da divisão vão para as primeiras parcelas, para que a
+// soma bata com o total.
+func Split(total money.Amount, count int, firstDue time.Time) ([]Installment, error) {
+	if total <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if count < 1 || count > MaxCount {
+		return nil, fmt.Errorf("count must be between 1 and %d", MaxCount)
+	}
+	if total < money.Amount(count) {
+		return nil, fmt.Errorf("amount is too small for %d installments", count)
+	}
+
+	rule := recurring.Rule{Frequency: recurring.Monthly, Interval: 1, Start: firstDue}
+	base, remainder := total/money.Amount(count), total%money.Amount(count)
+
+	installments := make([]Installment, count)
+	for i := range installments {
+		installments[i] = Installment{Number: i + 1, Amount: base, DueDate: rule.Occurrence(i), Status: Open}
+		if money.Amount(i) < remainder {
+			installments[i].Amount++
+		}
+	}
+	return installments, nil
+}
+
+// Allocate distribui paid entre as parcelas, em ordem, e atualiza a situação
+// de cada uma na data asOf com Classify.
+func Allocate(installments []Installment, paid money.Amount, asOf time.Time) {
+	for i := range installments {
+		item := &installments[i]
+		item.Paid = min(max(paid, 0), item.Amount)
+		paid -= item.Paid
+	}
+	Classify(installments, asOf)
+}
+
+// Classify atualiza a situação de cada parcela na data asOf a partir do que
+// já foi pago dela. Uma parcela vencida e não quitada fica overdue mesmo que
+// tenha recebido parte do valor.
+func Classify(installments []Installment, asOf time.Time) {
+	year, month, day := asOf.UTC().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	for i := range installments {
+		item := &installments[i]
+		switch {
+		case item.Paid == item.Amount:
+			item.Status = Paid
+		case item.DueDate.Before(today):
+			item.Status = Overdue
+		case item.Paid > 0:
+			item.Status = Partial
+		default:
+			item.Status = Open
+		}
+	}
+}
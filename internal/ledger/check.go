@@ -0,0 +1,122 @@
+package ledger
+
+import (
+	"fmt"
+	"me-pague/internal/money"
+
+	"gorm.io/gorm"
+)
+
+// Report é o resultado da verificação das invariantes do razão.
+type Report struct {
+	OK           bool         `json:"ok"`
+	TotalDebits  money.Amount `json:"total_debits"`
+	TotalCredits money.Amount `json:"total_credits"`
+	Entries      int64        `json:"entries"`
+	Problems     []string     `json:"problems"`
+}
+
+// Check confere que o total de débitos é igual ao de créditos, que cada
+// lançamento está balanceado, que a projeção de saldos bate com as partidas
+// e que o razão de cada cobrança bate com o valor pago guardado nela e com
+// os lançamentos da tabela charges.
+func Check(tx *gorm.DB) (Report, error) {
+	report := Report{Problems: []string{}}
+
+	var totals Totals
+	err := tx.Table("postings").
+		Select("COALESCE(SUM(CASE WHEN side = ? THEN amount ELSE 0 END), 0) AS debit, "+
+			"COALESCE(SUM(CASE WHEN side = ? THEN amount ELSE 0 END), 0) AS credit", Debit, Credit).
+		Scan(&totals).Error
+	if err != nil {
+		return report, fmt.Errorf("error summing postings: %w", err)
+	}
+	report.TotalDebits, report.TotalCredits = totals.Debit, totals.Credit
+	if err := tx.Table("journal_entries").Count(&report.Entries).Error; err != nil {
+		return report, fmt.Errorf("error counting journal entries: %w", err)
+	}
+
+	if report.TotalDebits != report.TotalCredits {
+		report.Problems = append(report.Problems,
+			fmt.Sprintf("total debits %d differ from total credits %d", report.TotalDebits, report.TotalCredits))
+	}
+
+	var unbalanced []struct {
+		EntryID int32
+		Debit   int64
+		Credit  int64
+	}
+	err = tx.Table("postings").
+		Select("entry_id, SUM(CASE WHEN side = ? THEN amount ELSE 0 END) AS debit, "+
+			"SUM(CASE WHEN side = ? THEN amount ELSE 0 END) AS credit", Debit, Credit).
+		Group("entry_id").Having("debit <> credit").Scan(&unbalanced).Error
+	if err != nil {
+		return report, fmt.Errorf("error checking journal entries: %w", err)
+	}
+	for _, entry := range unbalanced {
+		report.Problems = append(report.Problems,
+			fmt.Sprintf("journal entry %d is unbalanced: debits %d, credits %d", entry.EntryID, entry.Debit, entry.Credit))
+	}
+
+	var drifted []struct {
+		Code         string
+		Debit        int64
+		Credit       int64
+		PostedDebit  int64
+		PostedCredit int64
+	}
+	err = tx.Table("account_balances").
+		Select("ledger_accounts.code, account_balances.debit, account_balances.credit, "+
+			"COALESCE(SUM(CASE WHEN postings.side = ? THEN postings.amount ELSE 0 END), 0) AS posted_debit, "+
+			"COALESCE(SUM(CASE WHEN postings.side = ? THEN postings.amount ELSE 0 END), 0) AS posted_credit", Debit, Credit).
+		Joins("JOIN ledger_accounts ON ledger_accounts.id = account_balances.account_id").
+		Joins("LEFT JOIN postings ON postings.account_id = account_balances.account_id").
+		Group("account_balances.account_id").
+		Having("account_balances.debit <> posted_debit OR account_balances.credit <> posted_credit").
+		Scan(&drifted).Error
+	if err != nil {
+		return report, fmt.Errorf("error checking account balances: %w", err)
+	}
+	for _, account := range drifted {
+		report.Problems = append(report.Problems,
+			fmt.Sprintf("account %s projection %d/%d differs from postings %d/%d",
+				account.Code, account.Debit, account.Credit, account.PostedDebit, account.PostedCredit))
+	}
+
+	var billings []struct {
+		ID            int32
+		StoredPaid    int64
+		Charged       int64
+		PostedPaid    int64
+		PostedCharged int64
+	}
+	err = tx.Table("billings").
+		Select("billings.id, COALESCE(billings.amount, 0) AS stored_paid, "+
+			"(SELECT COALESCE(SUM(charges.amount), 0) FROM charges WHERE charges.billing_id = billings.id) AS charged, "+
+			"COALESCE(SUM(CASE WHEN journal_entries.kind = 'payment' AND postings.side = ? THEN postings.amount "+
+			"WHEN journal_entries.kind = 'reversal' AND postings.side = ? THEN -postings.amount ELSE 0 END), 0) AS posted_paid, "+
+			"COALESCE(SUM(CASE WHEN journal_entries.kind = 'charge' AND postings.side = ? THEN postings.amount ELSE 0 END), 0) AS posted_charged",
+			Credit, Debit, Debit).
+		Joins("LEFT JOIN ledger_accounts ON ledger_accounts.code = 'billing:' || billings.id").
+		Joins("LEFT JOIN postings ON postings.account_id = ledger_accounts.id").
+		Joins("LEFT JOIN journal_entries ON journal_entries.id = postings.entry_id").
+		Group("billings.id").
+		Having("stored_paid <> posted_paid OR charged <> posted_charged").
+		Scan(&billings).Error
+	if err != nil {
+		return report, fmt.Errorf("error checking billings: %w", err)
+	}
+	for _, billing := range billings {
+		if billing.StoredPaid != billing.PostedPaid {
+			report.Problems = append(report.Problems,
+				fmt.Sprintf("billing %d stored paid amount %d differs from ledger %d", billing.ID, billing.StoredPaid, billing.PostedPaid))
+		}
+		if billing.Charged != billing.PostedCharged {
+			report.Problems = append(report.Problems,
+				fmt.Sprintf("billing %d charges %d differ from ledger %d", billing.ID, billing.Charged, billing.PostedCharged))
+		}
+	}
+
+	report.OK = len(report.Problems) == 0
+	return report, nil
+}
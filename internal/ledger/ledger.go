@@ -0,0 +1,227 @@
+package ledger
+
+import (
+	"fmt"
+	"math"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	Debit  = "debit"
+	Credit = "credit"
+)
+
+// Line é uma partida de um lançamento ainda não gravado.
+type Line struct {
+	Account string
+	Side    string
+	Amount  money.Amount
+}
+
+// Totals são os débitos e créditos acumulados de uma conta.
+type Totals struct {
+	Debit  money.Amount `json:"debit"`
+	Credit money.Amount `json:"credit"`
+}
+
+// BillingAccount é a conta da cobrança: o saldo devedor (débitos menos
+// créditos) é o que o pagador ainda deve ao recebedor.
+func BillingAccount(billingID int32) string {
+	return fmt.Sprintf("billing:%d", billingID)
+}
+
+// UserAccount é a conta do usuário: o saldo credor é o que ele tem a receber
+// nas cobranças em que é o recebedor.
+func UserAccount(userID int32) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// Post grava um lançamento com suas partidas e atualiza a projeção de
+// saldos. O lançamento precisa ter ao menos duas partidas e os débitos devem
+// ser iguais aos créditos. Deve ser chamado dentro de uma transação.
+func Post(tx *gorm.DB, kind, reference, description string, lines ...Line) (models.JournalEntry, error) {
+	if len(lines) < 2 {
+		return models.JournalEntry{}, fmt.Errorf("a journal entry needs at least two postings")
+	}
+
+	var debits, credits money.Amount
+	for _, line := range lines {
+		if line.Amount <= 0 {
+			return models.JournalEntry{}, fmt.Errorf("posting amounts must be greater than zero")
+		}
+		var err error
+		switch line.Side {
+		case Debit:
+			debits, err = debits.Add(line.Amount)
+		case Credit:
+			credits, err = credits.Add(line.Amount)
+		default:
+			return models.JournalEntry{}, fmt.Errorf("unknown posting side %q", line.Side)
+		}
+		if err != nil {
+			return models.JournalEntry{}, err
+		}
+	}
+	if debits != credits {
+		return models.JournalEntry{}, fmt.Errorf("unbalanced journal entry: debits %d, credits %d", debits, credits)
+	}
+
+	entry := models.JournalEntry{Kind: kind, Reference: reference, Description: description, CreatedAt: time.Now()}
+	for _, line := range lines {
+		account, err := account(tx, line.Account)
+		if err != nil {
+			return models.JournalEntry{}, err
+		}
+		entry.Postings = append(entry.Postings, models.Posting{AccountID: account.ID, Side: line.Side, Amount: line.Amount})
+	}
+
+	if err := tx.Create(&entry).Error; err != nil {
+		return models.JournalEntry{}, fmt.Errorf("error creating journal entry: %w", err)
+	}
+
+	// O SQLite passa a soma para ponto flutuante quando ela estoura, então o
+	// limite é conferido antes: nenhuma linha atualizada quer dizer estouro.
+	for _, posting := range entry.Postings {
+		column := posting.Side
+		result := tx.Model(&models.AccountBalance{}).
+			Where("account_id = ? AND "+column+" <= ?", posting.AccountID, math.MaxInt64-int64(posting.Amount)).
+			Update(column, gorm.Expr(column+" + ?", posting.Amount))
+		if result.Error != nil {
+			return models.JournalEntry{}, fmt.Errorf("error updating account balance: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.JournalEntry{}, money.ErrOverflow
+		}
+	}
+	return entry, nil
+}
+
+// RecordCharge lança um valor devido: debita a cobrança e credita o
+// recebedor.
+func RecordCharge(tx *gorm.DB, billing models.Billing, charge models.Charge) error {
+	_, err := Post(tx, "charge", fmt.Sprintf("charge:%d", charge.ID), charge.Description,
+		Line{Account: BillingAccount(billing.ID), Side: Debit, Amount: charge.Amount},
+		Line{Account: UserAccount(billing.ReceiverID), Side: Credit, Amount: charge.Amount},
+	)
+	return err
+}
+
+// RecordPayment lança um pagamento: credita a cobrança e debita o
+// recebedor, que tem esse valor a menos a receber.
+func RecordPayment(tx *gorm.DB, billing models.Billing, payment models.Payment) error {
+	_, err := Post(tx, "payment", fmt.Sprintf("payment:%d", payment.ID), "",
+		Line{Account: UserAccount(billing.ReceiverID), Side: Debit, Amount: payment.Amount},
+		Line{Account: BillingAccount(billing.ID), Side: Credit, Amount: payment.Amount},
+	)
+	return err
+}
+
+// RecordReversal lança o estorno de um pagamento, espelhando RecordPayment.
+// credit é a parte do estorno que devolve crédito do pagador na cobrança, e
+// não valor em aberto; ela sai numa partida própria da cobrança.
+func RecordReversal(tx *gorm.DB, billing models.Billing, reversal models.PaymentReversal, credit money.Amount) error {
+	if credit < 0 || credit > reversal.Amount {
+		return fmt.Errorf("reversal credit %d must be between zero and the reversed amount %d", credit, reversal.Amount)
+	}
+	lines := []Line{{Account: UserAccount(billing.ReceiverID), Side: Credit, Amount: reversal.Amount}}
+	if credit > 0 {
+		lines = append(lines, Line{Account: BillingAccount(billing.ID), Side: Debit, Amount: credit})
+	}
+	if owed := reversal.Amount - credit; owed > 0 {
+		lines = append(lines, Line{Account: BillingAccount(billing.ID), Side: Debit, Amount: owed})
+	}
+	_, err := Post(tx, "reversal", fmt.Sprintf("reversal:%d", reversal.ID), reversal.Reason, lines...)
+	return err
+}
+
+// TotalsByKind soma as partidas das contas pedidas separadas pelo tipo do
+// lançamento (charge, payment, reversal...).
+func TotalsByKind(tx *gorm.DB, codes []string) (map[string]map[string]Totals, error) {
+	var rows []struct {
+		Code   string
+		Kind   string
+		Debit  money.Amount
+		Credit money.Amount
+	}
+	err := tx.Table("postings").
+		Select("ledger_accounts.code, journal_entries.kind, "+
+			"SUM(CASE WHEN postings.side = ? THEN postings.amount ELSE 0 END) AS debit, "+
+			"SUM(CASE WHEN postings.side = ? THEN postings.amount ELSE 0 END) AS credit", Debit, Credit).
+		Joins("JOIN ledger_accounts ON ledger_accounts.id = postings.account_id").
+		Joins("JOIN journal_entries ON journal_entries.id = postings.entry_id").
+		Where("ledger_accounts.code IN ?", codes).
+		Group("ledger_accounts.code, journal_entries.kind").Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("error summing postings: %w", err)
+	}
+
+	totals := make(map[string]map[string]Totals)
+	for _, row := range rows {
+		if totals[row.Code] == nil {
+			totals[row.Code] = make(map[string]Totals)
+		}
+		totals[row.Code][row.Kind] = Totals{Debit: row.Debit, Credit: row.Credit}
+	}
+	return totals, nil
+}
+
+// Balances lê da projeção os totais das contas pedidas. Contas sem
+// lançamentos ficam fora do mapa.
+func Balances(tx *gorm.DB, codes []string) (map[string]Totals, error) {
+	var rows []struct {
+		Code   string
+		Debit  money.Amount
+		Credit money.Amount
+	}
+	err := tx.Table("ledger_accounts").
+		Select("ledger_accounts.code, account_balances.debit, account_balances.credit").
+		Joins("JOIN account_balances ON account_balances.account_id = ledger_accounts.id").
+		Where("ledger_accounts.code IN ?", codes).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("error loading account balances: %w", err)
+	}
+
+	balances := make(map[string]Totals, len(rows))
+	for _, row := range rows {
+		balances[row.Code] = Totals{Debit: row.Debit, Credit: row.Credit}
+	}
+	return balances, nil
+}
+
+// Balance soma diretamente as partidas de uma conta, sem usar a projeção.
+func Balance(tx *gorm.DB, code string) (Totals, error) {
+	var totals Totals
+	err := tx.Table("postings").
+		Select("COALESCE(SUM(CASE WHEN side = ? THEN amount ELSE 0 END), 0) AS debit, "+
+			"COALESCE(SUM(CASE WHEN side = ? THEN amount ELSE 0 END), 0) AS credit", Debit, Credit).
+		Joins("JOIN ledger_accounts ON ledger_accounts.id = postings.account_id").
+		Where("ledger_accounts.code = ?", code).Scan(&totals).Error
+	if err != nil {
+		return totals, fmt.Errorf("error summing postings: %w", err)
+	}
+	return totals, nil
+}
+
+func account(tx *gorm.DB, code string) (models.LedgerAccount, error) {
+	account := models.LedgerAccount{Code: code, CreatedAt: time.Now()}
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error
+	if err != nil {
+		return account, fmt.Errorf("error creating ledger account: %w", err)
+	}
+	if account.ID == 0 {
+		if err := tx.Where("code = ?", code).First(&account).Error; err != nil {
+			return account, fmt.Errorf("error loading ledger account: %w", err)
+		}
+		return account, nil
+	}
+
+	if err := tx.Create(&models.AccountBalance{AccountID: account.ID}).Error; err != nil {
+		return account, fmt.Errorf("error creating account balance: %w", err)
+	}
+	return account, nil
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"me-pague/internal/auth"
+	"me-pague/internal/controller/response"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth exige um token de acesso válido no cabeçalho Authorization e grava o
+// ID do usuário no contexto. O token é verificado com options.
+func Auth(options auth.Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response.ErrorResponse{Error: "Missing bearer token"})
+			return
+		}
+
+		claims, err := options.Verify(token, auth.AccessToken, time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		auth.SetUserID(c, claims.Subject)
+		c.Next()
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"me-pague/internal/auth"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const IdempotencyHeader = "Idempotency-Key"
+
+// DefaultIdempotencyRetention é por quanto tempo uma chave é lembrada.
+const DefaultIdempotencyRetention = 24 * time.Hour
+
+// Idempotency faz com que requisições repetidas com o mesmo cabeçalho
+// Idempotency-Key recebam a resposta original em vez de serem executadas de
+// novo. Reusar a chave com outro corpo é rejeitado com 422, e uma repetição
+// enquanto a original ainda está em andamento recebe 409. Respostas 409 e
+// 5xx não são guardadas, para que o cliente possa tentar de novo com a mesma
+// chave. Requisições sem o cabeçalho passam direto. As chaves são de cada
+// usuário autenticado: a mesma chave enviada por outro usuário não devolve a
+// resposta do primeiro. As chaves ficam guardadas em db.
+func Idempotency(db *gorm.DB, retention time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		userID, _ := auth.UserID(c)
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, response.ErrorResponse{Error: "Error reading request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := requestFingerprint(c.Request.Method, c.FullPath(), body)
+
+		now := time.Now()
+		if _, err := PurgeExpiredIdempotencyKeys(db, now); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error purging idempotency keys: " + err.Error()})
+			return
+		}
+
+		record := models.IdempotencyKey{UserID: userID, Key: key, Fingerprint: fingerprint, CreatedAt: now, ExpiresAt: now.Add(retention)}
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error storing idempotency key: " + result.Error.Error()})
+			return
+		}
+
+		if result.RowsAffected == 0 {
+			replay(c, db, userID, key, fingerprint)
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status == http.StatusConflict || status >= http.StatusInternalServerError {
+			db.Where("user_id = ? AND key = ?", userID, key).Delete(&models.IdempotencyKey{})
+			return
+		}
+
+		db.Model(&models.IdempotencyKey{}).Where("user_id = ? AND key = ?", userID, key).Updates(map[string]interface{}{
+			"status_code":   status,
+			"content_type":  recorder.Header().Get("Content-Type"),
+			"response_body": recorder.body.Bytes(),
+		})
+	}
+}
+
+// PurgeExpiredIdempotencyKeys apaga as chaves cuja retenção já passou.
+func PurgeExpiredIdempotencyKeys(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+func replay(c *gin.Context, db *gorm.DB, userID int32, key, fingerprint string) {
+	var stored models.IdempotencyKey
+	if err := db.Where("user_id = ? AND key = ?", userID, key).First(&stored).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, response.ErrorResponse{Error: "Error loading idempotency key: " + err.Error()})
+		return
+	}
+
+	if stored.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, response.ErrorResponse{Error: "Idempotency-Key was already used with a different request"})
+		return
+	}
+	if stored.StatusCode == 0 {
+		c.AbortWithStatusJSON(http.StatusConflict, response.ErrorResponse{Error: "A request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.StatusCode, stored.ContentType, stored.ResponseBody)
+	c.Abort()
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// bodyRecorder copia o corpo da resposta enquanto ele é escrito.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *bodyRecorder) WriteString(data string) (int, error) {
+	r.body.WriteString(data)
+	return r.ResponseWriter.WriteString(data)
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"me-pague/internal/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader é o cabeçalho usado para correlacionar uma requisição com
+// os eventos de auditoria que ela gerou.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limita o ID aceito do cliente.
+const maxRequestIDLength = 128
+
+// RequestID reaproveita o X-Request-ID enviado pelo cliente ou gera um novo,
+// grava no contexto e devolve no cabeçalho da resposta.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+
+		audit.SetRequestID(c, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
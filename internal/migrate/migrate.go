@@ -0,0 +1,239 @@
+// Package migrate aplica e desfaz migrações SQL versionadas.
+//
+// Cada migração é um par de arquivos NNNN_nome.up.sql e NNNN_nome.down.sql;
+// a versão NNNN define a ordem. As aplicadas ficam na tabela
+// schema_migrations com o checksum dos dois arquivos, e uma migração editada
+// depois de aplicada é erro: a correção deve ir numa migração nova. Cada
+// migração roda numa transação junto com o seu registro.
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Table é a tabela com as migrações aplicadas.
+const Table = "schema_migrations"
+
+var (
+	// ErrChecksumMismatch indica uma migração alterada depois de aplicada.
+	ErrChecksumMismatch = errors.New("migration was edited after it was applied")
+	// ErrUnknownMigration indica uma migração aplicada que não existe mais
+	// nos arquivos.
+	ErrUnknownMigration = errors.New("applied migration has no file")
+)
+
+var filePattern = regexp.MustCompile(`^([0-9]+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration é uma migração lida dos arquivos.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// String é o nome do arquivo sem a direção, como "0001_initial_schema".
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// State é uma migração com a situação dela no banco.
+type State struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// record é uma linha de schema_migrations.
+type record struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (record) TableName() string { return Table }
+
+// Load lê as migrações da raiz de fsys, em ordem de versão. Arquivos que não
+// terminam em .sql são ignorados; um .sql fora do padrão, uma versão
+// repetida ou uma migração sem up ou sem down é erro.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := filePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s must be named NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("migration file %s must have a version greater than zero", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", entry.Name(), err)
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down file", m)
+		}
+		m.Checksum = checksum(m.Up, m.Down)
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up aplica as migrações pendentes, em ordem, e devolve as que aplicou.
+func Up(db *gorm.DB, fsys fs.FS) ([]Migration, error) {
+	migrations, applied, err := prepare(db, fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&record{Version: m.Version, Name: m.Name, Checksum: m.Checksum, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("error applying migration %s: %w", m, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down desfaz as últimas steps migrações aplicadas, da mais nova para a mais
+// velha, e devolve as que desfez.
+func Down(db *gorm.DB, fsys fs.FS, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1")
+	}
+	migrations, applied, err := prepare(db, fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&record{}, m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("error reverting migration %s: %w", m, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Status devolve todas as migrações com a situação de cada uma.
+func Status(db *gorm.DB, fsys fs.FS) ([]State, error) {
+	migrations, applied, err := prepare(db, fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]State, len(migrations))
+	for i, m := range migrations {
+		states[i] = State{Migration: m}
+		if r, ok := applied[m.Version]; ok {
+			states[i].Applied, states[i].AppliedAt = true, r.AppliedAt
+		}
+	}
+	return states, nil
+}
+
+// prepare cria schema_migrations se preciso, lê os arquivos e as migrações
+// aplicadas e confere que elas ainda batem com os arquivos.
+func prepare(db *gorm.DB, fsys fs.FS) ([]Migration, map[int]record, error) {
+	err := db.Exec("CREATE TABLE IF NOT EXISTS " + Table + " (" +
+		"version integer PRIMARY KEY, name text NOT NULL, checksum text NOT NULL, applied_at datetime NOT NULL)").Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating %s: %w", Table, err)
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []record
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, nil, fmt.Errorf("error loading %s: %w", Table, err)
+	}
+
+	known := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+	applied := make(map[int]record, len(records))
+	for _, r := range records {
+		m, ok := known[r.Version]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %04d_%s", ErrUnknownMigration, r.Version, r.Name)
+		}
+		if m.Checksum != r.Checksum {
+			return nil, nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, m)
+		}
+		applied[r.Version] = r
+	}
+	return migrations, applied, nil
+}
+
+// checksum é o SHA-256 dos arquivos up e down.
+func checksum(up, down string) string {
+	sum := sha256.Sum256([]byte(up + "\x00" + down))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditEvent registra uma escrita: quem fez, o que fez, em qual entidade e
+// como ela ficou antes e depois. ActorID zero indica o próprio sistema, como
+// na expiração de pagamentos pendentes.
+type AuditEvent struct {
+	ID         int32           `gorm:"primaryKey" json:"id"`
+	ActorID    int32           `gorm:"index" json:"actor_id"`
+	Action     string          `json:"action"`
+	EntityType string          `gorm:"index:idx_audit_entity" json:"entity_type"`
+	EntityID   int32           `gorm:"index:idx_audit_entity" json:"entity_id"`
+	Before     json.RawMessage `gorm:"type:text" json:"before,omitempty"`
+	After      json.RawMessage `gorm:"type:text" json:"after,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	ClientIP   string          `json:"client_ip,omitempty"`
+	CreatedAt  time.Time       `gorm:"index" json:"created_at"`
+}
+
+func (AuditEvent) BeforeUpdate(*gorm.DB) error { return ErrAppendOnly }
+func (AuditEvent) BeforeDelete(*gorm.DB) error { return ErrAppendOnly }
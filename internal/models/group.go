@@ -0,0 +1,39 @@
+package models
+
+import (
+	"me-pague/internal/money"
+	"time"
+)
+
+type Group struct {
+	ID        int32         `gorm:"primaryKey" json:"id"`
+	Name      string        `json:"name"`
+	Members   []GroupMember `json:"members"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type GroupMember struct {
+	ID      int32 `gorm:"primaryKey" json:"-"`
+	GroupID int32 `gorm:"uniqueIndex:idx_group_member" json:"-"`
+	UserID  int32 `gorm:"uniqueIndex:idx_group_member" json:"user_id"`
+}
+
+type GroupExpense struct {
+	ID          int32          `gorm:"primaryKey" json:"id"`
+	GroupID     int32          `json:"group_id"`
+	PayerID     int32          `json:"payer_id"`
+	Description string         `json:"description"`
+	Total       money.Amount   `json:"total"`
+	SplitType   string         `json:"split_type"`
+	Shares      []ExpenseShare `gorm:"foreignKey:ExpenseID" json:"shares"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type ExpenseShare struct {
+	ID        int32        `gorm:"primaryKey" json:"-"`
+	ExpenseID int32        `json:"-"`
+	UserID    int32        `json:"user_id"`
+	Amount    money.Amount `json:"amount"`
+	BillingID int32        `json:"billing_id,omitempty"`
+	ChargeID  int32        `json:"charge_id,omitempty"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyKey guarda a resposta dada a uma requisição com o cabeçalho
+// Idempotency-Key. A chave vale por usuário: UserID é quem fez a requisição.
+// StatusCode zero indica que a requisição ainda está em andamento.
+type IdempotencyKey struct {
+	UserID       int32  `gorm:"primaryKey;autoIncrement:false"`
+	Key          string `gorm:"primaryKey"`
+	Fingerprint  string `gorm:"not null"`
+	StatusCode   int    `gorm:"not null;default:0"`
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time `gorm:"index"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"me-pague/internal/money"
+	"time"
+)
+
+// InstallmentPlan parcela o que estava em aberto numa cobrança. PaidBefore é
+// o total pago da cobrança quando o plano foi criado: o que for pago além
+// disso abate as parcelas, da mais antiga para a mais nova.
+type InstallmentPlan struct {
+	ID         int32        `gorm:"primaryKey" json:"id"`
+	BillingID  int32        `gorm:"index" json:"billing_id"`
+	Total      money.Amount `json:"total"`
+	Count      int32        `json:"count"`
+	PaidBefore money.Amount `json:"paid_before"`
+	CreatedBy  int32        `json:"created_by"`
+	CreatedAt  time.Time    `json:"created_at"`
+
+	Paid         money.Amount  `gorm:"-" json:"paid"`
+	Installments []Installment `gorm:"foreignKey:PlanID" json:"installments"`
+}
+
+// Installment é uma parcela do plano. Paid e Status são calculados a partir
+// dos pagamentos da cobrança a cada leitura.
+type Installment struct {
+	ID      int32        `gorm:"primaryKey" json:"id"`
+	PlanID  int32        `gorm:"index" json:"plan_id"`
+	Number  int32        `json:"number"`
+	Amount  money.Amount `json:"amount"`
+	DueDate time.Time    `json:"due_date"`
+
+	Paid   money.Amount `gorm:"-" json:"paid"`
+	Status string       `gorm:"-" json:"status"`
+}
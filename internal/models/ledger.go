@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"me-pague/internal/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAppendOnly é devolvido ao tentar alterar ou apagar lançamentos do razão
+// ou eventos de auditoria.
+var ErrAppendOnly = errors.New("records are append-only")
+
+type LedgerAccount struct {
+	ID        int32     `gorm:"primaryKey" json:"id"`
+	Code      string    `gorm:"uniqueIndex" json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type JournalEntry struct {
+	ID          int32     `gorm:"primaryKey" json:"id"`
+	Kind        string    `json:"kind"`
+	Reference   string    `gorm:"index" json:"reference"`
+	Description string    `json:"description"`
+	Postings    []Posting `gorm:"foreignKey:EntryID" json:"postings"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Posting struct {
+	ID        int32        `gorm:"primaryKey" json:"id"`
+	EntryID   int32        `gorm:"index" json:"-"`
+	AccountID int32        `gorm:"index" json:"account_id"`
+	Side      string       `json:"side"`
+	Amount    money.Amount `json:"amount"`
+}
+
+// AccountBalance é a projeção materializada dos lançamentos de uma conta.
+type AccountBalance struct {
+	AccountID int32        `gorm:"primaryKey" json:"account_id"`
+	Debit     money.Amount `json:"debit"`
+	Credit    money.Amount `json:"credit"`
+}
+
+func (JournalEntry) BeforeUpdate(*gorm.DB) error { return ErrAppendOnly }
+func (JournalEntry) BeforeDelete(*gorm.DB) error { return ErrAppendOnly }
+func (Posting) BeforeUpdate(*gorm.DB) error      { return ErrAppendOnly }
+func (Posting) BeforeDelete(*gorm.DB) error      { return ErrAppendOnly }
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"me-pague/internal/money"
+	"time"
+)
+
+// Situações do empréstimo.
+const (
+	LoanActive  = "active"
+	LoanPaidOff = "paid_off"
+)
+
+// Loan é um empréstimo com juros sobre a cobrança entre o tomador (pagador)
+// e o credor (recebedor). As parcelas da tabela são lançadas na cobrança no
+// vencimento, e cada uma recebe o que os pagamentos da cobrança quitaram do
+// lançamento dela.
+type Loan struct {
+	ID            int32        `gorm:"primaryKey" json:"id"`
+	BillingID     int32        `gorm:"index" json:"billing_id"`
+	Principal     money.Amount `json:"principal"`
+	MonthlyRateBP int32        `json:"monthly_rate_bp"`
+	Term          int32        `json:"term"`
+	System        string       `json:"system"`
+	FirstDueDate  time.Time    `json:"first_due_date"`
+	Status        string       `json:"status"`
+	PaidOffAt     *time.Time   `json:"paid_off_at,omitempty"`
+	CreatedBy     int32        `json:"created_by"`
+	CreatedAt     time.Time    `json:"created_at"`
+
+	Paid         money.Amount      `gorm:"-" json:"paid"`
+	PayoffAmount money.Amount      `gorm:"-" json:"payoff_amount"`
+	Installments []LoanInstallment `gorm:"foreignKey:LoanID" json:"installments"`
+}
+
+// LoanInstallment é uma linha da tabela: a prestação dividida em juros e
+// amortização e o saldo devedor depois dela. ChargeID fica vazio até a
+// parcela ser lançada na cobrança. Paid e Status são calculados a cada
+// leitura.
+type LoanInstallment struct {
+	ID        int32        `gorm:"primaryKey" json:"id"`
+	LoanID    int32        `gorm:"index" json:"loan_id"`
+	Number    int32        `json:"number"`
+	DueDate   time.Time    `gorm:"index" json:"due_date"`
+	Payment   money.Amount `json:"payment"`
+	Interest  money.Amount `json:"interest"`
+	Principal money.Amount `json:"principal"`
+	Balance   money.Amount `json:"balance"`
+	ChargeID  *int32       `json:"charge_id"`
+
+	Paid   money.Amount `gorm:"-" json:"paid"`
+	Status string       `gorm:"-" json:"status"`
+}
@@ -1,24 +1,81 @@
 package models
 
-import "time"
+import (
+	"me-pague/internal/money"
+	"time"
+)
 
 type User struct {
 	ID   int32   `gorm:"primaryKey" json:"id"`
 	Name string `gorm:"unique" json:"name"`
+	PasswordHash string `json:"-"`
 }
 
 type Payment struct {
 	ID          int32      `gorm:"primaryKey" json:"id"`
 	PayerID     int32      `json:"payer_id"`
 	BillingID   int32      `json:"-"`
-	Amount	    int32     `json:"amount"`
+	Amount	    money.Amount `json:"amount"`
+	Currency    string    `gorm:"not null;default:BRL" json:"currency"`
+	Credit      money.Amount `json:"credit,omitempty"`
+	Status      string    `gorm:"index;not null;default:confirmed" json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+
+	// Pagamento feito em outra moeda: Amount já está convertido para a moeda
+	// da cobrança. ExchangeRate é quanto uma unidade de OriginalCurrency vale
+	// na moeda da cobrança, cotada em RateDate, e Rounding é o valor
+	// convertido menos o exato, na menor unidade da moeda da cobrança.
+	OriginalAmount   money.Amount `json:"original_amount,omitempty"`
+	OriginalCurrency string     `json:"original_currency,omitempty"`
+	ExchangeRate     string     `json:"exchange_rate,omitempty"`
+	RateDate         *time.Time `json:"rate_date,omitempty"`
+	Rounding         string     `json:"rounding,omitempty"`
+
+	Reversals []PaymentReversal `gorm:"foreignKey:PaymentID" json:"reversals,omitempty"`
+}
+
+// Situações de um pagamento. Só os confirmados contam para a cobrança.
+const (
+	PaymentPending   = "pending"
+	PaymentConfirmed = "confirmed"
+	PaymentRejected  = "rejected"
+	PaymentExpired   = "expired"
+)
+
+// PaymentReversal estorna, total ou parcialmente, um pagamento.
+type PaymentReversal struct {
+	ID        int32     `gorm:"primaryKey" json:"id"`
+	PaymentID int32     `gorm:"index" json:"payment_id"`
+	Amount    money.Amount `json:"amount"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Billing struct {
 	ID        	int32      `gorm:"primaryKey" json:"id"`
 	PayerID 	int32      `json:"payer_id"`
 	ReceiverID 	int32      `json:"receiver_id"`
-	Amount    	int32      `json:"amount"`
+	Amount    	money.Amount `json:"amount"`
+	Currency  	string     `gorm:"not null;default:BRL" json:"currency"`
 	CreatedAt 	time.Time  `json:"created_at"`
+	Version   	int32      `gorm:"not null;default:0" json:"-"`
+
+	TotalCharged money.Amount `gorm:"-" json:"total_charged"`
+	TotalPaid    money.Amount `gorm:"-" json:"total_paid"`
+	Outstanding  money.Amount `gorm:"-" json:"outstanding"`
+	Credit       money.Amount `gorm:"-" json:"credit"`
+}
+
+// Charge é um valor que o pagador da cobrança deve ao recebedor.
+type Charge struct {
+	ID          int32     `gorm:"primaryKey" json:"id"`
+	BillingID   int32     `gorm:"index" json:"billing_id"`
+	Amount      money.Amount `json:"amount"`
+	Currency    string    `gorm:"not null;default:BRL" json:"currency"`
+	Description string    `json:"description"`
+	Date        time.Time `json:"date"`
+	// DueDate é o vencimento; sem ele o lançamento nunca fica em atraso.
+	DueDate   *time.Time `json:"due_date,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
 }
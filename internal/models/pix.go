@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PixKey é uma chave Pix em que o usuário aceita receber. Cada chave pertence
+// a um único usuário, e cada usuário tem no máximo uma chave padrão, usada
+// quando um código Pix é gerado sem chave explícita.
+type PixKey struct {
+	ID        int32     `gorm:"primaryKey" json:"id"`
+	UserID    int32     `gorm:"index;uniqueIndex:idx_pix_keys_default,where:is_default" json:"user_id"`
+	Type      string    `gorm:"not null" json:"type"`
+	Key       string    `gorm:"uniqueIndex;not null" json:"key"`
+	IsDefault bool      `gorm:"not null;default:false" json:"default"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"me-pague/internal/money"
+	"time"
+)
+
+// RecurringBilling é o modelo de uma cobrança que se repete, como aluguel ou
+// mensalidade. A cada período o agendador lança um Charge na cobrança entre
+// as duas partes. NextPeriod conta os períodos já lançados e NextDate é a
+// data do próximo; fica nula quando a recorrência termina ou é cancelada.
+type RecurringBilling struct {
+	ID          int32        `gorm:"primaryKey" json:"id"`
+	PayerID     int32        `gorm:"index" json:"payer_id"`
+	ReceiverID  int32        `gorm:"index" json:"receiver_id"`
+	Amount      money.Amount `json:"amount"`
+	Description string       `json:"description"`
+	Frequency   string       `gorm:"not null" json:"frequency"`
+	Interval    int32        `gorm:"not null;default:1" json:"interval"`
+	StartDate   time.Time    `json:"start_date"`
+	EndDate     *time.Time   `json:"end_date,omitempty"`
+	NextPeriod  int32        `gorm:"not null;default:0" json:"next_period"`
+	NextDate    *time.Time   `gorm:"index" json:"next_date,omitempty"`
+	CreatedBy   int32        `json:"created_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CanceledAt  *time.Time   `json:"canceled_at,omitempty"`
+}
+
+// RecurringRun registra o lançamento de um período. O índice único impede
+// que o mesmo período vire dois lançamentos, mesmo com dois agendadores
+// rodando ao mesmo tempo.
+type RecurringRun struct {
+	ID                 int32     `gorm:"primaryKey" json:"id"`
+	RecurringBillingID int32     `gorm:"uniqueIndex:idx_recurring_runs_period" json:"recurring_billing_id"`
+	Period             int32     `gorm:"uniqueIndex:idx_recurring_runs_period" json:"period"`
+	PeriodDate         time.Time `json:"period_date"`
+	BillingID          int32     `json:"billing_id"`
+	ChargeID           int32     `json:"charge_id"`
+	CreatedAt          time.Time `json:"created_at"`
+}
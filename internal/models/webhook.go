@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookSubscription recebe, na URL informada, os eventos dos tipos
+// escolhidos que envolvem cobranças das quais o dono faz parte. O segredo
+// assina cada entrega e nunca é devolvido pela API.
+type WebhookSubscription struct {
+	ID         int32     `gorm:"primaryKey" json:"id"`
+	OwnerID    int32     `gorm:"index" json:"owner_id"`
+	URL        string    `gorm:"not null" json:"url"`
+	Secret     string    `gorm:"not null" json:"-"`
+	EventTypes []string  `gorm:"serializer:json" json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Situações de uma entrega de webhook.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery é o envio de um evento a uma assinatura. Fica pendente até
+// o assinante responder 2xx ou até esgotar as tentativas, quando vai para
+// dead e só sai de lá com uma reentrega manual.
+type WebhookDelivery struct {
+	ID             int32           `gorm:"primaryKey" json:"id"`
+	SubscriptionID int32           `gorm:"index" json:"subscription_id"`
+	EventID        string          `gorm:"index" json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `gorm:"type:text" json:"payload"`
+	Status         string          `gorm:"index;not null" json:"status"`
+	Attempts       int             `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time       `gorm:"index" json:"next_attempt_at"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
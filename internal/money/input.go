@@ -0,0 +1,77 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// Input é um valor recebido no JSON, ainda sem moeda: um inteiro na menor
+// unidade (1250) ou um texto decimal ("12.50"). As casas do texto só são
+// conhecidas quando a moeda é, em In.
+type Input struct {
+	minor   int64
+	decimal string
+	set     bool
+}
+
+// Minor cria uma entrada com o valor na menor unidade.
+func Minor(amount Amount) Input {
+	return Input{minor: int64(amount), set: true}
+}
+
+// Decimal cria uma entrada com o valor em texto decimal.
+func Decimal(s string) Input {
+	return Input{decimal: s, set: true}
+}
+
+// IsZero indica que o valor não foi informado ou é zero.
+func (in Input) IsZero() bool {
+	return !in.set || (in.decimal == "" && in.minor == 0)
+}
+
+// In converte a entrada para a menor unidade da moeda.
+func (in Input) In(currency string) (Amount, error) {
+	if in.decimal == "" {
+		return Amount(in.minor), nil
+	}
+	money, err := Parse(in.decimal, currency)
+	return money.Amount, err
+}
+
+func (in Input) MarshalJSON() ([]byte, error) {
+	if in.decimal != "" {
+		return json.Marshal(in.decimal)
+	}
+	return json.Marshal(in.minor)
+}
+
+func (in *Input) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*in = Input{}
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if !decimalPattern.MatchString(s) {
+			return fmt.Errorf("invalid amount %q", s)
+		}
+		*in = Decimal(s)
+		return nil
+	}
+
+	minor, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("amount must be an integer in minor units or a decimal string, like \"12.50\"")
+	}
+	*in = Minor(Amount(minor))
+	return nil
+}
@@ -0,0 +1,153 @@
+// Package money representa valores monetários como inteiros na menor unidade
+// da moeda (centavos, para o real). As contas são verificadas: uma soma que
+// passaria do limite devolve ErrOverflow em vez de dar a volta, e valores de
+// moedas diferentes não se misturam.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+var (
+	// ErrOverflow indica uma conta cujo resultado não cabe em Amount.
+	ErrOverflow = errors.New("amount is out of range")
+	// ErrCurrencyMismatch indica uma conta entre moedas diferentes.
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+)
+
+// minorUnits são as casas decimais das moedas aceitas (ISO 4217).
+var minorUnits = map[string]int{
+	"ARS": 2, "AUD": 2, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "COP": 2,
+	"EUR": 2, "GBP": 2, "JPY": 0, "MXN": 2, "PEN": 2, "PYG": 0, "USD": 2, "UYU": 2,
+}
+
+// MinorUnits é o número de casas decimais da moeda e se ela é aceita.
+func MinorUnits(currency string) (int, bool) {
+	units, ok := minorUnits[currency]
+	return units, ok
+}
+
+// Amount é um valor na menor unidade de uma moeda. No JSON é um inteiro.
+type Amount int64
+
+// Add soma b a a.
+func (a Amount) Add(b Amount) (Amount, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrOverflow
+	}
+	return a + b, nil
+}
+
+// Sub subtrai b de a.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		return 0, ErrOverflow
+	}
+	return a - b, nil
+}
+
+// Mul multiplica a por n.
+func (a Amount) Mul(n int64) (Amount, error) {
+	if a == 0 || n == 0 {
+		return 0, nil
+	}
+	product := a * Amount(n)
+	if product/Amount(n) != a || (a == -1 && n == math.MinInt64) || (n == -1 && a == math.MinInt64) {
+		return 0, ErrOverflow
+	}
+	return product, nil
+}
+
+// Sum soma os valores.
+func Sum(amounts ...Amount) (Amount, error) {
+	var total Amount
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// Format escreve o valor em unidades da moeda, com as casas decimais dela:
+// 1250 em BRL é "12.50".
+func (a Amount) Format(currency string) string {
+	units, _ := MinorUnits(currency)
+	return new(big.Rat).SetFrac(big.NewInt(int64(a)), pow10(units)).FloatString(units)
+}
+
+// Money é um valor com a sua moeda.
+type Money struct {
+	Amount   Amount `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// New cria o valor amount, na menor unidade de currency.
+func New(amount Amount, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Parse lê um valor decimal na moeda, como "12.50" ou "12", com no máximo as
+// casas decimais dela.
+func Parse(s, currency string) (Money, error) {
+	units, ok := MinorUnits(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("unsupported currency %q", currency)
+	}
+	amount, err := parseDecimal(s, units)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(amount, currency), nil
+}
+
+// Add soma o de o a m. As moedas precisam ser iguais.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	amount, err := m.Amount.Add(o.Amount)
+	return New(amount, m.Currency), err
+}
+
+// Sub subtrai o de m. As moedas precisam ser iguais.
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	amount, err := m.Amount.Sub(o.Amount)
+	return New(amount, m.Currency), err
+}
+
+// String escreve o valor com a moeda, como "12.50 BRL".
+func (m Money) String() string {
+	return m.Amount.Format(m.Currency) + " " + m.Currency
+}
+
+// parseDecimal converte "12.50" em 1250 quando units é 2. Mais casas do que
+// units é erro, para que nada seja arredondado sem aviso.
+func parseDecimal(s string, units int) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 && len(s)-i-1 > units {
+		return 0, fmt.Errorf("amount %q has more than %d decimal places", s, units)
+	}
+
+	r, _ := new(big.Rat).SetString(s)
+	r.Mul(r, new(big.Rat).SetInt(pow10(units)))
+	if !r.Num().IsInt64() {
+		return 0, ErrOverflow
+	}
+	return Amount(r.Num().Int64()), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
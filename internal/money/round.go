@@ -0,0 +1,53 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Rounding é a regra para levar um valor fracionário ao inteiro.
+type Rounding int
+
+const (
+	// HalfUp arredonda para o mais próximo, com as metades para longe do
+	// zero: 2,5 vira 3 e -2,5 vira -3.
+	HalfUp Rounding = iota
+	// HalfEven arredonda para o mais próximo, com as metades para o par
+	// (arredondamento bancário): 2,5 vira 2 e 3,5 vira 4.
+	HalfEven
+	// Down trunca, na direção do zero.
+	Down
+)
+
+func (r Rounding) String() string {
+	switch r {
+	case HalfUp:
+		return "half_up"
+	case HalfEven:
+		return "half_even"
+	case Down:
+		return "down"
+	default:
+		return fmt.Sprintf("Rounding(%d)", int(r))
+	}
+}
+
+// Round arredonda r para um Amount pela regra mode.
+func Round(r *big.Rat, mode Rounding) (Amount, error) {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+
+	if rem.Sign() != 0 && mode != Down {
+		// Compara o dobro do resto com o divisor para saber se passou da metade.
+		half := new(big.Int).Abs(rem)
+		half.Mul(half, big.NewInt(2))
+		cmp := half.Cmp(r.Denom())
+		if cmp > 0 || (cmp == 0 && (mode == HalfUp || quo.Bit(0) == 1)) {
+			quo.Add(quo, big.NewInt(int64(r.Sign())))
+		}
+	}
+
+	if !quo.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return Amount(quo.Int64()), nil
+}
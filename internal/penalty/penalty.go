@@ -0,0 +1,185 @@
+// Package penalty calcula multa e juros de mora dos lançamentos vencidos.
+// Todas as contas são feitas em inteiros, com taxas em pontos-base
+// (1/10000), e o resultado é truncado no centavo (money.Down), para que o
+// mesmo pedido dê sempre o mesmo valor.
+package penalty
+
+import (
+	"math/big"
+	"me-pague/internal/money"
+	"sort"
+	"time"
+)
+
+// basisPoints é o denominador das taxas: 10000 pontos-base são 100%.
+const basisPoints = 10000
+
+// daysPerMonth converte a taxa mensal de juros em diária, pro rata.
+const daysPerMonth = 30
+
+// Policy define os encargos por atraso.
+type Policy struct {
+	// LateFeeBP é a multa, cobrada uma vez sobre o que estava em aberto no
+	// vencimento.
+	LateFeeBP int64
+	// MonthlyInterestBP são os juros de mora ao mês, cobrados por dia de
+	// atraso sobre o que continua em aberto.
+	MonthlyInterestBP int64
+	// CapBP limita multa e juros somados, em relação ao valor do lançamento.
+	// Zero deixa sem limite.
+	CapBP int64
+}
+
+// DefaultPolicy devolve a política padrão: multa de 2%, juros de 1% ao mês
+// e encargos limitados a 20% do lançamento.
+func DefaultPolicy() Policy {
+	return Policy{LateFeeBP: 200, MonthlyInterestBP: 100, CapBP: 2000}
+}
+
+// Charge é um lançamento com o seu vencimento. Sem vencimento, nunca atrasa.
+type Charge struct {
+	ID      int32
+	Amount  money.Amount
+	DueDate *time.Time
+}
+
+// Payment é um valor pago na data informada.
+type Payment struct {
+	Amount money.Amount
+	Date   time.Time
+}
+
+// Assessment é a situação de um lançamento na data da consulta.
+type Assessment struct {
+	ChargeID int32        `json:"charge_id"`
+	Amount   money.Amount `json:"amount"`
+	Unpaid   money.Amount `json:"unpaid"`
+	DaysLate int          `json:"days_late"`
+	LateFee  money.Amount `json:"late_fee"`
+	Interest money.Amount `json:"interest"`
+}
+
+// Assess distribui os pagamentos feitos até asOf entre os lançamentos, do
+// vencimento mais antigo para o mais novo, e calcula os encargos de cada um.
+// Os lançamentos sem vencimento, que nunca atrasam, ficam por último, para
+// que os pagamentos abatam primeiro o que rende encargos. Os pagamentos
+// abatem o principal; multa e juros são somados à parte.
+func Assess(policy Policy, charges []Charge, payments []Payment, asOf time.Time) ([]Assessment, error) {
+	asOf = day(asOf)
+
+	ordered := append([]Charge{}, charges...)
+	sort.SliceStable(ordered, func(i, j int) bool { return dueBefore(ordered[i], ordered[j]) })
+
+	paid := append([]Payment{}, payments...)
+	sort.SliceStable(paid, func(i, j int) bool { return paid[i].Date.Before(paid[j].Date) })
+
+	// Cada lançamento guarda as parcelas que recebeu, com a data.
+	received := make([][]Payment, len(ordered))
+	next := 0
+	for _, payment := range paid {
+		if day(payment.Date).After(asOf) {
+			break
+		}
+		left := payment.Amount
+		for left > 0 && next < len(ordered) {
+			open := ordered[next].Amount - sum(received[next])
+			if open <= 0 {
+				next++
+				continue
+			}
+			part := min(left, open)
+			received[next] = append(received[next], Payment{Amount: part, Date: day(payment.Date)})
+			left -= part
+		}
+	}
+
+	result := make([]Assessment, len(ordered))
+	for i, charge := range ordered {
+		var err error
+		if result[i], err = assess(policy, charge, received[i], asOf); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func assess(policy Policy, charge Charge, received []Payment, asOf time.Time) (Assessment, error) {
+	a := Assessment{ChargeID: charge.ID, Amount: charge.Amount, Unpaid: charge.Amount - sum(received)}
+	if charge.DueDate == nil || !asOf.After(day(*charge.DueDate)) {
+		return a, nil
+	}
+	due := day(*charge.DueDate)
+	a.DaysLate = days(due, asOf)
+
+	// Saldo em aberto ao fim do vencimento, que sofre a multa.
+	balance := charge.Amount
+	i := 0
+	for ; i < len(received) && !received[i].Date.After(due); i++ {
+		balance -= received[i].Amount
+	}
+	lateFee, err := rate(big.NewInt(int64(balance)), policy.LateFeeBP, basisPoints)
+	if err != nil {
+		return a, err
+	}
+
+	// Juros: saldo x dias, trecho a trecho entre um pagamento e outro. O
+	// valor pago num dia já não rende juros naquele dia.
+	balanceDays := new(big.Int)
+	start := due.AddDate(0, 0, 1)
+	for ; i < len(received); i++ {
+		balanceDays.Add(balanceDays, new(big.Int).Mul(big.NewInt(int64(balance)), big.NewInt(int64(days(start, received[i].Date)))))
+		balance -= received[i].Amount
+		start = received[i].Date
+	}
+	balanceDays.Add(balanceDays, new(big.Int).Mul(big.NewInt(int64(balance)), big.NewInt(int64(days(start, asOf)+1))))
+	interest, err := rate(balanceDays, policy.MonthlyInterestBP, basisPoints*daysPerMonth)
+	if err != nil {
+		return a, err
+	}
+
+	if policy.CapBP > 0 {
+		limit, err := rate(big.NewInt(int64(charge.Amount)), policy.CapBP, basisPoints)
+		if err != nil {
+			return a, err
+		}
+		lateFee = min(lateFee, limit)
+		interest = min(interest, limit-lateFee)
+	}
+
+	a.LateFee, a.Interest = lateFee, interest
+	return a, nil
+}
+
+// rate é value x bp / denominator, truncado.
+func rate(value *big.Int, bp, denominator int64) (money.Amount, error) {
+	product := new(big.Int).Mul(value, big.NewInt(bp))
+	return money.Round(new(big.Rat).SetFrac(product, big.NewInt(denominator)), money.Down)
+}
+
+// dueBefore diz se a vence antes de b. Sem vencimento, o lançamento vem
+// depois de todos os que vencem.
+func dueBefore(a, b Charge) bool {
+	if a.DueDate == nil || b.DueDate == nil {
+		return a.DueDate != nil && b.DueDate == nil
+	}
+	return day(*a.DueDate).Before(day(*b.DueDate))
+}
+
+func sum(payments []Payment) money.Amount {
+	var total money.Amount
+	for _, payment := range payments {
+		total += payment.Amount
+	}
+	return total
+}
+
+// day é a data em UTC, sem as horas.
+func day(t time.Time) time.Time {
+	year, month, d := t.UTC().Date()
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// days conta os dias corridos de from até to.
+func days(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
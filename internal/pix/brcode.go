@@ -0,0 +1,163 @@
+// Package pix monta o BR Code (Pix "copia e cola") estático no padrão EMV
+// QRCPS-MPM adotado pelo Banco Central.
+package pix
+
+import (
+	"fmt"
+	"me-pague/internal/money"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// IDs dos campos do BR Code usados aqui.
+const (
+	idPayloadFormat       = "00"
+	idMerchantAccount     = "26"
+	idMerchantCategory    = "52"
+	idTransactionCurrency = "53"
+	idTransactionAmount   = "54"
+	idCountryCode         = "58"
+	idMerchantName        = "59"
+	idMerchantCity        = "60"
+	idAdditionalData      = "62"
+	idCRC                 = "63"
+
+	// Subcampos do 26 (conta do recebedor) e do 62 (dados adicionais).
+	idGUI            = "00"
+	idKey            = "01"
+	idReferenceLabel = "05"
+)
+
+// GUI identifica o arranjo Pix dentro do campo 26.
+const GUI = "br.gov.bcb.pix"
+
+// Limites de tamanho do padrão.
+const (
+	MaxKeyLength          = 77
+	MaxMerchantNameLength = 25
+	MaxMerchantCityLength = 15
+	MaxTxIDLength         = 25
+	MaxAmountLength       = 13
+)
+
+// NoTxID é o TXID dos códigos estáticos sem identificador.
+const NoTxID = "***"
+
+var txidPattern = regexp.MustCompile(`^[A-Za-z0-9]{1,25}$`)
+
+// Payload são os dados de um BR Code estático.
+type Payload struct {
+	Key          string
+	MerchantName string
+	MerchantCity string
+	// Amount é o valor em centavos; zero deixa o valor para o pagador.
+	Amount money.Amount
+	// TxID identifica a cobrança na conciliação; vazio vira NoTxID.
+	TxID string
+}
+
+// Encode monta o BR Code com o CRC16 no final. Nome e cidade perdem os
+// acentos e são cortados no tamanho máximo do padrão.
+func (p Payload) Encode() (string, error) {
+	key := strings.TrimSpace(p.Key)
+	if key == "" || len(key) > MaxKeyLength {
+		return "", fmt.Errorf("pix key must have between 1 and %d characters", MaxKeyLength)
+	}
+
+	name := MerchantName(p.MerchantName)
+	if name == "" {
+		return "", fmt.Errorf("merchant name is required")
+	}
+	city := truncate(Sanitize(p.MerchantCity), MaxMerchantCityLength)
+	if city == "" {
+		return "", fmt.Errorf("merchant city is required")
+	}
+
+	txid := p.TxID
+	if txid == "" {
+		txid = NoTxID
+	}
+	if txid != NoTxID && !txidPattern.MatchString(txid) {
+		return "", fmt.Errorf("txid must have between 1 and %d letters or digits", MaxTxIDLength)
+	}
+
+	if p.Amount < 0 {
+		return "", fmt.Errorf("amount must not be negative")
+	}
+	if len(FormatAmount(p.Amount)) > MaxAmountLength {
+		return "", fmt.Errorf("amount must have at most %d characters", MaxAmountLength)
+	}
+
+	var b strings.Builder
+	b.WriteString(field(idPayloadFormat, "01"))
+	b.WriteString(field(idMerchantAccount, field(idGUI, GUI)+field(idKey, key)))
+	b.WriteString(field(idMerchantCategory, "0000"))
+	b.WriteString(field(idTransactionCurrency, "986"))
+	if p.Amount > 0 {
+		b.WriteString(field(idTransactionAmount, FormatAmount(p.Amount)))
+	}
+	b.WriteString(field(idCountryCode, "BR"))
+	b.WriteString(field(idMerchantName, name))
+	b.WriteString(field(idMerchantCity, city))
+	b.WriteString(field(idAdditionalData, field(idReferenceLabel, txid)))
+
+	// O CRC cobre tudo, inclusive o ID e o tamanho do próprio campo.
+	b.WriteString(idCRC + "04")
+	b.WriteString(fmt.Sprintf("%04X", CRC16(b.String())))
+	return b.String(), nil
+}
+
+// FormatAmount escreve centavos no formato do campo 54, como "12.50".
+func FormatAmount(cents money.Amount) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+// CRC16 é o CRC16-CCITT (polinômio 0x1021, valor inicial 0xFFFF) exigido no
+// campo 63.
+func CRC16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// MerchantName é o nome como ele sai no campo 59: sem acentos e cortado no
+// tamanho máximo.
+func MerchantName(name string) string {
+	return truncate(Sanitize(name), MaxMerchantNameLength)
+}
+
+// Sanitize tira os acentos e descarta o que não for ASCII imprimível, como
+// os aplicativos dos bancos esperam no nome e na cidade.
+func Sanitize(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) || r < 0x20 || r > 0x7E {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func field(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.TrimSpace(s[:max])
+}
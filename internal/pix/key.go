@@ -0,0 +1,128 @@
+package pix
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Tipos de chave Pix.
+const (
+	KeyCPF   = "cpf"
+	KeyCNPJ  = "cnpj"
+	KeyEmail = "email"
+	KeyPhone = "phone"
+	KeyEVP   = "evp"
+)
+
+// KeyTypes são os tipos aceitos, na ordem em que aparecem na documentação.
+var KeyTypes = []string{KeyCPF, KeyCNPJ, KeyEmail, KeyPhone, KeyEVP}
+
+var (
+	phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+	evpPattern   = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+// NormalizeKey valida a chave conforme o tipo e devolve a forma em que ela
+// vai no BR Code: CPF e CNPJ só com dígitos, e-mail e EVP em minúsculas e
+// telefone no formato E.164.
+func NormalizeKey(keyType, key string) (string, error) {
+	key = strings.TrimSpace(key)
+
+	switch keyType {
+	case KeyCPF:
+		digits := onlyDigits(key, ".-")
+		if len(digits) != 11 || !validCPF(digits) {
+			return "", fmt.Errorf("invalid CPF")
+		}
+		return digits, nil
+	case KeyCNPJ:
+		digits := onlyDigits(key, "./-")
+		if len(digits) != 14 || !validCNPJ(digits) {
+			return "", fmt.Errorf("invalid CNPJ")
+		}
+		return digits, nil
+	case KeyEmail:
+		key = strings.ToLower(key)
+		address, err := mail.ParseAddress(key)
+		if err != nil || address.Address != key || len(key) > MaxKeyLength {
+			return "", fmt.Errorf("invalid email")
+		}
+		return key, nil
+	case KeyPhone:
+		if !phonePattern.MatchString(key) {
+			return "", fmt.Errorf("phone must be in E.164 format, like +5511999998888")
+		}
+		return key, nil
+	case KeyEVP:
+		key = strings.ToLower(key)
+		if !evpPattern.MatchString(key) {
+			return "", fmt.Errorf("random key must be a UUID")
+		}
+		return key, nil
+	default:
+		return "", fmt.Errorf("key type must be one of %s", strings.Join(KeyTypes, ", "))
+	}
+}
+
+// onlyDigits tira a pontuação usual do documento. Devolve vazio se sobrar
+// algo que não seja dígito.
+func onlyDigits(s, punctuation string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case !strings.ContainsRune(punctuation, r):
+			return ""
+		}
+	}
+	return b.String()
+}
+
+// validCPF confere os dois dígitos verificadores. Sequências repetidas, como
+// 111.111.111-11, passam na conta mas não são CPFs válidos.
+func validCPF(digits string) bool {
+	if repeated(digits) {
+		return false
+	}
+	for _, n := range []int{9, 10} {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += int(digits[i]-'0') * (n + 1 - i)
+		}
+		check := sum * 10 % 11 % 10
+		if check != int(digits[n]-'0') {
+			return false
+		}
+	}
+	return true
+}
+
+// validCNPJ confere os dois dígitos verificadores, com os pesos de 2 a 9
+// repetidos da direita para a esquerda.
+func validCNPJ(digits string) bool {
+	if repeated(digits) {
+		return false
+	}
+	for _, n := range []int{12, 13} {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += int(digits[i]-'0') * (2 + (n-1-i)%8)
+		}
+		check := 0
+		if sum%11 >= 2 {
+			check = 11 - sum%11
+		}
+		if check != int(digits[n]-'0') {
+			return false
+		}
+	}
+	return true
+}
+
+func repeated(digits string) bool {
+	return !slices.ContainsFunc([]byte(digits), func(d byte) bool { return d != digits[0] })
+}
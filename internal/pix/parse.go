@@ -0,0 +1,138 @@
+package pix
+
+import (
+	"errors"
+	"fmt"
+	"me-pague/internal/money"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrMalformed  = errors.New("malformed Pix payload")
+	ErrInvalidCRC = errors.New("Pix payload CRC does not match")
+)
+
+// Parsed são os campos lidos de um BR Code.
+type Parsed struct {
+	Key          string
+	MerchantName string
+	MerchantCity string
+	// Amount é o valor em centavos; zero quando o código não traz valor.
+	Amount money.Amount
+	TxID   string
+}
+
+// Parse lê um BR Code colado pelo usuário: confere o CRC, separa os campos
+// TLV e extrai a chave do recebedor, o valor, o nome, a cidade e o TXID.
+func Parse(payload string) (Parsed, error) {
+	payload = strings.TrimSpace(payload)
+
+	fields, err := parseTLV(payload)
+	if err != nil {
+		return Parsed{}, err
+	}
+	if len(fields) == 0 || fields[0].id != idPayloadFormat {
+		return Parsed{}, fmt.Errorf("%w: it must start with the payload format indicator", ErrMalformed)
+	}
+
+	crc := fields[len(fields)-1]
+	if crc.id != idCRC || len(crc.value) != 4 {
+		return Parsed{}, fmt.Errorf("%w: it must end with the CRC field", ErrMalformed)
+	}
+	if !strings.EqualFold(crc.value, fmt.Sprintf("%04X", CRC16(payload[:len(payload)-4]))) {
+		return Parsed{}, ErrInvalidCRC
+	}
+
+	var parsed Parsed
+	for _, f := range fields {
+		switch {
+		case f.id >= "26" && f.id <= "51" && parsed.Key == "":
+			parsed.Key, err = pixKey(f.value)
+		case f.id == idTransactionAmount:
+			parsed.Amount, err = ParseAmount(f.value)
+		case f.id == idMerchantName:
+			parsed.MerchantName = f.value
+		case f.id == idMerchantCity:
+			parsed.MerchantCity = f.value
+		case f.id == idAdditionalData:
+			parsed.TxID, err = subfield(f.value, idReferenceLabel)
+		}
+		if err != nil {
+			return Parsed{}, err
+		}
+	}
+
+	if parsed.Key == "" {
+		return Parsed{}, fmt.Errorf("%w: it has no Pix key", ErrMalformed)
+	}
+	return parsed, nil
+}
+
+// ParseAmount lê o valor do campo 54, como "12.50", em centavos.
+func ParseAmount(value string) (money.Amount, error) {
+	if len(value) > MaxAmountLength {
+		return 0, fmt.Errorf("%w: amount %q is too large", ErrMalformed, value)
+	}
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || len(fraction) > 2 || strings.Trim(whole+fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: invalid amount %q", ErrMalformed, value)
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	cents, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: amount %q is too large", ErrMalformed, value)
+	}
+	return money.Amount(cents), nil
+}
+
+// pixKey devolve a chave de um modelo de conta do recebedor, ou vazio se o
+// modelo não for do arranjo Pix.
+func pixKey(template string) (string, error) {
+	gui, err := subfield(template, idGUI)
+	if err != nil || !strings.EqualFold(gui, GUI) {
+		return "", err
+	}
+	return subfield(template, idKey)
+}
+
+func subfield(template, id string) (string, error) {
+	fields, err := parseTLV(template)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range fields {
+		if f.id == id {
+			return f.value, nil
+		}
+	}
+	return "", nil
+}
+
+type tlv struct {
+	id, value string
+}
+
+func parseTLV(data string) ([]tlv, error) {
+	var fields []tlv
+	for pos := 0; pos < len(data); {
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("%w: truncated field at position %d", ErrMalformed, pos)
+		}
+		id := data[pos : pos+2]
+		length, err := strconv.Atoi(data[pos+2 : pos+4])
+		if err != nil || length < 0 || strings.Trim(id, "0123456789") != "" {
+			return nil, fmt.Errorf("%w: invalid field header at position %d", ErrMalformed, pos)
+		}
+		pos += 4
+		if pos+length > len(data) {
+			return nil, fmt.Errorf("%w: field %s overflows the payload", ErrMalformed, id)
+		}
+		fields = append(fields, tlv{id: id, value: data[pos : pos+length]})
+		pos += length
+	}
+	return fields, nil
+}
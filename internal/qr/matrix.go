@@ -0,0 +1,283 @@
+package qr
+
+// drawFunctionPatterns desenha os padrões fixos: localizadores, sincronismo,
+// alinhamento e as áreas de formato e versão.
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	positions := alignmentPositions(c.Version, c.Size)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Os cantos já são dos localizadores.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignment(x, y)
+		}
+	}
+
+	// Reserva a área de formato; os bits certos entram depois da máscara.
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+// drawFinder desenha o localizador centrado em (x, y) com o separador claro
+// em volta.
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			dist := max(abs(dx), abs(dy))
+			xx, yy := x+dx, y+dy
+			if xx >= 0 && xx < c.Size && yy >= 0 && yy < c.Size {
+				c.setFunction(xx, yy, dist != 2 && dist != 4)
+			}
+		}
+	}
+}
+
+func (c *Code) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits grava o nível e a máscara, protegidos por BCH, nas duas
+// cópias da área de formato.
+func (c *Code) drawFormatBits(mask int) {
+	data := formatBits[c.Level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(bits, i))
+	}
+	c.setFunction(8, c.Size-8, true)
+}
+
+// drawVersion grava a versão, protegida por BCH, a partir da versão 7.
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+
+	rem := c.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := c.Version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, bit(bits, i))
+		c.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords preenche os módulos livres em zigue-zague, de duas em duas
+// colunas, da direita para a esquerda.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.isFunction[y][x] && i < len(data)*8 {
+					c.modules[y][x] = bit(int(data[i>>3]), 7-i&7)
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyBestMask testa as oito máscaras e fica com a de menor penalidade.
+func (c *Code) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		penalty := c.penalty()
+		if bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		c.applyMask(mask) // aplicar de novo desfaz
+	}
+
+	c.Mask = best
+	c.applyMask(best)
+	c.drawFormatBits(best)
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.isFunction[y][x] && maskBit(mask, x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// Pesos das regras de penalidade da norma.
+const (
+	penaltyN1 = 3
+	penaltyN2 = 3
+	penaltyN3 = 40
+	penaltyN4 = 10
+)
+
+// penalty avalia a matriz pelas quatro regras da norma: sequências longas da
+// mesma cor, blocos 2x2, padrões parecidos com o localizador e desequilíbrio
+// entre escuros e claros.
+func (c *Code) penalty() int {
+	result := 0
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return c.modules[x][y]
+		}
+		return c.modules[y][x]
+	}
+
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < c.Size; y++ {
+			run := 1
+			for x := 1; x <= c.Size; x++ {
+				if x < c.Size && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += penaltyN1 + run - 5
+				}
+				run = 1
+			}
+
+			for x := 0; x+11 <= c.Size; x++ {
+				if finderLike(func(i int) bool { return at(x+i, y, transpose) }) {
+					result += penaltyN3
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				color := c.modules[y][x]
+				if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+					result += penaltyN2
+				}
+			}
+		}
+	}
+
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return result + k*penaltyN4
+}
+
+// finderLike reconhece 1011101 com quatro claros antes ou depois numa janela
+// de onze módulos.
+func finderLike(at func(int) bool) bool {
+	const before, after = "00001011101", "10111010000"
+	matches := func(pattern string) bool {
+		for i := 0; i < len(pattern); i++ {
+			if at(i) != (pattern[i] == '1') {
+				return false
+			}
+		}
+		return true
+	}
+	return matches(before) || matches(after)
+}
+
+// alignmentPositions são as coordenadas dos centros dos padrões de
+// alinhamento da versão.
+func alignmentPositions(version, size int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, size-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunction[y][x] = true
+}
+
+func bit(value, i int) bool {
+	return (value>>i)&1 != 0
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
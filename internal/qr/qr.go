@@ -0,0 +1,221 @@
+// Package qr gera QR Codes (ISO/IEC 18004) em modo byte, o suficiente para
+// os códigos Pix. Segue o algoritmo de referência: escolhe a menor versão
+// que comporta os dados, acrescenta a correção de erros Reed-Solomon,
+// posiciona os módulos e aplica a máscara de menor penalidade.
+package qr
+
+import (
+	"errors"
+)
+
+// Level é o nível de correção de erros.
+type Level int
+
+const (
+	L Level = iota // recupera ~7% dos dados
+	M              // ~15%
+	Q              // ~25%
+	H              // ~30%
+)
+
+// ErrTooLong indica que os dados não cabem nem na versão 40.
+var ErrTooLong = errors.New("data too long for a QR code")
+
+// formatBits é o valor de cada nível nas informações de formato.
+var formatBits = [4]int{L: 1, M: 0, Q: 3, H: 2}
+
+// eccCodewordsPerBlock e numErrorCorrectionBlocks vêm da tabela 9 da norma,
+// indexados por nível e versão (a posição 0 não é usada).
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var numErrorCorrectionBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Code é um QR Code pronto: uma matriz quadrada de módulos escuros e claros.
+type Code struct {
+	Version int
+	Size    int
+	Level   Level
+	Mask    int
+
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Black informa se o módulo na coluna x, linha y é escuro. Fora da matriz
+// tudo é claro.
+func (c *Code) Black(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y][x]
+}
+
+// Encode gera o QR Code dos bytes em data com o nível de correção pedido.
+func Encode(data []byte, level Level) (*Code, error) {
+	version, capacityBits := 0, 0
+	for v := 1; v <= 40; v++ {
+		capacityBits = numDataCodewords(v, level) * 8
+		if 4+charCountBits(v)+len(data)*8 <= capacityBits {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	var bits bitBuffer
+	bits.append(0x4, 4) // modo byte
+	bits.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, capacityBits-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacityBits; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+
+	code := newCode(version, level)
+	code.drawFunctionPatterns()
+	code.drawCodewords(addECCAndInterleave(codewords, version, level))
+	code.applyBestMask()
+	return code, nil
+}
+
+func newCode(version int, level Level) *Code {
+	size := version*4 + 17
+	code := &Code{Version: version, Size: size, Level: level}
+	code.modules = make([][]bool, size)
+	code.isFunction = make([][]bool, size)
+	for y := range code.modules {
+		code.modules[y] = make([]bool, size)
+		code.isFunction[y] = make([]bool, size)
+	}
+	return code
+}
+
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// numRawDataModules é quantos módulos da versão sobram para dados e correção
+// depois dos padrões fixos.
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// addECCAndInterleave divide os dados em blocos, calcula a correção de cada
+// um e intercala os bytes na ordem em que vão para a matriz.
+func addECCAndInterleave(data []byte, version int, level Level) []byte {
+	numBlocks := numErrorCorrectionBlocks[level][version]
+	blockECCLen := eccCodewordsPerBlock[level][version]
+	rawCodewords := numRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		dataLen := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			dataLen++
+		}
+		block := append([]byte{}, data[k:k+dataLen]...)
+		k += dataLen
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			// Os blocos curtos têm um byte de enchimento que não vai para a matriz.
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// reedSolomonDivisor é o polinômio gerador de grau degree, sem o coeficiente
+// líder.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplica em GF(2^8) com o polinômio 0x11D.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
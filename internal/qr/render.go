@@ -0,0 +1,54 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// QuietZone é a margem clara, em módulos, exigida em volta do código.
+const QuietZone = 4
+
+// PNG desenha o código com scale pixels por módulo e a margem clara.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		return nil, fmt.Errorf("scale must be at least 1")
+	}
+
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			if c.Black(x/scale-QuietZone, y/scale-QuietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("error encoding PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG desenha o código como um único path, um quadrado por módulo escuro,
+// numa viewBox em módulos que já inclui a margem clara.
+func (c *Code) SVG() string {
+	side := c.Size + 2*QuietZone
+
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Black(x, y) {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#fff"/><path d="%s" fill="#000"/></svg>`, side, side, path.String())
+}
@@ -0,0 +1,112 @@
+// Package recurring calcula as datas das cobranças recorrentes e define o
+// relógio usado pelo agendador, que os testes podem substituir.
+package recurring
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Frequências aceitas.
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+// Frequencies são as frequências aceitas.
+var Frequencies = []string{Daily, Weekly, Monthly}
+
+// DateLayout é o formato das datas de início e fim.
+const DateLayout = "2006-01-02"
+
+// MaxCatchUp limita os períodos atrasados lançados de uma vez: uma regra não
+// pode começar com mais do que isso já vencido, e cada rodada do agendador
+// lança no máximo isso por recorrência, deixando o resto para as seguintes.
+const MaxCatchUp = 366
+
+// Clock informa a hora atual. O agendador usa SystemClock; os testes passam
+// um relógio parado.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock é o relógio do sistema.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// Rule é a regra de repetição: a cada Interval dias, semanas ou meses a
+// partir de Start, até End inclusive quando informado. Na regra mensal o dia
+// é o de Start, ou o último do mês quando ele não existe (31 de janeiro vira
+// 28 ou 29 de fevereiro e volta a 31 em março).
+type Rule struct {
+	Frequency string
+	Interval  int
+	Start     time.Time
+	End       *time.Time
+}
+
+// Validate confere a frequência, o intervalo e a ordem das datas.
+func (r Rule) Validate() error {
+	if !slices.Contains(Frequencies, r.Frequency) {
+		return fmt.Errorf("frequency must be one of daily, weekly or monthly")
+	}
+	if r.Interval < 1 {
+		return fmt.Errorf("interval must be at least 1")
+	}
+	if r.End != nil && r.End.Before(r.Start) {
+		return fmt.Errorf("end date must not be before the start date")
+	}
+	return nil
+}
+
+// Occurrence é a data do período n, contando de zero.
+func (r Rule) Occurrence(n int) time.Time {
+	year, month, day := r.Start.Date()
+	steps := n * r.Interval
+
+	switch r.Frequency {
+	case Daily:
+		return time.Date(year, month, day+steps, 0, 0, 0, 0, time.UTC)
+	case Weekly:
+		return time.Date(year, month, day+7*steps, 0, 0, 0, 0, time.UTC)
+	default:
+		first := time.Date(year, month+time.Month(steps), 1, 0, 0, 0, 0, time.UTC)
+		last := first.AddDate(0, 1, -1).Day()
+		return first.AddDate(0, 0, min(day, last)-1)
+	}
+}
+
+// CheckStart recusa um início com mais de MaxCatchUp períodos já vencidos
+// em now.
+func (r Rule) CheckStart(now time.Time) error {
+	if !r.Ended(MaxCatchUp) && !r.Occurrence(MaxCatchUp).After(today(now)) {
+		return fmt.Errorf("start_date must not be more than %d periods in the past", MaxCatchUp)
+	}
+	return nil
+}
+
+// Ended informa se o período n já passou do fim da regra.
+func (r Rule) Ended(n int) bool {
+	return r.End != nil && r.Occurrence(n).After(*r.End)
+}
+
+// Due devolve os períodos a partir de next cuja data já chegou em now. Depois
+// de uma parada, os atrasados vêm juntos, em ordem, até MaxCatchUp deles.
+func (r Rule) Due(next int, now time.Time) []int {
+	limit := today(now)
+
+	var due []int
+	for n := next; len(due) < MaxCatchUp && !r.Ended(n) && !r.Occurrence(n).After(limit); n++ {
+		due = append(due, n)
+	}
+	return due
+}
+
+// today é a data de now em UTC, sem as horas.
+func today(now time.Time) time.Time {
+	year, month, day := now.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
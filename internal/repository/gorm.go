@@ -0,0 +1,828 @@
+package repository
+
+import (
+	"errors"
+	"me-pague/internal/audit"
+	"me-pague/internal/ledger"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/webhook"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NewGorm devolve os repositórios sobre o banco db. Transaction roda numa
+// transação de db, com os repositórios sobre ela.
+func NewGorm(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:        GormUsers{DB: db},
+		Billings:     GormBillings{DB: db},
+		Payments:     GormPayments{DB: db},
+		PixKeys:      GormPixKeys{DB: db},
+		Groups:       GormGroups{DB: db},
+		Recurring:    GormRecurring{DB: db},
+		Loans:        GormLoans{DB: db},
+		Installments: GormInstallmentPlans{DB: db},
+		Webhooks:     GormWebhooks{DB: db},
+		Audit:        GormAudit{DB: db},
+		transaction: func(fn func(Repositories) error) error {
+			return db.Transaction(func(tx *gorm.DB) error { return fn(NewGorm(tx)) })
+		},
+	}
+}
+
+// GormUsers guarda os usuários no banco, auditando as criações e as trocas
+// de senha.
+type GormUsers struct {
+	DB *gorm.DB
+}
+
+func (r GormUsers) Get(id int32) (models.User, error) {
+	var user models.User
+	if err := r.DB.First(&user, id).Error; err != nil {
+		return models.User{}, notFound(err)
+	}
+	return user, nil
+}
+
+func (r GormUsers) GetByName(name string) (models.User, error) {
+	var user models.User
+	if err := r.DB.Where("name = ?", name).First(&user).Error; err != nil {
+		return models.User{}, notFound(err)
+	}
+	return user, nil
+}
+
+func (r GormUsers) Create(user *models.User, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Create, "user", user.ID, nil, *user)
+	})
+}
+
+func (r GormUsers) SetPasswordHash(id int32, hash string, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := GormUsers{DB: tx}.Get(id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("password_hash", hash).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Update, "user", user.ID, user, user)
+	})
+}
+
+// GormBillings guarda as cobranças no banco; os totais vêm do razão.
+type GormBillings struct {
+	DB *gorm.DB
+}
+
+func (r GormBillings) Get(id int32) (models.Billing, error) {
+	var billing models.Billing
+	if err := r.DB.Where("id = ?", id).First(&billing).Error; err != nil {
+		return billing, notFound(err)
+	}
+	return r.withTotals(billing)
+}
+
+func (r GormBillings) Find(payerID, receiverID int32, currency string) (models.Billing, error) {
+	var billing models.Billing
+	err := r.DB.Where("payer_id = ? AND receiver_id = ? AND currency = ?", payerID, receiverID, currency).First(&billing).Error
+	if err != nil {
+		return billing, notFound(err)
+	}
+	return r.withTotals(billing)
+}
+
+func (r GormBillings) Create(billing *models.Billing, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(billing).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, meta, audit.Create, "billing", billing.ID, nil, *billing); err != nil {
+			return err
+		}
+		return webhook.Enqueue(tx, webhook.BillingCreated, *billing, *billing)
+	})
+}
+
+func (r GormBillings) AddCharge(charge *models.Charge, billing models.Billing, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		charge.BillingID = billing.ID
+		if err := tx.Create(charge).Error; err != nil {
+			return err
+		}
+		if err := ledger.RecordCharge(tx, billing, *charge); err != nil {
+			return err
+		}
+		if err := audit.Record(tx, meta, audit.Create, "charge", charge.ID, nil, *charge); err != nil {
+			return err
+		}
+		return TouchBilling(tx, billing.ID)
+	})
+}
+
+func (r GormBillings) Between(userA, userB int32, currency string) ([]models.Billing, error) {
+	billings := []models.Billing{}
+	err := r.DB.Where("((payer_id = ? AND receiver_id = ?) OR (payer_id = ? AND receiver_id = ?)) AND currency = ?",
+		userA, userB, userB, userA, currency).Order("id").Find(&billings).Error
+	if err != nil {
+		return billings, err
+	}
+	return billings, LoadBillingTotals(r.DB, billings)
+}
+
+func (r GormBillings) Charges(billingID int32) ([]models.Charge, error) {
+	charges := []models.Charge{}
+	err := r.DB.Where("billing_id = ?", billingID).Order("id").Find(&charges).Error
+	return charges, err
+}
+
+func (r GormBillings) OfUser(userID int32, currency string) ([]models.Billing, error) {
+	return r.list(r.DB.Where("(payer_id = ? OR receiver_id = ?) AND currency = ?", userID, userID, currency))
+}
+
+func (r GormBillings) Among(userIDs []int32, currency string) ([]models.Billing, error) {
+	if len(userIDs) == 0 {
+		return []models.Billing{}, nil
+	}
+	return r.list(r.DB.Where("payer_id IN ? AND receiver_id IN ? AND currency = ?", userIDs, userIDs, currency))
+}
+
+func (r GormBillings) list(query *gorm.DB) ([]models.Billing, error) {
+	billings := []models.Billing{}
+	if err := query.Order("id").Find(&billings).Error; err != nil {
+		return billings, err
+	}
+	return billings, LoadBillingTotals(r.DB, billings)
+}
+
+func (r GormBillings) withTotals(billing models.Billing) (models.Billing, error) {
+	billings := []models.Billing{billing}
+	if err := LoadBillingTotals(r.DB, billings); err != nil {
+		return billing, err
+	}
+	return billings[0], nil
+}
+
+// GormPayments guarda os pagamentos no banco. Criar um pagamento confirmado
+// lança no razão e soma ao valor pago da cobrança; toda criação é auditada e
+// avisada aos webhooks, na mesma transação.
+type GormPayments struct {
+	DB *gorm.DB
+}
+
+func (r GormPayments) Create(payment *models.Payment, billing models.Billing, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		if payment.Status == models.PaymentConfirmed {
+			if err := ApplyPayment(tx, billing, *payment); err != nil {
+				return err
+			}
+		}
+		if err := audit.Record(tx, meta, audit.Create, "payment", payment.ID, nil, *payment); err != nil {
+			return err
+		}
+		return webhook.Enqueue(tx, webhook.PaymentCreated, billing, PaymentEvent(billing, *payment))
+	})
+}
+
+func (r GormPayments) ListConfirmed(billingID int32) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.DB.Preload("Reversals").Where("billing_id = ? AND status = ?", billingID, models.PaymentConfirmed).
+		Order("id").Find(&payments).Error
+	return payments, err
+}
+
+func (r GormPayments) ListByBillings(billingIDs []int32) ([]models.Payment, error) {
+	payments := []models.Payment{}
+	if len(billingIDs) == 0 {
+		return payments, nil
+	}
+	err := r.DB.Preload("Reversals").Where("billing_id IN ?", billingIDs).Order("id").Find(&payments).Error
+	return payments, err
+}
+
+func (r GormPayments) Get(id int32) (models.Payment, error) {
+	var payment models.Payment
+	if err := r.DB.Preload("Reversals").Where("id = ?", id).First(&payment).Error; err != nil {
+		return payment, notFound(err)
+	}
+	return payment, nil
+}
+
+func (r GormPayments) Resolve(payment *models.Payment, status string, credit money.Amount, billing models.Billing, meta audit.Meta) error {
+	before := *payment
+	now := time.Now()
+	after := before
+	after.Status, after.ResolvedAt = status, &now
+	updates := map[string]interface{}{"status": status, "resolved_at": now}
+	if status == models.PaymentConfirmed {
+		after.Credit = credit
+		updates["credit"] = credit
+	}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Payment{}).
+			Where("id = ? AND status = ?", payment.ID, models.PaymentPending).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPaymentNotPending
+		}
+
+		action := audit.Reject
+		if status == models.PaymentConfirmed {
+			if err := ApplyPayment(tx, billing, after); err != nil {
+				return err
+			}
+			action = audit.Confirm
+		}
+		if err := audit.Record(tx, meta, action, "payment", payment.ID, before, after); err != nil {
+			return err
+		}
+		if status == models.PaymentConfirmed {
+			return webhook.Enqueue(tx, webhook.PaymentConfirmed, billing, PaymentEvent(billing, after))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*payment = after
+	return nil
+}
+
+func (r GormPayments) ExpirePending(cutoff, now time.Time) (int64, error) {
+	var pending []models.Payment
+	err := r.DB.Where("status = ? AND created_at <= ?", models.PaymentPending, cutoff).Order("id").Find(&pending).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var expired int64
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, payment := range pending {
+			result := tx.Model(&models.Payment{}).
+				Where("id = ? AND status = ?", payment.ID, models.PaymentPending).
+				Updates(map[string]interface{}{"status": models.PaymentExpired, "resolved_at": now})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			after := payment
+			after.Status, after.ResolvedAt = models.PaymentExpired, &now
+			if err := audit.Record(tx, audit.System, audit.Expire, "payment", payment.ID, payment, after); err != nil {
+				return err
+			}
+			expired++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+func (r GormPayments) Reverse(reversal *models.PaymentReversal, payment models.Payment, billing models.Billing, credit money.Amount, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		reversal.PaymentID = payment.ID
+		if err := tx.Create(reversal).Error; err != nil {
+			return err
+		}
+		if err := ledger.RecordReversal(tx, billing, *reversal, credit); err != nil {
+			return err
+		}
+		if err := AddToBillingAmount(tx, billing, -reversal.Amount); err != nil {
+			return err
+		}
+		if credit > 0 {
+			if err := tx.Model(&models.Payment{}).Where("id = ?", payment.ID).Update("credit", payment.Credit-credit).Error; err != nil {
+				return err
+			}
+		}
+
+		after := payment
+		after.Credit -= credit
+		after.Reversals = append(slices.Clone(payment.Reversals), *reversal)
+		return audit.Record(tx, meta, audit.Reverse, "payment", payment.ID, payment, after)
+	})
+}
+
+// GormPixKeys guarda as chaves Pix no banco, auditando cada alteração.
+type GormPixKeys struct {
+	DB *gorm.DB
+}
+
+func (r GormPixKeys) GetByKey(key string) (models.PixKey, error) {
+	var pixKey models.PixKey
+	if err := r.DB.Where(&models.PixKey{Key: key}).First(&pixKey).Error; err != nil {
+		return models.PixKey{}, notFound(err)
+	}
+	return pixKey, nil
+}
+
+func (r GormPixKeys) Default(userID int32) (models.PixKey, error) {
+	var pixKey models.PixKey
+	if err := r.DB.Where("user_id = ? AND is_default", userID).First(&pixKey).Error; err != nil {
+		return models.PixKey{}, notFound(err)
+	}
+	return pixKey, nil
+}
+
+func (r GormPixKeys) Get(id int32) (models.PixKey, error) {
+	var pixKey models.PixKey
+	if err := r.DB.Where("id = ?", id).First(&pixKey).Error; err != nil {
+		return models.PixKey{}, notFound(err)
+	}
+	return pixKey, nil
+}
+
+func (r GormPixKeys) ListByUser(userID int32) ([]models.PixKey, error) {
+	keys := []models.PixKey{}
+	err := r.DB.Where("user_id = ?", userID).Order("is_default DESC, id").Find(&keys).Error
+	return keys, err
+}
+
+func (r GormPixKeys) Create(key *models.PixKey, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&models.PixKey{}).Where(&models.PixKey{Key: key.Key}).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrPixKeyTaken
+		}
+
+		current, err := GormPixKeys{DB: tx}.Default(key.UserID)
+		if errors.Is(err, ErrNotFound) {
+			key.IsDefault = true
+		} else if err != nil {
+			return err
+		} else if key.IsDefault {
+			if err := setPixKeyDefault(tx, &current, false, meta); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(key).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Create, "pix_key", key.ID, nil, *key)
+	})
+}
+
+func (r GormPixKeys) SetDefault(key *models.PixKey, meta audit.Meta) error {
+	if key.IsDefault {
+		return nil
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := GormPixKeys{DB: tx}.Default(key.UserID)
+		if err == nil {
+			err = setPixKeyDefault(tx, &current, false, meta)
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return setPixKeyDefault(tx, key, true, meta)
+	})
+}
+
+func (r GormPixKeys) Delete(key models.PixKey, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&key).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, meta, audit.Delete, "pix_key", key.ID, key, nil); err != nil {
+			return err
+		}
+		if !key.IsDefault {
+			return nil
+		}
+
+		var next models.PixKey
+		err := tx.Where("user_id = ?", key.UserID).Order("id").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return setPixKeyDefault(tx, &next, true, meta)
+	})
+}
+
+// setPixKeyDefault marca ou desmarca a chave como a padrão do usuário,
+// auditando a troca.
+func setPixKeyDefault(tx *gorm.DB, key *models.PixKey, isDefault bool, meta audit.Meta) error {
+	before := *key
+	if err := tx.Model(key).Update("is_default", isDefault).Error; err != nil {
+		return err
+	}
+	key.IsDefault = isDefault
+	return audit.Record(tx, meta, audit.Update, "pix_key", key.ID, before, *key)
+}
+
+// GormGroups guarda os grupos e as despesas no banco, auditando as
+// criações.
+type GormGroups struct {
+	DB *gorm.DB
+}
+
+func (r GormGroups) Get(id int32) (models.Group, error) {
+	var group models.Group
+	if err := r.DB.Preload("Members").Where("id = ?", id).First(&group).Error; err != nil {
+		return group, notFound(err)
+	}
+	return group, nil
+}
+
+func (r GormGroups) Create(group *models.Group, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Create, "group", group.ID, nil, *group)
+	})
+}
+
+func (r GormGroups) AddExpense(expense *models.GroupExpense, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(expense).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Create, "group_expense", expense.ID, nil, *expense)
+	})
+}
+
+// GormRecurring guarda as recorrências e os períodos lançados no banco,
+// auditando a criação e o cancelamento.
+type GormRecurring struct {
+	DB *gorm.DB
+}
+
+func (r GormRecurring) Get(id int32) (models.RecurringBilling, error) {
+	var template models.RecurringBilling
+	if err := r.DB.Where("id = ?", id).First(&template).Error; err != nil {
+		return template, notFound(err)
+	}
+	return template, nil
+}
+
+func (r GormRecurring) ListByUser(userID int32) ([]models.RecurringBilling, error) {
+	templates := []models.RecurringBilling{}
+	err := r.DB.Where("payer_id = ? OR receiver_id = ?", userID, userID).Order("id").Find(&templates).Error
+	return templates, err
+}
+
+func (r GormRecurring) Due(now time.Time) ([]models.RecurringBilling, error) {
+	var due []models.RecurringBilling
+	err := r.DB.Where("next_date <= ?", now).Order("id").Find(&due).Error
+	return due, err
+}
+
+func (r GormRecurring) Create(template *models.RecurringBilling, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Create, "recurring_billing", template.ID, nil, *template)
+	})
+}
+
+func (r GormRecurring) Cancel(template *models.RecurringBilling, now time.Time, meta audit.Meta) error {
+	before := *template
+	after := before
+	after.CanceledAt, after.NextDate = &now, nil
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RecurringBilling{}).Where("id = ?", template.ID).
+			Updates(map[string]interface{}{"canceled_at": now, "next_date": nil}).Error
+		if err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Update, "recurring_billing", template.ID, before, after)
+	})
+	if err != nil {
+		return err
+	}
+	*template = after
+	return nil
+}
+
+func (r GormRecurring) Advance(templateID int32, period int, next *time.Time) error {
+	result := r.DB.Model(&models.RecurringBilling{}).
+		Where("id = ? AND next_period = ? AND canceled_at IS NULL", templateID, period).
+		Updates(map[string]interface{}{"next_period": period + 1, "next_date": next})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPeriodTaken
+	}
+	return nil
+}
+
+func (r GormRecurring) Runs(templateID int32) ([]models.RecurringRun, error) {
+	runs := []models.RecurringRun{}
+	err := r.DB.Where("recurring_billing_id = ?", templateID).Order("period").Find(&runs).Error
+	return runs, err
+}
+
+func (r GormRecurring) AddRun(run *models.RecurringRun) error {
+	return r.DB.Create(run).Error
+}
+
+// GormLoans guarda os empréstimos e as parcelas no banco, auditando a
+// criação e a quitação.
+type GormLoans struct {
+	DB *gorm.DB
+}
+
+func (r GormLoans) Get(id int32) (models.Loan, error) {
+	var loan models.Loan
+	err := r.DB.Preload("Installments", func(tx *gorm.DB) *gorm.DB { return tx.Order("number") }).
+		Where("id = ?", id).First(&loan).Error
+	if err != nil {
+		return loan, notFound(err)
+	}
+	return loan, nil
+}
+
+func (r GormLoans) Create(loan *models.Loan, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(loan).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Create, "loan", loan.ID, nil, *loan)
+	})
+}
+
+func (r GormLoans) PayOff(loan *models.Loan, payoff *models.LoanInstallment, now time.Time, meta audit.Meta) error {
+	before := *loan
+	after := before
+	after.Status, after.PaidOffAt, after.Installments = models.LoanPaidOff, &now, postedInstallments(loan.Installments)
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Loan{}).Where("id = ? AND status = ?", loan.ID, models.LoanActive).
+			Updates(map[string]interface{}{"status": models.LoanPaidOff, "paid_off_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrLoanNotActive
+		}
+
+		if err := tx.Where("loan_id = ? AND charge_id IS NULL", loan.ID).Delete(&models.LoanInstallment{}).Error; err != nil {
+			return err
+		}
+		if payoff != nil {
+			payoff.LoanID = loan.ID
+			if err := tx.Create(payoff).Error; err != nil {
+				return err
+			}
+			after.Installments = append(after.Installments, *payoff)
+		}
+		return audit.Record(tx, meta, audit.Update, "loan", loan.ID, before, after)
+	})
+	if err != nil {
+		return err
+	}
+	*loan = after
+	return nil
+}
+
+func (r GormLoans) DueInstallments(now time.Time) ([]models.LoanInstallment, error) {
+	var due []models.LoanInstallment
+	err := r.DB.Joins("JOIN loans ON loans.id = loan_installments.loan_id").
+		Where("loans.status = ? AND loan_installments.charge_id IS NULL AND loan_installments.due_date <= ?", models.LoanActive, now).
+		Order("loan_installments.loan_id, loan_installments.number").Find(&due).Error
+	return due, err
+}
+
+func (r GormLoans) SetInstallmentCharge(installmentID, chargeID int32) error {
+	result := r.DB.Model(&models.LoanInstallment{}).Where("id = ? AND charge_id IS NULL", installmentID).Update("charge_id", chargeID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInstallmentPosted
+	}
+	return nil
+}
+
+// GormInstallmentPlans guarda os parcelamentos no banco, auditando as
+// criações.
+type GormInstallmentPlans struct {
+	DB *gorm.DB
+}
+
+func (r GormInstallmentPlans) ListByBilling(billingID int32) ([]models.InstallmentPlan, error) {
+	plans := []models.InstallmentPlan{}
+	err := r.DB.Preload("Installments", func(tx *gorm.DB) *gorm.DB { return tx.Order("number") }).
+		Where("billing_id = ?", billingID).Order("id").Find(&plans).Error
+	return plans, err
+}
+
+func (r GormInstallmentPlans) Create(plan *models.InstallmentPlan, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(plan).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Create, "installment_plan", plan.ID, nil, *plan)
+	})
+}
+
+// GormWebhooks guarda as assinaturas e as entregas no banco, auditando as
+// criações e os reenvios.
+type GormWebhooks struct {
+	DB *gorm.DB
+}
+
+func (r GormWebhooks) Get(id int32) (models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := r.DB.Where("id = ?", id).First(&subscription).Error; err != nil {
+		return subscription, notFound(err)
+	}
+	return subscription, nil
+}
+
+func (r GormWebhooks) ListByOwner(ownerID int32) ([]models.WebhookSubscription, error) {
+	subscriptions := []models.WebhookSubscription{}
+	err := r.DB.Where("owner_id = ?", ownerID).Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r GormWebhooks) Create(subscription *models.WebhookSubscription, meta audit.Meta) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(subscription).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Create, "webhook_subscription", subscription.ID, nil, *subscription)
+	})
+}
+
+func (r GormWebhooks) Deliveries(subscriptionID int32, status string) ([]models.WebhookDelivery, error) {
+	query := r.DB.Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	deliveries := []models.WebhookDelivery{}
+	err := query.Order("id DESC").Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r GormWebhooks) GetDelivery(subscriptionID, deliveryID int32) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.DB.Where("id = ? AND subscription_id = ?", deliveryID, subscriptionID).First(&delivery).Error
+	if err != nil {
+		return delivery, notFound(err)
+	}
+	return delivery, nil
+}
+
+func (r GormWebhooks) Redeliver(delivery *models.WebhookDelivery, now time.Time, meta audit.Meta) error {
+	before := *delivery
+	after := before
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := webhook.Redeliver(tx, &after, now); err != nil {
+			return err
+		}
+		return audit.Record(tx, meta, audit.Update, "webhook_delivery", delivery.ID, before, after)
+	})
+	if err != nil {
+		return err
+	}
+	*delivery = after
+	return nil
+}
+
+// GormAudit consulta os eventos de auditoria no banco.
+type GormAudit struct {
+	DB *gorm.DB
+}
+
+func (r GormAudit) Query(filter audit.Filter) ([]models.AuditEvent, error) {
+	return audit.Query(r.DB, filter)
+}
+
+// postedInstallments devolve as parcelas que já foram lançadas na cobrança.
+func postedInstallments(installments []models.LoanInstallment) []models.LoanInstallment {
+	posted := installments[:0:0]
+	for _, item := range installments {
+		if item.ChargeID != nil {
+			posted = append(posted, item)
+		}
+	}
+	return posted
+}
+
+// LoadBillingTotals preenche os totais lançados, pagos e em aberto de cada
+// cobrança a partir do razão: os lançamentos de charge na conta da cobrança
+// são o que foi lançado, e os de payment menos os de reversal, o que foi
+// pago. O que foi pago além do lançado aparece como crédito.
+func LoadBillingTotals(db *gorm.DB, billings []models.Billing) error {
+	if len(billings) == 0 {
+		return nil
+	}
+
+	codes := make([]string, 0, len(billings))
+	for _, billing := range billings {
+		codes = append(codes, ledger.BillingAccount(billing.ID))
+	}
+
+	totals, err := ledger.TotalsByKind(db, codes)
+	if err != nil {
+		return err
+	}
+
+	for i := range billings {
+		billing := &billings[i]
+		byKind := totals[ledger.BillingAccount(billing.ID)]
+		billing.TotalCharged = byKind["charge"].Debit
+		if billing.TotalPaid, err = byKind["payment"].Credit.Sub(byKind["reversal"].Debit); err != nil {
+			return err
+		}
+		if err := setBalance(billing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyPayment faz o pagamento contar para a cobrança: lança no razão e soma
+// ao valor pago. Se a cobrança mudou desde que foi lida, devolve
+// ErrBillingConflict e a transação deve ser desfeita.
+func ApplyPayment(tx *gorm.DB, billing models.Billing, payment models.Payment) error {
+	if err := ledger.RecordPayment(tx, billing, payment); err != nil {
+		return err
+	}
+	return AddToBillingAmount(tx, billing, payment.Amount)
+}
+
+// AddToBillingAmount soma delta ao valor pago da cobrança com um incremento
+// atômico no banco. A gravação só acontece se a cobrança ainda estiver na
+// versão lida em billing, garantindo que as validações feitas sobre ela
+// continuam valendo.
+func AddToBillingAmount(tx *gorm.DB, billing models.Billing, delta money.Amount) error {
+	amount, err := billing.Amount.Add(delta)
+	if err != nil {
+		return err
+	}
+	result := tx.Model(&models.Billing{}).
+		Where("id = ? AND version = ?", billing.ID, billing.Version).
+		Updates(map[string]interface{}{
+			"amount":  amount,
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBillingConflict
+	}
+	return nil
+}
+
+// TouchBilling incrementa a versão da cobrança, invalidando leituras feitas
+// antes de uma alteração nos seus lançamentos.
+func TouchBilling(tx *gorm.DB, billingID int32) error {
+	return tx.Model(&models.Billing{}).Where("id = ?", billingID).
+		Update("version", gorm.Expr("version + 1")).Error
+}
+
+// PaymentEvent é o dado dos eventos de pagamento enviados por webhook; o JSON
+// do pagamento sozinho não diz de qual cobrança ele é.
+func PaymentEvent(billing models.Billing, payment models.Payment) map[string]interface{} {
+	return map[string]interface{}{"billing_id": billing.ID, "payment": payment}
+}
+
+// setBalance preenche o valor em aberto e o crédito a partir do total
+// cobrado e do total pago.
+func setBalance(billing *models.Billing) error {
+	var err error
+	billing.Outstanding, billing.Credit = 0, 0
+	if billing.TotalCharged > billing.TotalPaid {
+		billing.Outstanding, err = billing.TotalCharged.Sub(billing.TotalPaid)
+	} else {
+		billing.Credit, err = billing.TotalPaid.Sub(billing.TotalCharged)
+	}
+	return err
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
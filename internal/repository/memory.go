@@ -0,0 +1,853 @@
+package repository
+
+import (
+	"fmt"
+	"me-pague/internal/audit"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory guarda as entidades dos repositórios em mapas. Não há auditoria,
+// razão nem webhooks: o total cobrado de uma cobrança é a soma dos
+// lançamentos e o total pago, a dos pagamentos confirmados menos os
+// estornos; as assinaturas de webhook são guardadas, mas nenhuma entrega é
+// criada, e a consulta de auditoria volta vazia. É seguro para uso
+// concorrente.
+type Memory struct {
+	mu            sync.Mutex
+	users         map[int32]models.User
+	billings      map[int32]models.Billing
+	charges       map[int32]models.Charge
+	payments      map[int32]models.Payment
+	pixKeys       map[int32]models.PixKey
+	groups        map[int32]models.Group
+	expenses      map[int32]models.GroupExpense
+	recurring     map[int32]models.RecurringBilling
+	runs          map[int32]models.RecurringRun
+	loans         map[int32]models.Loan
+	plans         map[int32]models.InstallmentPlan
+	subscriptions map[int32]models.WebhookSubscription
+	lastID        int32
+}
+
+// NewMemory devolve os repositórios sobre um Memory vazio. Transaction só
+// roda a função: o que ela gravou antes de um erro fica.
+func NewMemory() Repositories {
+	m := &Memory{
+		users:         make(map[int32]models.User),
+		billings:      make(map[int32]models.Billing),
+		charges:       make(map[int32]models.Charge),
+		payments:      make(map[int32]models.Payment),
+		pixKeys:       make(map[int32]models.PixKey),
+		groups:        make(map[int32]models.Group),
+		expenses:      make(map[int32]models.GroupExpense),
+		recurring:     make(map[int32]models.RecurringBilling),
+		runs:          make(map[int32]models.RecurringRun),
+		loans:         make(map[int32]models.Loan),
+		plans:         make(map[int32]models.InstallmentPlan),
+		subscriptions: make(map[int32]models.WebhookSubscription),
+	}
+	return Repositories{
+		Users:        memoryUsers{m},
+		Billings:     memoryBillings{m},
+		Payments:     memoryPayments{m},
+		PixKeys:      memoryPixKeys{m},
+		Groups:       memoryGroups{m},
+		Recurring:    memoryRecurring{m},
+		Loans:        memoryLoans{m},
+		Installments: memoryInstallmentPlans{m},
+		Webhooks:     memoryWebhooks{m},
+		Audit:        memoryAudit{},
+	}
+}
+
+// nextID devolve um ID novo; m.mu deve estar travado.
+func (m *Memory) nextID() int32 {
+	m.lastID++
+	return m.lastID
+}
+
+// withTotals preenche os totais da cobrança a partir dos lançamentos e dos
+// pagamentos confirmados; m.mu deve estar travado.
+func (m *Memory) withTotals(billing models.Billing) (models.Billing, error) {
+	var err error
+	billing.TotalCharged, billing.TotalPaid = 0, 0
+	for _, charge := range m.charges {
+		if charge.BillingID != billing.ID {
+			continue
+		}
+		if billing.TotalCharged, err = billing.TotalCharged.Add(charge.Amount); err != nil {
+			return billing, err
+		}
+	}
+	for _, payment := range m.payments {
+		if payment.BillingID != billing.ID || payment.Status != models.PaymentConfirmed {
+			continue
+		}
+		if billing.TotalPaid, err = billing.TotalPaid.Add(payment.Amount); err != nil {
+			return billing, err
+		}
+		for _, reversal := range payment.Reversals {
+			if billing.TotalPaid, err = billing.TotalPaid.Sub(reversal.Amount); err != nil {
+				return billing, err
+			}
+		}
+	}
+	return billing, setBalance(&billing)
+}
+
+// listBillings devolve as cobranças que passam em keep, com os totais, em
+// ordem de criação; m.mu deve estar travado.
+func (m *Memory) listBillings(keep func(models.Billing) bool) ([]models.Billing, error) {
+	billings := []models.Billing{}
+	for _, billing := range m.billings {
+		if !keep(billing) {
+			continue
+		}
+		billing, err := m.withTotals(billing)
+		if err != nil {
+			return nil, err
+		}
+		billings = append(billings, billing)
+	}
+	sort.Slice(billings, func(i, j int) bool { return billings[i].ID < billings[j].ID })
+	return billings, nil
+}
+
+// addToBillingAmount soma delta ao valor pago da cobrança se ela ainda está
+// na versão de billing; m.mu deve estar travado.
+func (m *Memory) addToBillingAmount(billing models.Billing, delta money.Amount) error {
+	stored, ok := m.billings[billing.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Version != billing.Version {
+		return ErrBillingConflict
+	}
+	var err error
+	if stored.Amount, err = stored.Amount.Add(delta); err != nil {
+		return err
+	}
+	stored.Version++
+	m.billings[stored.ID] = stored
+	return nil
+}
+
+type memoryUsers struct{ m *Memory }
+
+func (r memoryUsers) Get(id int32) (models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	user, ok := r.m.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r memoryUsers) GetByName(name string) (models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, user := range r.m.users {
+		if user.Name == name {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (r memoryUsers) Create(user *models.User, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, existing := range r.m.users {
+		if existing.Name == user.Name {
+			return fmt.Errorf("user name %q is already taken", user.Name)
+		}
+	}
+	user.ID = r.m.nextID()
+	r.m.users[user.ID] = *user
+	return nil
+}
+
+func (r memoryUsers) SetPasswordHash(id int32, hash string, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	user, ok := r.m.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.PasswordHash = hash
+	r.m.users[id] = user
+	return nil
+}
+
+type memoryBillings struct{ m *Memory }
+
+func (r memoryBillings) Get(id int32) (models.Billing, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	billing, ok := r.m.billings[id]
+	if !ok {
+		return models.Billing{}, ErrNotFound
+	}
+	return r.m.withTotals(billing)
+}
+
+func (r memoryBillings) Find(payerID, receiverID int32, currency string) (models.Billing, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, billing := range r.m.billings {
+		if billing.PayerID == payerID && billing.ReceiverID == receiverID && billing.Currency == currency {
+			return r.m.withTotals(billing)
+		}
+	}
+	return models.Billing{}, ErrNotFound
+}
+
+func (r memoryBillings) Create(billing *models.Billing, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	billing.ID = r.m.nextID()
+	if billing.CreatedAt.IsZero() {
+		billing.CreatedAt = time.Now()
+	}
+	r.m.billings[billing.ID] = *billing
+
+	var err error
+	*billing, err = r.m.withTotals(*billing)
+	return err
+}
+
+func (r memoryBillings) AddCharge(charge *models.Charge, billing models.Billing, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	stored, ok := r.m.billings[billing.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	charge.ID = r.m.nextID()
+	charge.BillingID = billing.ID
+	if charge.CreatedAt.IsZero() {
+		charge.CreatedAt = time.Now()
+	}
+	r.m.charges[charge.ID] = *charge
+
+	stored.Version++
+	r.m.billings[stored.ID] = stored
+	return nil
+}
+
+func (r memoryBillings) Between(userA, userB int32, currency string) ([]models.Billing, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.m.listBillings(func(billing models.Billing) bool {
+		between := (billing.PayerID == userA && billing.ReceiverID == userB) ||
+			(billing.PayerID == userB && billing.ReceiverID == userA)
+		return between && billing.Currency == currency
+	})
+}
+
+func (r memoryBillings) OfUser(userID int32, currency string) ([]models.Billing, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.m.listBillings(func(billing models.Billing) bool {
+		return (billing.PayerID == userID || billing.ReceiverID == userID) && billing.Currency == currency
+	})
+}
+
+func (r memoryBillings) Among(userIDs []int32, currency string) ([]models.Billing, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.m.listBillings(func(billing models.Billing) bool {
+		return slices.Contains(userIDs, billing.PayerID) && slices.Contains(userIDs, billing.ReceiverID) &&
+			billing.Currency == currency
+	})
+}
+
+func (r memoryBillings) Charges(billingID int32) ([]models.Charge, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	charges := []models.Charge{}
+	for _, charge := range r.m.charges {
+		if charge.BillingID == billingID {
+			charges = append(charges, charge)
+		}
+	}
+	sort.Slice(charges, func(i, j int) bool { return charges[i].ID < charges[j].ID })
+	return charges, nil
+}
+
+type memoryPayments struct{ m *Memory }
+
+func (r memoryPayments) Create(payment *models.Payment, billing models.Billing, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.billings[billing.ID]; !ok {
+		return ErrNotFound
+	}
+	if payment.Status == models.PaymentConfirmed {
+		if err := r.m.addToBillingAmount(billing, payment.Amount); err != nil {
+			return err
+		}
+	}
+
+	payment.ID = r.m.nextID()
+	if payment.CreatedAt.IsZero() {
+		payment.CreatedAt = time.Now()
+	}
+	r.m.payments[payment.ID] = *payment
+	return nil
+}
+
+func (r memoryPayments) ListConfirmed(billingID int32) ([]models.Payment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	payments := []models.Payment{}
+	for _, payment := range r.m.payments {
+		if payment.BillingID == billingID && payment.Status == models.PaymentConfirmed {
+			payments = append(payments, payment)
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool { return payments[i].ID < payments[j].ID })
+	return payments, nil
+}
+
+func (r memoryPayments) ListByBillings(billingIDs []int32) ([]models.Payment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	wanted := make(map[int32]bool, len(billingIDs))
+	for _, id := range billingIDs {
+		wanted[id] = true
+	}
+	payments := []models.Payment{}
+	for _, payment := range r.m.payments {
+		if wanted[payment.BillingID] {
+			payments = append(payments, payment)
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool { return payments[i].ID < payments[j].ID })
+	return payments, nil
+}
+
+func (r memoryPayments) Get(id int32) (models.Payment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	payment, ok := r.m.payments[id]
+	if !ok {
+		return models.Payment{}, ErrNotFound
+	}
+	return payment, nil
+}
+
+func (r memoryPayments) Resolve(payment *models.Payment, status string, credit money.Amount, billing models.Billing, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	stored, ok := r.m.payments[payment.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Status != models.PaymentPending {
+		return ErrPaymentNotPending
+	}
+
+	now := time.Now()
+	stored.Status, stored.ResolvedAt = status, &now
+	if status == models.PaymentConfirmed {
+		stored.Credit = credit
+		if err := r.m.addToBillingAmount(billing, stored.Amount); err != nil {
+			return err
+		}
+	}
+	r.m.payments[stored.ID] = stored
+	*payment = stored
+	return nil
+}
+
+func (r memoryPayments) ExpirePending(cutoff, now time.Time) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var expired int64
+	for id, payment := range r.m.payments {
+		if payment.Status != models.PaymentPending || payment.CreatedAt.After(cutoff) {
+			continue
+		}
+		payment.Status, payment.ResolvedAt = models.PaymentExpired, &now
+		r.m.payments[id] = payment
+		expired++
+	}
+	return expired, nil
+}
+
+func (r memoryPayments) Reverse(reversal *models.PaymentReversal, payment models.Payment, billing models.Billing, credit money.Amount, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	stored, ok := r.m.payments[payment.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := r.m.addToBillingAmount(billing, -reversal.Amount); err != nil {
+		return err
+	}
+
+	reversal.ID = r.m.nextID()
+	reversal.PaymentID = payment.ID
+	if reversal.CreatedAt.IsZero() {
+		reversal.CreatedAt = time.Now()
+	}
+	stored.Credit -= credit
+	stored.Reversals = append(slices.Clone(stored.Reversals), *reversal)
+	r.m.payments[stored.ID] = stored
+	return nil
+}
+
+type memoryPixKeys struct{ m *Memory }
+
+func (r memoryPixKeys) GetByKey(key string) (models.PixKey, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, pixKey := range r.m.pixKeys {
+		if pixKey.Key == key {
+			return pixKey, nil
+		}
+	}
+	return models.PixKey{}, ErrNotFound
+}
+
+func (r memoryPixKeys) Default(userID int32) (models.PixKey, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, pixKey := range r.m.pixKeys {
+		if pixKey.UserID == userID && pixKey.IsDefault {
+			return pixKey, nil
+		}
+	}
+	return models.PixKey{}, ErrNotFound
+}
+
+func (r memoryPixKeys) Get(id int32) (models.PixKey, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	pixKey, ok := r.m.pixKeys[id]
+	if !ok {
+		return models.PixKey{}, ErrNotFound
+	}
+	return pixKey, nil
+}
+
+func (r memoryPixKeys) ListByUser(userID int32) ([]models.PixKey, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	keys := []models.PixKey{}
+	for _, pixKey := range r.m.pixKeys {
+		if pixKey.UserID == userID {
+			keys = append(keys, pixKey)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].IsDefault != keys[j].IsDefault {
+			return keys[i].IsDefault
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+func (r memoryPixKeys) Create(key *models.PixKey, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	hasDefault := false
+	for _, pixKey := range r.m.pixKeys {
+		if pixKey.Key == key.Key {
+			return ErrPixKeyTaken
+		}
+		hasDefault = hasDefault || (pixKey.UserID == key.UserID && pixKey.IsDefault)
+	}
+	if !hasDefault {
+		key.IsDefault = true
+	} else if key.IsDefault {
+		r.m.clearDefaultPixKey(key.UserID)
+	}
+
+	key.ID = r.m.nextID()
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	r.m.pixKeys[key.ID] = *key
+	return nil
+}
+
+func (r memoryPixKeys) SetDefault(key *models.PixKey, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.pixKeys[key.ID]; !ok {
+		return ErrNotFound
+	}
+	r.m.clearDefaultPixKey(key.UserID)
+	key.IsDefault = true
+	r.m.pixKeys[key.ID] = *key
+	return nil
+}
+
+func (r memoryPixKeys) Delete(key models.PixKey, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	delete(r.m.pixKeys, key.ID)
+	if !key.IsDefault {
+		return nil
+	}
+
+	var next *models.PixKey
+	for _, pixKey := range r.m.pixKeys {
+		if pixKey.UserID == key.UserID && (next == nil || pixKey.ID < next.ID) {
+			next = &pixKey
+		}
+	}
+	if next != nil {
+		next.IsDefault = true
+		r.m.pixKeys[next.ID] = *next
+	}
+	return nil
+}
+
+// clearDefaultPixKey desmarca a chave padrão do usuário; m.mu deve estar
+// travado.
+func (m *Memory) clearDefaultPixKey(userID int32) {
+	for id, pixKey := range m.pixKeys {
+		if pixKey.UserID == userID && pixKey.IsDefault {
+			pixKey.IsDefault = false
+			m.pixKeys[id] = pixKey
+		}
+	}
+}
+
+type memoryGroups struct{ m *Memory }
+
+func (r memoryGroups) Get(id int32) (models.Group, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	group, ok := r.m.groups[id]
+	if !ok {
+		return models.Group{}, ErrNotFound
+	}
+	group.Members = slices.Clone(group.Members)
+	return group, nil
+}
+
+func (r memoryGroups) Create(group *models.Group, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	group.ID = r.m.nextID()
+	if group.CreatedAt.IsZero() {
+		group.CreatedAt = time.Now()
+	}
+	group.Members = slices.Clone(group.Members)
+	for i := range group.Members {
+		group.Members[i].ID = r.m.nextID()
+		group.Members[i].GroupID = group.ID
+	}
+	r.m.groups[group.ID] = *group
+	return nil
+}
+
+func (r memoryGroups) AddExpense(expense *models.GroupExpense, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	expense.ID = r.m.nextID()
+	if expense.CreatedAt.IsZero() {
+		expense.CreatedAt = time.Now()
+	}
+	expense.Shares = slices.Clone(expense.Shares)
+	for i := range expense.Shares {
+		expense.Shares[i].ID = r.m.nextID()
+		expense.Shares[i].ExpenseID = expense.ID
+	}
+	r.m.expenses[expense.ID] = *expense
+	return nil
+}
+
+type memoryRecurring struct{ m *Memory }
+
+func (r memoryRecurring) Get(id int32) (models.RecurringBilling, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	template, ok := r.m.recurring[id]
+	if !ok {
+		return models.RecurringBilling{}, ErrNotFound
+	}
+	return template, nil
+}
+
+func (r memoryRecurring) ListByUser(userID int32) ([]models.RecurringBilling, error) {
+	return r.list(func(template models.RecurringBilling) bool {
+		return template.PayerID == userID || template.ReceiverID == userID
+	}), nil
+}
+
+func (r memoryRecurring) Due(now time.Time) ([]models.RecurringBilling, error) {
+	return r.list(func(template models.RecurringBilling) bool {
+		return template.NextDate != nil && !template.NextDate.After(now)
+	}), nil
+}
+
+func (r memoryRecurring) list(keep func(models.RecurringBilling) bool) []models.RecurringBilling {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	templates := []models.RecurringBilling{}
+	for _, template := range r.m.recurring {
+		if keep(template) {
+			templates = append(templates, template)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates
+}
+
+func (r memoryRecurring) Create(template *models.RecurringBilling, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	template.ID = r.m.nextID()
+	if template.CreatedAt.IsZero() {
+		template.CreatedAt = time.Now()
+	}
+	r.m.recurring[template.ID] = *template
+	return nil
+}
+
+func (r memoryRecurring) Cancel(template *models.RecurringBilling, now time.Time, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	stored, ok := r.m.recurring[template.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.CanceledAt, stored.NextDate = &now, nil
+	r.m.recurring[stored.ID] = stored
+	*template = stored
+	return nil
+}
+
+func (r memoryRecurring) Advance(templateID int32, period int, next *time.Time) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	stored, ok := r.m.recurring[templateID]
+	if !ok || int(stored.NextPeriod) != period || stored.CanceledAt != nil {
+		return ErrPeriodTaken
+	}
+	stored.NextPeriod, stored.NextDate = int32(period+1), next
+	r.m.recurring[stored.ID] = stored
+	return nil
+}
+
+func (r memoryRecurring) Runs(templateID int32) ([]models.RecurringRun, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	runs := []models.RecurringRun{}
+	for _, run := range r.m.runs {
+		if run.RecurringBillingID == templateID {
+			runs = append(runs, run)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Period < runs[j].Period })
+	return runs, nil
+}
+
+func (r memoryRecurring) AddRun(run *models.RecurringRun) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, existing := range r.m.runs {
+		if existing.RecurringBillingID == run.RecurringBillingID && existing.Period == run.Period {
+			return ErrPeriodTaken
+		}
+	}
+	run.ID = r.m.nextID()
+	r.m.runs[run.ID] = *run
+	return nil
+}
+
+type memoryLoans struct{ m *Memory }
+
+func (r memoryLoans) Get(id int32) (models.Loan, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	loan, ok := r.m.loans[id]
+	if !ok {
+		return models.Loan{}, ErrNotFound
+	}
+	loan.Installments = slices.Clone(loan.Installments)
+	return loan, nil
+}
+
+func (r memoryLoans) Create(loan *models.Loan, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	loan.ID = r.m.nextID()
+	if loan.CreatedAt.IsZero() {
+		loan.CreatedAt = time.Now()
+	}
+	loan.Installments = slices.Clone(loan.Installments)
+	for i := range loan.Installments {
+		loan.Installments[i].ID = r.m.nextID()
+		loan.Installments[i].LoanID = loan.ID
+	}
+	r.m.loans[loan.ID] = *loan
+	return nil
+}
+
+func (r memoryLoans) PayOff(loan *models.Loan, payoff *models.LoanInstallment, now time.Time, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	stored, ok := r.m.loans[loan.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Status != models.LoanActive {
+		return ErrLoanNotActive
+	}
+
+	stored.Status, stored.PaidOffAt = models.LoanPaidOff, &now
+	stored.Installments = postedInstallments(stored.Installments)
+	if payoff != nil {
+		payoff.ID = r.m.nextID()
+		payoff.LoanID = loan.ID
+		stored.Installments = append(stored.Installments, *payoff)
+	}
+	r.m.loans[stored.ID] = stored
+	*loan = stored
+	loan.Installments = slices.Clone(stored.Installments)
+	return nil
+}
+
+func (r memoryLoans) DueInstallments(now time.Time) ([]models.LoanInstallment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	due := []models.LoanInstallment{}
+	for _, loan := range r.m.loans {
+		if loan.Status != models.LoanActive {
+			continue
+		}
+		for _, item := range loan.Installments {
+			if item.ChargeID == nil && !item.DueDate.After(now) {
+				due = append(due, item)
+			}
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].LoanID != due[j].LoanID {
+			return due[i].LoanID < due[j].LoanID
+		}
+		return due[i].Number < due[j].Number
+	})
+	return due, nil
+}
+
+func (r memoryLoans) SetInstallmentCharge(installmentID, chargeID int32) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for id, loan := range r.m.loans {
+		for i, item := range loan.Installments {
+			if item.ID != installmentID {
+				continue
+			}
+			if item.ChargeID != nil {
+				return ErrInstallmentPosted
+			}
+			loan.Installments = slices.Clone(loan.Installments)
+			loan.Installments[i].ChargeID = &chargeID
+			r.m.loans[id] = loan
+			return nil
+		}
+	}
+	return ErrInstallmentPosted
+}
+
+type memoryInstallmentPlans struct{ m *Memory }
+
+func (r memoryInstallmentPlans) ListByBilling(billingID int32) ([]models.InstallmentPlan, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	plans := []models.InstallmentPlan{}
+	for _, plan := range r.m.plans {
+		if plan.BillingID == billingID {
+			plan.Installments = slices.Clone(plan.Installments)
+			plans = append(plans, plan)
+		}
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].ID < plans[j].ID })
+	return plans, nil
+}
+
+func (r memoryInstallmentPlans) Create(plan *models.InstallmentPlan, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	plan.ID = r.m.nextID()
+	if plan.CreatedAt.IsZero() {
+		plan.CreatedAt = time.Now()
+	}
+	plan.Installments = slices.Clone(plan.Installments)
+	for i := range plan.Installments {
+		plan.Installments[i].ID = r.m.nextID()
+		plan.Installments[i].PlanID = plan.ID
+	}
+	r.m.plans[plan.ID] = *plan
+	return nil
+}
+
+type memoryWebhooks struct{ m *Memory }
+
+func (r memoryWebhooks) Get(id int32) (models.WebhookSubscription, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	subscription, ok := r.m.subscriptions[id]
+	if !ok {
+		return models.WebhookSubscription{}, ErrNotFound
+	}
+	return subscription, nil
+}
+
+func (r memoryWebhooks) ListByOwner(ownerID int32) ([]models.WebhookSubscription, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	subscriptions := []models.WebhookSubscription{}
+	for _, subscription := range r.m.subscriptions {
+		if subscription.OwnerID == ownerID {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].ID < subscriptions[j].ID })
+	return subscriptions, nil
+}
+
+func (r memoryWebhooks) Create(subscription *models.WebhookSubscription, meta audit.Meta) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	subscription.ID = r.m.nextID()
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = time.Now()
+	}
+	r.m.subscriptions[subscription.ID] = *subscription
+	return nil
+}
+
+func (r memoryWebhooks) Deliveries(subscriptionID int32, status string) ([]models.WebhookDelivery, error) {
+	return []models.WebhookDelivery{}, nil
+}
+
+func (r memoryWebhooks) GetDelivery(subscriptionID, deliveryID int32) (models.WebhookDelivery, error) {
+	return models.WebhookDelivery{}, ErrNotFound
+}
+
+func (r memoryWebhooks) Redeliver(delivery *models.WebhookDelivery, now time.Time, meta audit.Meta) error {
+	return ErrNotFound
+}
+
+type memoryAudit struct{}
+
+func (memoryAudit) Query(filter audit.Filter) ([]models.AuditEvent, error) {
+	return []models.AuditEvent{}, nil
+}
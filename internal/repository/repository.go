@@ -0,0 +1,242 @@
+// Package repository isola o acesso aos dados da API atrás de interfaces. A
+// implementação em GORM grava no banco junto com a auditoria, o razão e os
+// webhooks; a em memória guarda só as entidades e serve para testar
+// serviços e handlers sem banco.
+package repository
+
+import (
+	"errors"
+	"me-pague/internal/audit"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"time"
+)
+
+var (
+	// ErrNotFound indica que o registro procurado não existe.
+	ErrNotFound = errors.New("record not found")
+	// ErrBillingConflict indica que a cobrança foi alterada por outra
+	// requisição entre a leitura e a gravação.
+	ErrBillingConflict = errors.New("billing was modified by another request, please retry")
+	// ErrPaymentNotPending indica que o pagamento já foi confirmado,
+	// rejeitado ou expirou.
+	ErrPaymentNotPending = errors.New("payment is not pending")
+	// ErrPixKeyTaken indica que a chave já está cadastrada, pelo mesmo
+	// usuário ou por outro.
+	ErrPixKeyTaken = errors.New("pix key is already registered")
+	// ErrPeriodTaken indica que outro agendador já lançou o período da
+	// recorrência.
+	ErrPeriodTaken = errors.New("recurring period already created")
+	// ErrLoanNotActive indica que o empréstimo já foi quitado.
+	ErrLoanNotActive = errors.New("loan is already paid off")
+	// ErrInstallmentPosted indica que a parcela do empréstimo já foi lançada
+	// ou que o empréstimo deixou de estar ativo.
+	ErrInstallmentPosted = errors.New("loan installment already posted")
+)
+
+// UserRepository guarda os usuários.
+type UserRepository interface {
+	// Get devolve o usuário pelo ID ou ErrNotFound.
+	Get(id int32) (models.User, error)
+	// GetByName devolve o usuário pelo nome ou ErrNotFound.
+	GetByName(name string) (models.User, error)
+	// Create grava o usuário e preenche o ID dele.
+	Create(user *models.User, meta audit.Meta) error
+	// SetPasswordHash troca o hash da senha do usuário; ErrNotFound se ele
+	// não existe.
+	SetPasswordHash(id int32, hash string, meta audit.Meta) error
+}
+
+// BillingRepository guarda as cobranças. As cobranças devolvidas vêm com os
+// totais cobrado, pago, em aberto e de crédito preenchidos.
+type BillingRepository interface {
+	// Get devolve a cobrança pelo ID ou ErrNotFound.
+	Get(id int32) (models.Billing, error)
+	// Find devolve a cobrança do pagador para o recebedor na moeda ou
+	// ErrNotFound.
+	Find(payerID, receiverID int32, currency string) (models.Billing, error)
+	// Create grava a cobrança e preenche o ID dela.
+	Create(billing *models.Billing, meta audit.Meta) error
+	// AddCharge grava o lançamento na cobrança e preenche o ID dele; o total
+	// cobrado de billing passa a incluí-lo.
+	AddCharge(charge *models.Charge, billing models.Billing, meta audit.Meta) error
+	// Between devolve as cobranças entre os dois usuários na moeda, nos dois
+	// sentidos, em ordem de criação.
+	Between(userA, userB int32, currency string) ([]models.Billing, error)
+	// Charges devolve os lançamentos da cobrança, em ordem de criação.
+	Charges(billingID int32) ([]models.Charge, error)
+	// OfUser devolve as cobranças na moeda em que o usuário é pagador ou
+	// recebedor, em ordem de criação.
+	OfUser(userID int32, currency string) ([]models.Billing, error)
+	// Among devolve as cobranças na moeda em que pagador e recebedor estão
+	// ambos em userIDs, em ordem de criação.
+	Among(userIDs []int32, currency string) ([]models.Billing, error)
+}
+
+// PaymentRepository guarda os pagamentos.
+type PaymentRepository interface {
+	// Create grava o pagamento e preenche o ID dele. Um pagamento confirmado
+	// passa a contar para billing na mesma operação; se billing mudou desde
+	// que foi lida, devolve ErrBillingConflict e nada é gravado.
+	Create(payment *models.Payment, billing models.Billing, meta audit.Meta) error
+	// ListConfirmed devolve os pagamentos confirmados da cobrança com os
+	// estornos, em ordem de criação.
+	ListConfirmed(billingID int32) ([]models.Payment, error)
+	// ListByBillings devolve os pagamentos das cobranças, de qualquer
+	// status, com os estornos, em ordem de criação.
+	ListByBillings(billingIDs []int32) ([]models.Payment, error)
+	// Get devolve o pagamento pelo ID, com os estornos, ou ErrNotFound.
+	Get(id int32) (models.Payment, error)
+	// Resolve tira o pagamento de pendente com o status, agora, e atualiza
+	// payment. Confirmado, ele passa a contar para billing e fica com o
+	// crédito credit. ErrPaymentNotPending se ele já saiu de pendente e
+	// ErrBillingConflict se billing mudou desde que foi lida.
+	Resolve(payment *models.Payment, status string, credit money.Amount, billing models.Billing, meta audit.Meta) error
+	// ExpirePending marca como expirados, em now, os pagamentos pendentes
+	// criados até cutoff e devolve quantos foram.
+	ExpirePending(cutoff, now time.Time) (int64, error)
+	// Reverse grava o estorno do pagamento e preenche o ID dele. O valor
+	// pago de billing diminui do estorno e o crédito do pagamento, de
+	// credit, a parte do estorno que devolve crédito; ErrBillingConflict se
+	// billing mudou desde que foi lida.
+	Reverse(reversal *models.PaymentReversal, payment models.Payment, billing models.Billing, credit money.Amount, meta audit.Meta) error
+}
+
+// PixKeyRepository guarda as chaves Pix.
+type PixKeyRepository interface {
+	// GetByKey devolve a chave cadastrada com o valor normalizado key ou
+	// ErrNotFound.
+	GetByKey(key string) (models.PixKey, error)
+	// Default devolve a chave padrão do usuário ou ErrNotFound.
+	Default(userID int32) (models.PixKey, error)
+	// Get devolve a chave pelo ID ou ErrNotFound.
+	Get(id int32) (models.PixKey, error)
+	// ListByUser devolve as chaves do usuário, a padrão primeiro e as
+	// demais em ordem de criação.
+	ListByUser(userID int32) ([]models.PixKey, error)
+	// Create grava a chave e preenche o ID dela; ErrPixKeyTaken se o valor
+	// já está cadastrado. A chave vira a padrão se o usuário ainda não tem
+	// uma ou se vem com IsDefault, e então a anterior deixa de ser.
+	Create(key *models.PixKey, meta audit.Meta) error
+	// SetDefault torna a chave a padrão do usuário no lugar da anterior.
+	SetDefault(key *models.PixKey, meta audit.Meta) error
+	// Delete remove a chave; se era a padrão, a mais antiga das restantes
+	// assume.
+	Delete(key models.PixKey, meta audit.Meta) error
+}
+
+// GroupRepository guarda os grupos e as despesas divididas entre os membros.
+type GroupRepository interface {
+	// Get devolve o grupo pelo ID, com os membros, ou ErrNotFound.
+	Get(id int32) (models.Group, error)
+	// Create grava o grupo com os membros e preenche o ID dele.
+	Create(group *models.Group, meta audit.Meta) error
+	// AddExpense grava a despesa com as partes e preenche o ID dela; os
+	// lançamentos das partes ficam com quem chama.
+	AddExpense(expense *models.GroupExpense, meta audit.Meta) error
+}
+
+// RecurringRepository guarda as cobranças recorrentes e os períodos já
+// lançados.
+type RecurringRepository interface {
+	// Get devolve a recorrência pelo ID ou ErrNotFound.
+	Get(id int32) (models.RecurringBilling, error)
+	// ListByUser devolve as recorrências em que o usuário é pagador ou
+	// recebedor, em ordem de criação.
+	ListByUser(userID int32) ([]models.RecurringBilling, error)
+	// Due devolve as recorrências com o próximo período vencido até now, em
+	// ordem de criação.
+	Due(now time.Time) ([]models.RecurringBilling, error)
+	// Create grava a recorrência e preenche o ID dela.
+	Create(template *models.RecurringBilling, meta audit.Meta) error
+	// Cancel cancela a recorrência em now e atualiza template.
+	Cancel(template *models.RecurringBilling, now time.Time, meta audit.Meta) error
+	// Advance passa a recorrência do período period para o seguinte, com a
+	// data next; ErrPeriodTaken se ela não está mais em period ou foi
+	// cancelada.
+	Advance(templateID int32, period int, next *time.Time) error
+	// Runs devolve os períodos lançados da recorrência, em ordem.
+	Runs(templateID int32) ([]models.RecurringRun, error)
+	// AddRun grava o período lançado; dá erro se ele já existe.
+	AddRun(run *models.RecurringRun) error
+}
+
+// LoanRepository guarda os empréstimos e as tabelas de amortização.
+type LoanRepository interface {
+	// Get devolve o empréstimo pelo ID, com as parcelas em ordem, ou
+	// ErrNotFound.
+	Get(id int32) (models.Loan, error)
+	// Create grava o empréstimo com as parcelas e preenche os IDs.
+	Create(loan *models.Loan, meta audit.Meta) error
+	// PayOff quita o empréstimo em now: as parcelas ainda não lançadas saem
+	// da tabela e payoff, se não for nil, entra no lugar delas. loan é
+	// atualizado; ErrLoanNotActive se ele já foi quitado.
+	PayOff(loan *models.Loan, payoff *models.LoanInstallment, now time.Time, meta audit.Meta) error
+	// DueInstallments devolve as parcelas ainda não lançadas dos
+	// empréstimos ativos com vencimento até now, por empréstimo e número.
+	DueInstallments(now time.Time) ([]models.LoanInstallment, error)
+	// SetInstallmentCharge associa a parcela ao lançamento que a cobra;
+	// ErrInstallmentPosted se ela já foi lançada.
+	SetInstallmentCharge(installmentID, chargeID int32) error
+}
+
+// InstallmentPlanRepository guarda os parcelamentos das cobranças.
+type InstallmentPlanRepository interface {
+	// ListByBilling devolve os planos da cobrança, com as parcelas em
+	// ordem, em ordem de criação.
+	ListByBilling(billingID int32) ([]models.InstallmentPlan, error)
+	// Create grava o plano com as parcelas e preenche os IDs.
+	Create(plan *models.InstallmentPlan, meta audit.Meta) error
+}
+
+// WebhookRepository guarda as assinaturas de webhook e as entregas delas.
+type WebhookRepository interface {
+	// Get devolve a assinatura pelo ID ou ErrNotFound.
+	Get(id int32) (models.WebhookSubscription, error)
+	// ListByOwner devolve as assinaturas do usuário, em ordem de criação.
+	ListByOwner(ownerID int32) ([]models.WebhookSubscription, error)
+	// Create grava a assinatura e preenche o ID dela.
+	Create(subscription *models.WebhookSubscription, meta audit.Meta) error
+	// Deliveries devolve as entregas da assinatura, da mais recente para a
+	// mais antiga; com status, só as nessa situação.
+	Deliveries(subscriptionID int32, status string) ([]models.WebhookDelivery, error)
+	// GetDelivery devolve a entrega da assinatura pelo ID ou ErrNotFound.
+	GetDelivery(subscriptionID, deliveryID int32) (models.WebhookDelivery, error)
+	// Redeliver devolve a entrega ao início do ciclo de tentativas, com a
+	// próxima em now, e atualiza delivery.
+	Redeliver(delivery *models.WebhookDelivery, now time.Time, meta audit.Meta) error
+}
+
+// AuditRepository consulta os eventos de auditoria, que os demais
+// repositórios gravam junto com as escritas.
+type AuditRepository interface {
+	// Query devolve os eventos que atendem ao filtro, do mais antigo para o
+	// mais recente.
+	Query(filter audit.Filter) ([]models.AuditEvent, error)
+}
+
+// Repositories reúne os repositórios de uma mesma fonte de dados.
+type Repositories struct {
+	Users        UserRepository
+	Billings     BillingRepository
+	Payments     PaymentRepository
+	PixKeys      PixKeyRepository
+	Groups       GroupRepository
+	Recurring    RecurringRepository
+	Loans        LoanRepository
+	Installments InstallmentPlanRepository
+	Webhooks     WebhookRepository
+	Audit        AuditRepository
+
+	transaction func(fn func(Repositories) error) error
+}
+
+// Transaction roda fn com repositórios cujas gravações valem juntas: se fn
+// devolve erro, nada do que ela gravou fica. Em memória as gravações não são
+// desfeitas.
+func (r Repositories) Transaction(fn func(tx Repositories) error) error {
+	if r.transaction == nil {
+		return fn(r)
+	}
+	return r.transaction(fn)
+}
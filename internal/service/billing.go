@@ -0,0 +1,209 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"me-pague/internal/audit"
+	"me-pague/internal/currency"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/penalty"
+	"me-pague/internal/repository"
+	"time"
+)
+
+// BillingService valida e abre as cobranças entre pares de usuários e
+// calcula os encargos delas.
+type BillingService struct {
+	Users    repository.UserRepository
+	Billings repository.BillingRepository
+	Payments repository.PaymentRepository
+	// Penalty é a política de multa e juros aplicada aos lançamentos
+	// vencidos.
+	Penalty penalty.Policy
+}
+
+// BillingKey identifica uma cobrança: cada par de pagador e recebedor tem uma
+// por moeda. Currency vazio é a moeda padrão.
+type BillingKey struct {
+	PayerID    int32
+	ReceiverID int32
+	Currency   string
+}
+
+// Statement é a cobrança com os encargos por atraso calculados em AsOf.
+// OriginalAmount é o principal em aberto; Penalties soma multa e juros;
+// TotalDue é o que o pagador deve naquela data.
+type Statement struct {
+	Billing        models.Billing
+	AsOf           time.Time
+	OriginalAmount money.Amount
+	LateFee        money.Amount
+	Interest       money.Amount
+	Penalties      money.Amount
+	TotalDue       money.Amount
+	Charges        []penalty.Assessment
+}
+
+// Balance é o saldo líquido entre dois usuários em uma moeda. Quando Net é
+// zero, DebtorID e CreditorID ficam vazios.
+type Balance struct {
+	UserA      int32
+	UserB      int32
+	Currency   string
+	Net        money.Amount
+	DebtorID   int32
+	CreditorID int32
+	Billings   []models.Billing
+	Payments   []models.Payment
+}
+
+// Get devolve a cobrança pelo ID, com os totais, ou repository.ErrNotFound.
+func (s BillingService) Get(id int32) (models.Billing, error) {
+	return s.Billings.Get(id)
+}
+
+// Validate confere que as duas partes existem e são diferentes e normaliza a
+// moeda do pedido.
+func (s BillingService) Validate(input BillingKey) (BillingKey, error) {
+	if input.PayerID == input.ReceiverID {
+		return input, fmt.Errorf("payer_id and receiver_id cannot be the same")
+	}
+
+	if _, err := s.Users.Get(input.PayerID); err != nil {
+		return input, fmt.Errorf("Person 1 not found")
+	}
+	if _, err := s.Users.Get(input.ReceiverID); err != nil {
+		return input, fmt.Errorf("Person 2 not found")
+	}
+
+	code, err := currency.Normalize(input.Currency)
+	if err != nil {
+		return input, err
+	}
+	input.Currency = code
+
+	return input, nil
+}
+
+// GetOrCreate devolve a cobrança do pagador para o recebedor na moeda do
+// pedido, abrindo uma zerada se ainda não houver.
+func (s BillingService) GetOrCreate(input BillingKey, meta audit.Meta) (models.Billing, error) {
+	code, err := currency.Normalize(input.Currency)
+	if err != nil {
+		return models.Billing{}, err
+	}
+
+	billing, err := s.Billings.Find(input.PayerID, input.ReceiverID, code)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return billing, err
+	}
+
+	billing = models.Billing{
+		PayerID:    input.PayerID,
+		ReceiverID: input.ReceiverID,
+		Amount:     0,
+		Currency:   code,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.Billings.Create(&billing, meta); err != nil {
+		return billing, err
+	}
+	return billing, nil
+}
+
+// WithPenalties calcula os encargos de cada lançamento da cobrança na data
+// asOf. Os pagamentos entram pelo valor líquido dos estornos, na data em que
+// foram feitos.
+func (s BillingService) WithPenalties(billing models.Billing, asOf time.Time) (Statement, error) {
+	result := Statement{Billing: billing, AsOf: asOf}
+
+	charges, err := s.Billings.Charges(billing.ID)
+	if err != nil {
+		return result, fmt.Errorf("error loading charges: %w", err)
+	}
+	payments, err := s.Payments.ListConfirmed(billing.ID)
+	if err != nil {
+		return result, fmt.Errorf("error loading payments: %w", err)
+	}
+
+	items := make([]penalty.Charge, 0, len(charges))
+	for _, charge := range charges {
+		items = append(items, penalty.Charge{ID: charge.ID, Amount: charge.Amount, DueDate: charge.DueDate})
+	}
+	paid := make([]penalty.Payment, 0, len(payments))
+	for _, payment := range payments {
+		amount := payment.Amount
+		for _, reversal := range payment.Reversals {
+			amount -= reversal.Amount
+		}
+		paid = append(paid, penalty.Payment{Amount: amount, Date: payment.CreatedAt})
+	}
+
+	result.Charges, err = penalty.Assess(s.Penalty, items, paid, asOf)
+	if err != nil {
+		return result, err
+	}
+	for _, assessment := range result.Charges {
+		if result.OriginalAmount, err = result.OriginalAmount.Add(assessment.Unpaid); err != nil {
+			return result, err
+		}
+		if result.LateFee, err = result.LateFee.Add(assessment.LateFee); err != nil {
+			return result, err
+		}
+		if result.Interest, err = result.Interest.Add(assessment.Interest); err != nil {
+			return result, err
+		}
+	}
+	if result.Penalties, err = result.LateFee.Add(result.Interest); err != nil {
+		return result, err
+	}
+	result.TotalDue, err = result.OriginalAmount.Add(result.Penalties)
+	return result, err
+}
+
+// PairBalance junta as cobranças userA -> userB e userB -> userA na moeda
+// code e os pagamentos feitos nelas. O saldo líquido de userA é o que ele
+// pagou além do lançado em userA -> userB menos o mesmo valor em
+// userB -> userA.
+func (s BillingService) PairBalance(userA, userB int32, code string) (Balance, error) {
+	balance := Balance{UserA: userA, UserB: userB, Currency: code}
+
+	billings, err := s.Billings.Between(userA, userB, code)
+	if err != nil {
+		return balance, fmt.Errorf("error loading billings: %w", err)
+	}
+	balance.Billings = billings
+
+	var net money.Amount
+	billingIDs := make([]int32, 0, len(billings))
+	for _, billing := range billings {
+		billingIDs = append(billingIDs, billing.ID)
+		paidOverCharged, err := billing.TotalPaid.Sub(billing.TotalCharged)
+		if err == nil {
+			if billing.PayerID == userA {
+				net, err = net.Add(paidOverCharged)
+			} else {
+				net, err = net.Sub(paidOverCharged)
+			}
+		}
+		if err != nil {
+			return balance, err
+		}
+	}
+
+	if balance.Payments, err = s.Payments.ListByBillings(billingIDs); err != nil {
+		return balance, fmt.Errorf("error loading payments: %w", err)
+	}
+
+	switch {
+	case net > 0:
+		balance.Net, balance.CreditorID, balance.DebtorID = net, userA, userB
+	case net < 0:
+		if balance.Net, err = money.Amount(0).Sub(net); err != nil {
+			return balance, err
+		}
+		balance.CreditorID, balance.DebtorID = userB, userA
+	}
+	return balance, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"me-pague/internal/audit"
+	"me-pague/internal/currency"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/repository"
+)
+
+// PaymentService registra pagamentos em cobranças.
+type PaymentService struct {
+	Payments repository.PaymentRepository
+	// RequireConfirmation deixa pendentes os pagamentos registrados por
+	// quem não é o recebedor da cobrança.
+	RequireConfirmation bool
+}
+
+// NewPayment é o pagamento pedido. Amount está na moeda da cobrança; com
+// RejectOverpayment, um valor acima do que está em aberto é recusado.
+type NewPayment struct {
+	Amount            money.Input
+	RejectOverpayment bool
+}
+
+// Create grava o pagamento. Quando a confirmação pelo recebedor é exigida e
+// quem registra não é o próprio recebedor, ele fica pendente e não conta
+// para a cobrança; caso contrário é aplicado na hora. conversion, se houver,
+// é o câmbio que levou o valor pago para a moeda da cobrança.
+func (s PaymentService) Create(input NewPayment, billing models.Billing, conversion *currency.Conversion, meta audit.Meta) (models.Payment, error) {
+	amount, err := input.Amount.In(billing.Currency)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if amount <= 0 {
+		return models.Payment{}, fmt.Errorf("amount must be greater than zero")
+	}
+
+	var payment models.Payment
+	payment.PayerID = billing.PayerID
+	payment.BillingID = billing.ID
+	payment.Amount = amount
+	payment.Currency = billing.Currency
+	if conversion != nil {
+		payment.OriginalAmount = conversion.Amount
+		payment.OriginalCurrency = conversion.From
+		payment.ExchangeRate = conversion.Rate.Text
+		payment.RateDate = &conversion.Rate.Date
+		payment.Rounding = currency.Decimal(conversion.Rounding, 6)
+	}
+	payment.Status = models.PaymentConfirmed
+	if s.RequireConfirmation && meta.ActorID != billing.ReceiverID {
+		payment.Status = models.PaymentPending
+	}
+
+	if amount > billing.Outstanding && input.RejectOverpayment {
+		return models.Payment{}, fmt.Errorf("amount exceeds the outstanding balance of %d", billing.Outstanding)
+	}
+	if payment.Status == models.PaymentConfirmed {
+		payment.Credit = CreditFor(billing, payment.Amount)
+	}
+
+	err = s.Payments.Create(&payment, billing, meta)
+	if errors.Is(err, repository.ErrBillingConflict) {
+		return models.Payment{}, err
+	}
+	if err != nil {
+		return models.Payment{}, fmt.Errorf("error creating payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// CreditFor é a parte de amount que passa do valor em aberto da cobrança.
+func CreditFor(billing models.Billing, amount money.Amount) money.Amount {
+	if amount > billing.Outstanding {
+		return amount - billing.Outstanding
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+// Package service guarda as regras de negócio dos usuários, cobranças e
+// pagamentos, sobre os repositórios. Os handlers só traduzem HTTP para
+// chamadas aos serviços e os erros deles para respostas.
+package service
+
+import (
+	"errors"
+	"me-pague/internal/audit"
+	"me-pague/internal/auth"
+	"me-pague/internal/models"
+	"me-pague/internal/repository"
+)
+
+var (
+	// ErrUserExists indica que já há um usuário com o nome pedido.
+	ErrUserExists = errors.New("User already exists")
+	// ErrInvalidCredentials indica nome ou senha errados no login.
+	ErrInvalidCredentials = errors.New("Invalid name or password")
+)
+
+// UserService cadastra e autentica usuários.
+type UserService struct {
+	Users repository.UserRepository
+}
+
+// Get devolve o usuário pelo ID ou repository.ErrNotFound.
+func (s UserService) Get(id int32) (models.User, error) {
+	return s.Users.Get(id)
+}
+
+// Create cadastra um usuário com a senha guardada como hash. O nome não pode
+// estar em uso.
+func (s UserService) Create(name, password string, meta audit.Meta) (models.User, error) {
+	if _, err := s.Users.GetByName(name); err == nil {
+		return models.User{}, ErrUserExists
+	}
+
+	passwordHash, err := auth.HashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{Name: name, PasswordHash: passwordHash}
+	if err := s.Users.Create(&user, meta); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// SetPassword troca a senha do usuário com esse nome. É o caminho para dar
+// senha aos usuários criados antes da autenticação, que não têm uma.
+func (s UserService) SetPassword(name, password string, meta audit.Meta) (models.User, error) {
+	user, err := s.Users.GetByName(name)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	passwordHash, err := auth.HashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+	if err := s.Users.SetPasswordHash(user.ID, passwordHash, meta); err != nil {
+		return models.User{}, err
+	}
+	user.PasswordHash = passwordHash
+	return user, nil
+}
+
+// Authenticate devolve o usuário com esse nome e senha ou
+// ErrInvalidCredentials.
+func (s UserService) Authenticate(name, password string) (models.User, error) {
+	user, err := s.Users.GetByName(name)
+	if err != nil || !auth.CheckPassword(user.PasswordHash, password) {
+		return models.User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
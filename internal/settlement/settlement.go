@@ -0,0 +1,218 @@
+// Package settlement simplifica as dívidas entre usuários: a partir dos
+// saldos líquidos, sugere transferências que zeram todos eles.
+//
+// Com até 16 usuários com saldo, o plano é o de menos transferências,
+// calculado por programação dinâmica sobre os 2^n subconjuntos de saldos.
+// Acima disso o custo cresce rápido demais, e o plano passa a ser o do
+// algoritmo guloso, que casa o maior devedor com o maior credor: faz no
+// máximo n-1 transferências, mas pode fazer mais do que o ótimo.
+package settlement
+
+import (
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"sort"
+)
+
+// exactLimit é o número máximo de usuários com saldo para o qual o plano
+// ótimo é calculado por programação dinâmica; acima disso usa-se o
+// algoritmo guloso, que garante no máximo n-1 transferências.
+const exactLimit = 16
+
+// Balance é o saldo líquido de um usuário: positivo quando ele tem valores
+// a receber, negativo quando deve.
+type Balance struct {
+	UserID int32        `json:"user_id"`
+	Amount money.Amount `json:"amount"`
+}
+
+// Transfer é um pagamento sugerido de From para To.
+type Transfer struct {
+	From   int32        `json:"from"`
+	To     int32        `json:"to"`
+	Amount money.Amount `json:"amount"`
+}
+
+// NetBalances calcula o saldo líquido de cada usuário a partir das
+// cobranças, que precisam estar com os totais carregados. O que está em
+// aberto numa cobrança é débito do pagador e crédito do recebedor; o que foi
+// pago além do lançado conta no sentido contrário. O resultado vem ordenado
+// por UserID e omite saldos zerados. Um saldo fora do limite de Amount é
+// erro.
+func NetBalances(billings []models.Billing) ([]Balance, error) {
+	net := make(map[int32]money.Amount)
+	for _, billing := range billings {
+		paidOverCharged, err := billing.TotalPaid.Sub(billing.TotalCharged)
+		if err != nil {
+			return nil, err
+		}
+		if net[billing.PayerID], err = net[billing.PayerID].Add(paidOverCharged); err != nil {
+			return nil, err
+		}
+		if net[billing.ReceiverID], err = net[billing.ReceiverID].Sub(paidOverCharged); err != nil {
+			return nil, err
+		}
+	}
+
+	balances := make([]Balance, 0, len(net))
+	for userID, amount := range net {
+		if amount != 0 {
+			balances = append(balances, Balance{UserID: userID, Amount: amount})
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].UserID < balances[j].UserID })
+	return balances, nil
+}
+
+// Plan devolve a lista de transferências que zera todos os saldos, a menor
+// possível com até exactLimit usuários com saldo. A mesma entrada sempre
+// produz o mesmo plano: empates são resolvidos pelo menor UserID. Uma soma
+// parcial fora do limite de Amount é erro.
+func Plan(balances []Balance) ([]Transfer, error) {
+	nonZero := make([]Balance, 0, len(balances))
+	for _, b := range balances {
+		if b.Amount != 0 {
+			nonZero = append(nonZero, b)
+		}
+	}
+	sort.Slice(nonZero, func(i, j int) bool { return nonZero[i].UserID < nonZero[j].UserID })
+
+	if len(nonZero) > exactLimit {
+		return greedy(nonZero), nil
+	}
+
+	groups, err := zeroSumGroups(nonZero)
+	if err != nil {
+		return nil, err
+	}
+	transfers := []Transfer{}
+	for _, group := range groups {
+		transfers = append(transfers, greedy(group)...)
+	}
+	sort.SliceStable(transfers, func(i, j int) bool {
+		if transfers[i].From != transfers[j].From {
+			return transfers[i].From < transfers[j].From
+		}
+		return transfers[i].To < transfers[j].To
+	})
+	return transfers, nil
+}
+
+// zeroSumGroups particiona os saldos no maior número possível de grupos de
+// soma zero. Cada grupo com k usuários é quitado com k-1 transferências,
+// então maximizar os grupos minimiza o total de transferências.
+func zeroSumGroups(balances []Balance) ([][]Balance, error) {
+	n := len(balances)
+	if n == 0 {
+		return nil, nil
+	}
+
+	full := 1<<n - 1
+	sums := make([]money.Amount, full+1)
+	for mask := 1; mask <= full; mask++ {
+		low := mask & -mask
+		i := bitIndex(low)
+		sum, err := sums[mask^low].Add(balances[i].Amount)
+		if err != nil {
+			return nil, err
+		}
+		sums[mask] = sum
+	}
+
+	// groups[mask] é o maior número de grupos de soma zero em que mask pode
+	// ser dividida quando os elementos são retirados um a um; last guarda o
+	// elemento retirado para reconstruir a ordem.
+	groups := make([]int, full+1)
+	last := make([]int, full+1)
+	for mask := 1; mask <= full; mask++ {
+		best, bestIndex := -1, -1
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			if groups[mask^(1<<i)] > best {
+				best, bestIndex = groups[mask^(1<<i)], i
+			}
+		}
+		groups[mask], last[mask] = best, bestIndex
+		if sums[mask] == 0 {
+			groups[mask]++
+		}
+	}
+
+	var order []int
+	for mask := full; mask != 0; mask ^= 1 << last[mask] {
+		order = append(order, last[mask])
+	}
+
+	var result [][]Balance
+	var current []Balance
+	var running money.Amount
+	for i := len(order) - 1; i >= 0; i-- {
+		b := balances[order[i]]
+		current = append(current, b)
+		// Cada prefixo é um subconjunto já somado acima, sem estouro.
+		running, _ = running.Add(b.Amount)
+		if running == 0 {
+			result = append(result, current)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		result = append(result, current)
+	}
+	return result, nil
+}
+
+// greedy quita os saldos casando sempre o maior devedor com o maior credor.
+func greedy(balances []Balance) []Transfer {
+	var debtors, creditors []Balance
+	for _, b := range balances {
+		if b.Amount < 0 {
+			debtors = append(debtors, Balance{UserID: b.UserID, Amount: -b.Amount})
+		} else if b.Amount > 0 {
+			creditors = append(creditors, b)
+		}
+	}
+
+	transfers := []Transfer{}
+	for len(debtors) > 0 && len(creditors) > 0 {
+		sortByAmount(debtors)
+		sortByAmount(creditors)
+
+		debtor, creditor := &debtors[0], &creditors[0]
+		amount := debtor.Amount
+		if creditor.Amount < amount {
+			amount = creditor.Amount
+		}
+		transfers = append(transfers, Transfer{From: debtor.UserID, To: creditor.UserID, Amount: amount})
+
+		debtor.Amount -= amount
+		creditor.Amount -= amount
+		if debtor.Amount == 0 {
+			debtors = debtors[1:]
+		}
+		if creditor.Amount == 0 {
+			creditors = creditors[1:]
+		}
+	}
+	return transfers
+}
+
+func sortByAmount(balances []Balance) {
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Amount != balances[j].Amount {
+			return balances[i].Amount > balances[j].Amount
+		}
+		return balances[i].UserID < balances[j].UserID
+	})
+}
+
+func bitIndex(bit int) int {
+	i := 0
+	for bit > 1 {
+		bit >>= 1
+		i++
+	}
+	return i
+}
@@ -0,0 +1,152 @@
+package split
+
+import (
+	"fmt"
+	"math/big"
+	"me-pague/internal/money"
+	"sort"
+)
+
+// Estratégias de divisão aceitas por Split.
+const (
+	Equal      = "equal"
+	Percentage = "percentage"
+	Shares     = "shares"
+	Exact      = "exact"
+)
+
+// PercentageBase é o valor que representa 100% na estratégia Percentage
+// (os percentuais são informados em pontos-base: 2550 = 25,50%).
+const PercentageBase = 10000
+
+// Part é a participação de um usuário na divisão. O significado de Value
+// depende da estratégia: ignorado em Equal, pontos-base em Percentage,
+// peso em Shares e valor exato em Exact.
+type Part struct {
+	UserID int32
+	Value  int64
+}
+
+// Split divide total entre as partes segundo a estratégia informada e
+// devolve o valor de cada parte, na mesma ordem de parts. Os centavos que
+// sobram do arredondamento vão para as partes com maior resto; em caso de
+// empate, para o menor UserID. A soma do resultado é sempre igual a total.
+func Split(total money.Amount, strategy string, parts []Part) ([]money.Amount, error) {
+	if total <= 0 {
+		return nil, fmt.Errorf("total must be greater than zero")
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("at least one participant is required")
+	}
+
+	seen := make(map[int32]bool, len(parts))
+	for _, p := range parts {
+		if seen[p.UserID] {
+			return nil, fmt.Errorf("user %d appears more than once in the split", p.UserID)
+		}
+		seen[p.UserID] = true
+	}
+
+	switch strategy {
+	case Equal:
+		weights := make([]int64, len(parts))
+		for i := range weights {
+			weights[i] = 1
+		}
+		return allocate(total, parts, weights), nil
+
+	case Percentage:
+		weights := make([]int64, len(parts))
+		var sum int64
+		for i, p := range parts {
+			if p.Value < 0 {
+				return nil, fmt.Errorf("percentage for user %d cannot be negative", p.UserID)
+			}
+			if p.Value > PercentageBase {
+				return nil, fmt.Errorf("percentage for user %d cannot exceed %d basis points", p.UserID, PercentageBase)
+			}
+			weights[i] = p.Value
+			sum += p.Value
+		}
+		if sum != PercentageBase {
+			return nil, fmt.Errorf("percentages must add up to %d basis points, got %d", PercentageBase, sum)
+		}
+		return allocate(total, parts, weights), nil
+
+	case Shares:
+		weights := make([]int64, len(parts))
+		var sum int64
+		for i, p := range parts {
+			if p.Value < 0 {
+				return nil, fmt.Errorf("shares for user %d cannot be negative", p.UserID)
+			}
+			weights[i] = p.Value
+			if sum+p.Value < sum {
+				return nil, money.ErrOverflow
+			}
+			sum += p.Value
+		}
+		if sum == 0 {
+			return nil, fmt.Errorf("shares must add up to more than zero")
+		}
+		return allocate(total, parts, weights), nil
+
+	case Exact:
+		amounts := make([]money.Amount, len(parts))
+		var sum money.Amount
+		for i, p := range parts {
+			if p.Value < 0 {
+				return nil, fmt.Errorf("amount for user %d cannot be negative", p.UserID)
+			}
+			amounts[i] = money.Amount(p.Value)
+			var err error
+			if sum, err = sum.Add(amounts[i]); err != nil {
+				return nil, err
+			}
+		}
+		if sum != total {
+			return nil, fmt.Errorf("exact amounts must add up to %d, got %d", total, sum)
+		}
+		return amounts, nil
+	}
+
+	return nil, fmt.Errorf("unknown split strategy %q", strategy)
+}
+
+// allocate reparte total proporcionalmente aos pesos usando o método do
+// maior resto. As contas intermediárias são feitas com inteiros grandes,
+// porque total vezes peso pode passar de 64 bits.
+func allocate(total money.Amount, parts []Part, weights []int64) []money.Amount {
+	sum := new(big.Int)
+	for _, w := range weights {
+		sum.Add(sum, big.NewInt(w))
+	}
+
+	amounts := make([]money.Amount, len(parts))
+	remainders := make([]*big.Int, len(parts))
+	var assigned money.Amount
+	for i, w := range weights {
+		value := new(big.Int).Mul(big.NewInt(int64(total)), big.NewInt(w))
+		quo, rem := value.QuoRem(value, sum, new(big.Int))
+		amounts[i] = money.Amount(quo.Int64())
+		remainders[i] = rem
+		assigned += amounts[i]
+	}
+
+	order := make([]int, len(parts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ia, ib := order[a], order[b]
+		if cmp := remainders[ia].Cmp(remainders[ib]); cmp != 0 {
+			return cmp > 0
+		}
+		return parts[ia].UserID < parts[ib].UserID
+	})
+
+	for i := 0; money.Amount(i) < total-assigned; i++ {
+		amounts[order[i]]++
+	}
+	return amounts
+}
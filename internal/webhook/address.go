@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress indica uma URL de webhook que aponta para a própria
+// máquina ou para a rede interna.
+var ErrForbiddenAddress = errors.New("webhook URL must not point to a loopback, private, link-local or unspecified address")
+
+// CheckHost resolve host e recusa o endereço se algum dos IPs for de
+// loopback, privado, link-local ou não especificado, a menos que
+// AllowPrivateNetworks esteja ligado. Como o DNS pode mudar depois da
+// assinatura, o envio confere de novo cada IP na hora de conectar.
+func (o Options) CheckHost(ctx context.Context, host string) error {
+	if o.AllowPrivateNetworks {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("could not resolve webhook host %q", host)
+	}
+	for _, addr := range addrs {
+		if forbidden(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// forbidden diz se o envio para ip alcançaria a própria máquina ou a rede
+// interna.
+func forbidden(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// dialControl recusa a conexão com um IP proibido depois da resolução do
+// nome, o que cobre um DNS que muda de resposta depois da assinatura.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || forbidden(ip) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// publicTransport é usado nos envios quando AllowPrivateNetworks está
+// desligado; privateTransport, quando está ligado. Nenhum dos dois passa por
+// proxy, para que a verificação do dialControl valha para o assinante e não
+// para o proxy.
+var (
+	publicTransport  = newTransport(dialControl)
+	privateTransport = newTransport(nil)
+)
+
+func newTransport(control func(network, address string, c syscall.RawConn) error) *http.Transport {
+	return &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 30 * time.Second,
+			Control: control,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
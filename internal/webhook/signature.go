@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// Cabeçalhos enviados em cada entrega.
+const (
+	SignatureHeader = "X-MePague-Signature"
+	EventHeader     = "X-MePague-Event"
+	DeliveryHeader  = "X-MePague-Delivery"
+)
+
+// Sign calcula o HMAC-SHA256, em hexadecimal, de "<timestamp>.<body>" com o
+// segredo da assinatura. Incluir o timestamp permite ao assinante recusar
+// entregas antigas reenviadas por terceiros.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureValue monta o valor do cabeçalho SignatureHeader.
+func SignatureValue(secret string, timestamp int64, body []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(secret, timestamp, body))
+}
@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"me-pague/internal/models"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de evento que podem ser assinados.
+const (
+	BillingCreated   = "billing.created"
+	PaymentCreated   = "payment.created"
+	PaymentConfirmed = "payment.confirmed"
+)
+
+// EventTypes são todos os tipos de evento aceitos numa assinatura.
+var EventTypes = []string{BillingCreated, PaymentCreated, PaymentConfirmed}
+
+// Options controla o envio das entregas.
+type Options struct {
+	// MaxAttempts é quantas tentativas uma entrega tem antes de ir para dead.
+	MaxAttempts int
+	// BaseDelay é a espera depois da primeira falha; dobra a cada nova falha
+	// até MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Timeout limita cada requisição ao assinante.
+	Timeout time.Duration
+	// BatchSize é quantas entregas pendentes cada rodada do worker envia.
+	BatchSize int
+	// AllowPrivateNetworks aceita assinantes em endereços de loopback,
+	// privados ou link-local. Só deve ser ligado em desenvolvimento.
+	AllowPrivateNetworks bool
+}
+
+// DefaultOptions devolve as opções padrão: oito tentativas, com espera de
+// 30 segundos a uma hora, e assinantes só em endereços públicos.
+func DefaultOptions() Options {
+	return Options{
+		MaxAttempts: 8,
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    time.Hour,
+		Timeout:     10 * time.Second,
+		BatchSize:   100,
+	}
+}
+
+// Event é o corpo JSON enviado ao assinante.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Enqueue agenda o evento para as assinaturas do pagador e do recebedor da
+// cobrança que pediram esse tipo. Deve ser chamado na mesma transação da
+// escrita que gerou o evento, para que nada seja enviado se ela for
+// desfeita; o envio em si fica com o worker.
+func Enqueue(tx *gorm.DB, eventType string, billing models.Billing, data interface{}) error {
+	var subscriptions []models.WebhookSubscription
+	err := tx.Where("owner_id IN ?", []int32{billing.PayerID, billing.ReceiverID}).Order("id").Find(&subscriptions).Error
+	if err != nil {
+		return fmt.Errorf("error loading webhook subscriptions: %w", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	now := time.Now()
+	event := Event{ID: newEventID(), Type: eventType, CreatedAt: now, Data: data}
+	for _, subscription := range subscriptions {
+		if !slices.Contains(subscription.EventTypes, eventType) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      eventType,
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding webhook event: %w", err)
+	}
+	for i := range deliveries {
+		deliveries[i].Payload = payload
+	}
+	if err := tx.Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("error enqueuing webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func newEventID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return "evt_" + hex.EncodeToString(buf)
+}
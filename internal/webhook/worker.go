@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"me-pague/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxErrorLength limita o erro guardado de cada tentativa.
+const maxErrorLength = 500
+
+// DeliverDue envia as entregas pendentes cuja próxima tentativa já venceu e
+// devolve quantas foram entregues. A entrega é pelo menos uma vez: o
+// assinante deve usar o cabeçalho DeliveryHeader para descartar repetições.
+func (o Options) DeliverDue(tx *gorm.DB, now time.Time) (int, error) {
+	var due []models.WebhookDelivery
+	err := tx.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at, id").Limit(o.BatchSize).Find(&due).Error
+	if err != nil {
+		return 0, fmt.Errorf("error loading webhook deliveries: %w", err)
+	}
+
+	delivered := 0
+	for i := range due {
+		if err := o.Attempt(tx, &due[i], now); err != nil {
+			return delivered, err
+		}
+		if due[i].Status == models.DeliveryDelivered {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// DeliverEvery roda DeliverDue periodicamente até o processo terminar.
+func (o Options) DeliverEvery(tx *gorm.DB, interval time.Duration) {
+	for now := range time.Tick(interval) {
+		if _, err := o.DeliverDue(tx, now); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// Redeliver devolve a entrega, mesmo que já entregue ou em dead, ao início do
+// ciclo de tentativas, com a próxima tentativa em now. O envio fica com o
+// worker, como o de qualquer entrega pendente.
+func Redeliver(tx *gorm.DB, delivery *models.WebhookDelivery, now time.Time) error {
+	delivery.Status = models.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.DeliveredAt = nil
+	return save(tx, delivery)
+}
+
+// Attempt faz uma tentativa de envio e grava o resultado: entregue com
+// resposta 2xx; senão, agendada de novo com espera exponencial ou, esgotadas
+// as tentativas, em dead.
+func (o Options) Attempt(tx *gorm.DB, delivery *models.WebhookDelivery, now time.Time) error {
+	var subscription models.WebhookSubscription
+	err := tx.Where("id = ?", delivery.SubscriptionID).First(&subscription).Error
+
+	delivery.Attempts++
+	if err != nil {
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = "subscription not found"
+		return save(tx, delivery)
+	}
+
+	status, err := o.post(subscription, *delivery, now)
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return save(tx, delivery)
+	}
+
+	delivery.LastError = truncate(err.Error(), maxErrorLength)
+	if delivery.Attempts >= o.MaxAttempts {
+		delivery.Status = models.DeliveryDead
+	} else {
+		delivery.NextAttemptAt = now.Add(o.Backoff(delivery.Attempts))
+	}
+	return save(tx, delivery)
+}
+
+// Backoff é a espera depois da tentativa número attempts que falhou.
+func (o Options) Backoff(attempts int) time.Duration {
+	delay := o.BaseDelay
+	for i := 1; i < attempts && delay < o.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > o.MaxDelay {
+		delay = o.MaxDelay
+	}
+	return delay
+}
+
+func (o Options) post(subscription models.WebhookSubscription, delivery models.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, SignatureValue(subscription.Secret, now.Unix(), delivery.Payload))
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(int(delivery.ID)))
+
+	transport := publicTransport
+	if o.AllowPrivateNetworks {
+		transport = privateTransport
+	}
+	client := http.Client{Timeout: o.Timeout, Transport: transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func save(tx *gorm.DB, delivery *models.WebhookDelivery) error {
+	if err := tx.Save(delivery).Error; err != nil {
+		return fmt.Errorf("error saving webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"me-pague/internal/config"
+	"me-pague/internal/db"
+	"me-pague/internal/ledger"
+	"os"
+)
+
+const ledgerUsage = "usage: me-pague [flags] ledger check"
+
+// runLedger trata "me-pague ledger check", que confere as invariantes do
+// razão no banco de database.dsn e sai com status 1 se alguma falhar. Os
+// totais são de todo o sistema, por isso a verificação fica fora da API.
+func runLedger(cfg config.Config, args []string) {
+	if len(args) != 1 || args[0] != "check" {
+		log.Fatal(ledgerUsage)
+	}
+
+	database, err := db.Open(cfg.Database.DSN)
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+	report, err := ledger.Check(database)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("entries: %d\ntotal debits: %d\ntotal credits: %d\n", report.Entries, report.TotalDebits, report.TotalCredits)
+	for _, problem := range report.Problems {
+		fmt.Println("problem:", problem)
+	}
+	if !report.OK {
+		os.Exit(1)
+	}
+	fmt.Println("ok")
+}
@@ -1,12 +1,22 @@
 package main
 
 import (
+	"crypto/rand"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
 	_ "me-pague/docs"
+	"me-pague/internal/auth"
+	"me-pague/internal/config"
 	"me-pague/internal/db"
 	"me-pague/internal/controller"
+	"me-pague/internal/correction"
+	"me-pague/internal/currency"
+	"me-pague/internal/recurring"
+	"me-pague/internal/repository"
 	"github.com/gin-gonic/gin"
-	ginSwagger "github.com/swaggo/gin-swagger"
-	swaggerFiles "github.com/swaggo/files"
 )
 
 // @title Me Pague API
@@ -14,18 +24,116 @@ import (
 // @description API simples para registro de pagamentos entre usuários.
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
-	db.Init()
-	r := gin.Default()
+	cfg, args, err := config.Load(os.Args[1:], os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+	applyConfig(cfg)
 
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			runMigrate(cfg, args[1:])
+		case "config":
+			runConfig(cfg, args[1:])
+		case "ledger":
+			runLedger(cfg, args[1:])
+		case "user":
+			runUser(cfg, args[1:])
+		default:
+			log.Fatalf("unknown command %q; the commands are migrate, config, ledger and user", args[0])
+		}
+		return
+	}
 
-	r.POST("/user", controller.CreateUser)
-	r.GET("/user/:id", controller.GetUser)
+	database := db.Init(cfg.Database.DSN)
+	deps := controller.Deps{
+		Repositories: repository.NewGorm(database),
+		DB:           database,
+		Payments: controller.PaymentOptions{
+			RequireConfirmation: cfg.Payments.RequireConfirmation,
+			PendingTTL:          cfg.Payments.PendingTTL,
+		},
+		Auth: auth.Options{
+			Secret:     authSecret(cfg.Auth.Secret),
+			AccessTTL:  cfg.Auth.AccessTTL,
+			RefreshTTL: cfg.Auth.RefreshTTL,
+		},
+		Webhooks:             cfg.Webhooks.Options,
+		Penalty:              cfg.Penalty,
+		Indexes:              loadIndexTable(cfg.Correction.IndexFile),
+		Rates:                loadExchangeRates(cfg.Currency.ExchangeRatesFile),
+		IdempotencyRetention: cfg.Payments.IdempotencyRetention,
+		Swagger:              cfg.Features.Swagger,
+	}
+	if cfg.Features.Jobs {
+		jobs := controller.NewHandlers(deps)
+		go jobs.ExpirePendingPaymentsEvery(recurring.SystemClock{}, cfg.Jobs.Interval)
+		go jobs.RunRecurringBillingsEvery(recurring.SystemClock{}, cfg.Jobs.Interval)
+		go jobs.PostDueLoanInstallmentsEvery(recurring.SystemClock{}, cfg.Jobs.Interval)
+	}
+	if cfg.Features.WebhookWorker {
+		go cfg.Webhooks.Options.DeliverEvery(database, cfg.Webhooks.Interval)
+	}
 
-	r.GET("/billing", controller.GetBilling)
+	gin.SetMode(cfg.Server.GinMode)
+	r := controller.NewRouter(deps)
+	server := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	log.Printf("listening on %s", cfg.Server.Addr)
+	log.Fatal(server.ListenAndServe())
+}
+
+// authSecret devolve o segredo que assina os tokens. Sem secret, um
+// segredo aleatório é gerado e os tokens deixam de valer quando o servidor
+// reinicia.
+func authSecret(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		panic("failed to generate auth secret")
+	}
+	warn("auth.secret (ME_PAGUE_AUTH_SECRET) is not set; using a random secret, tokens will not survive a restart")
+	return random
+}
+
+func loadIndexTable(path string) *correction.Table {
+	if path == "" {
+		warn("correction.index_file (ME_PAGUE_INDEX_FILE) is not set; monetary correction has no index rates")
+		return nil
+	}
+
+	table, err := correction.LoadFile(path)
+	if err != nil {
+		log.Fatalf("failed to load index table %s: %v", path, err)
+	}
+	return table
+}
 
-	r.POST("/payment", controller.CreatePayment)
+func loadExchangeRates(path string) *currency.Rates {
+	if path == "" {
+		warn("currency.exchange_rates_file (ME_PAGUE_EXCHANGE_RATES_FILE) is not set; payments in other currencies will be rejected")
+		return nil
+	}
 
-	r.Run(":8080")
+	rates, err := currency.LoadRatesFile(path)
+	if err != nil {
+		log.Fatalf("failed to load exchange rates %s: %v", path, err)
+	}
+	return rates
 }
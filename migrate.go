@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"me-pague/internal/config"
+	"me-pague/internal/db"
+	"me-pague/internal/db/migrations"
+	"me-pague/internal/migrate"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = "usage: me-pague [flags] migrate up | down [steps] | status"
+
+// runMigrate trata "me-pague migrate", no banco de database.dsn: up aplica as migrações pendentes, down
+// desfaz as últimas (uma por padrão) e status lista todas com a situação de
+// cada uma. Como em db.Init, up adota um banco criado pelo AutoMigrate.
+func runMigrate(cfg config.Config, args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	database, err := db.Open(cfg.Database.DSN)
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := db.Migrate(database)
+		for _, m := range applied {
+			fmt.Println("applied", m)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatalf("invalid steps %q: %s", args[1], migrateUsage)
+			}
+		}
+		reverted, err := migrate.Down(database, migrations.FS, steps)
+		for _, m := range reverted {
+			fmt.Println("reverted", m)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	case "status":
+		states, err := migrate.Status(database, migrations.FS)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range states {
+			status, appliedAt := "pending", ""
+			if s.Applied {
+				status, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		w.Flush()
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
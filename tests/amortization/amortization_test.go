@@ -0,0 +1,81 @@
+package amortization_test
+
+import (
+	"me-pague/internal/amortization"
+	"me-pague/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func totals(rows []amortization.Row) (payment, interest, principal money.Amount) {
+	for _, row := range rows {
+		payment += row.Payment
+		interest += row.Interest
+		principal += row.Principal
+	}
+	return
+}
+
+func TestSchedule_Price(t *testing.T) {
+	// R$ 1.000,00 a 1% ao mês em 12 meses: prestação de R$ 88,85.
+	rows, err := amortization.Schedule(amortization.Price, 100000, 100, 12, date("2026-01-15"))
+
+	assert.Nil(t, err)
+	assert.Len(t, rows, 12)
+	assert.Equal(t, amortization.Row{Number: 1, DueDate: date("2026-01-15"), Payment: 8885, Interest: 1000, Principal: 7885, Balance: 92115}, rows[0])
+	assert.Equal(t, money.Amount(921), rows[1].Interest)
+	for _, row := range rows[:11] {
+		assert.Equal(t, money.Amount(8885), row.Payment)
+	}
+	assert.Equal(t, money.Amount(0), rows[11].Balance)
+	assert.InDelta(t, 8885, float64(rows[11].Payment), 5)
+	assert.Equal(t, date("2026-12-15"), rows[11].DueDate)
+
+	payment, interest, principal := totals(rows)
+	assert.Equal(t, money.Amount(100000), principal)
+	assert.Equal(t, payment, interest+principal)
+}
+
+func TestSchedule_SAC(t *testing.T) {
+	rows, err := amortization.Schedule(amortization.SAC, 100000, 100, 12, date("2026-01-15"))
+
+	assert.Nil(t, err)
+	// Amortização de 833,33 e juros decrescentes sobre o saldo.
+	assert.Equal(t, amortization.Row{Number: 1, DueDate: date("2026-01-15"), Payment: 9333, Interest: 1000, Principal: 8333, Balance: 91667}, rows[0])
+	assert.Equal(t, money.Amount(917), rows[1].Interest)
+	assert.Equal(t, money.Amount(8337), rows[11].Principal)
+	assert.Equal(t, money.Amount(0), rows[11].Balance)
+	for i := 1; i < len(rows); i++ {
+		assert.Less(t, rows[i].Interest, rows[i-1].Interest)
+	}
+
+	_, interest, principal := totals(rows)
+	assert.Equal(t, money.Amount(100000), principal)
+	assert.Equal(t, money.Amount(6500), interest)
+}
+
+func TestSchedule_ZeroRate(t *testing.T) {
+	rows, err := amortization.Schedule(amortization.Price, 1000, 0, 3, date("2026-01-31"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []money.Amount{333, 333, 334}, []money.Amount{rows[0].Payment, rows[1].Payment, rows[2].Payment})
+	assert.Equal(t, date("2026-02-28"), rows[1].DueDate)
+}
+
+func TestSchedule_Validation(t *testing.T) {
+	_, err := amortization.Schedule("german", 1000, 100, 3, date("2026-01-01"))
+	assert.NotNil(t, err)
+	_, err = amortization.Schedule(amortization.Price, 0, 100, 3, date("2026-01-01"))
+	assert.NotNil(t, err)
+	_, err = amortization.Schedule(amortization.Price, 1000, -1, 3, date("2026-01-01"))
+	assert.NotNil(t, err)
+	_, err = amortization.Schedule(amortization.SAC, 1000, 100, amortization.MaxTerm+1, date("2026-01-01"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,83 @@
+package auth_test
+
+import (
+	"me-pague/internal/auth"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOptions() auth.Options {
+	options := auth.DefaultOptions()
+	options.Secret = []byte("segredo-de-teste")
+	return options
+}
+
+func TestIssuePair_TokensVerify(t *testing.T) {
+	options := testOptions()
+	now := time.Now()
+
+	pair, err := options.IssuePair(7, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer", pair.TokenType)
+
+	claims, err := options.Verify(pair.AccessToken, auth.AccessToken, now)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(7), claims.Subject)
+
+	claims, err = options.Verify(pair.RefreshToken, auth.RefreshToken, now)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(7), claims.Subject)
+}
+
+func TestVerify_RejectsWrongType(t *testing.T) {
+	options := testOptions()
+	now := time.Now()
+	pair, _ := options.IssuePair(7, now)
+
+	_, err := options.Verify(pair.RefreshToken, auth.AccessToken, now)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	_, err = options.Verify(pair.AccessToken, auth.RefreshToken, now)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestVerify_RejectsExpired(t *testing.T) {
+	options := testOptions()
+	now := time.Now()
+	pair, _ := options.IssuePair(7, now)
+
+	_, err := options.Verify(pair.AccessToken, auth.AccessToken, now.Add(options.AccessTTL))
+	assert.ErrorIs(t, err, auth.ErrExpiredToken)
+}
+
+func TestVerify_RejectsTampered(t *testing.T) {
+	options := testOptions()
+	now := time.Now()
+	pair, _ := options.IssuePair(7, now)
+
+	forged, _ := options.Sign(auth.Claims{Subject: 8, Type: auth.AccessToken, ExpiresAt: now.Add(time.Hour).Unix()})
+	parts := strings.Split(pair.AccessToken, ".")
+	forgedParts := strings.Split(forged, ".")
+	_, err := options.Verify(parts[0]+"."+forgedParts[1]+"."+parts[2], auth.AccessToken, now)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	options.Secret = []byte("outro-segredo")
+	_, err = options.Verify(pair.AccessToken, auth.AccessToken, now)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestHashPassword(t *testing.T) {
+	_, err := auth.HashPassword("curta")
+	assert.ErrorIs(t, err, auth.ErrPasswordTooShort)
+	_, err = auth.HashPassword(strings.Repeat("a", auth.MaxPasswordLength+1))
+	assert.ErrorIs(t, err, auth.ErrPasswordTooLong)
+
+	hash, err := auth.HashPassword("segredo123")
+	assert.Nil(t, err)
+	assert.True(t, auth.CheckPassword(hash, "segredo123"))
+	assert.False(t, auth.CheckPassword(hash, "segredo124"))
+	assert.False(t, auth.CheckPassword("", "segredo123"))
+}
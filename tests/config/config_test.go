@@ -0,0 +1,134 @@
+package config_test
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"me-pague/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func env(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, args, err := config.Load(nil, env(nil), io.Discard)
+	assert.Nil(t, err)
+	assert.Empty(t, args)
+	assert.Equal(t, ":8080", cfg.Server.Addr)
+	assert.Equal(t, "payments.db", cfg.Database.DSN)
+	assert.True(t, cfg.Payments.RequireConfirmation)
+	assert.Equal(t, 24*time.Hour, cfg.Payments.IdempotencyRetention)
+	assert.Equal(t, int64(200), cfg.Penalty.LateFeeBP)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, "me-pague.yaml", `
+server:
+  addr: ":7000"
+  read_timeout: 5s
+database:
+  dsn: arquivo.db
+payments:
+  require_confirmation: false
+`)
+	vars := map[string]string{
+		"ME_PAGUE_CONFIG":      path,
+		"ME_PAGUE_SERVER_ADDR": ":7100",
+		"ME_PAGUE_INDEX_FILE":  "indices.csv",
+	}
+
+	cfg, args, err := config.Load([]string{"-server.addr=:7200", "migrate", "up"}, env(vars), io.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"migrate", "up"}, args)
+	assert.Equal(t, ":7200", cfg.Server.Addr)
+	assert.Equal(t, 5*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, "arquivo.db", cfg.Database.DSN)
+	assert.False(t, cfg.Payments.RequireConfirmation)
+	assert.Equal(t, "indices.csv", cfg.Correction.IndexFile)
+
+	cfg, _, err = config.Load(nil, env(vars), io.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, ":7100", cfg.Server.Addr)
+}
+
+func TestLoad_TOML(t *testing.T) {
+	path := writeFile(t, "me-pague.toml", `
+[webhooks]
+max_attempts = 3
+base_delay = "1s"
+
+[penalty]
+cap_bp = 0
+`)
+
+	cfg, _, err := config.Load([]string{"-config", path}, env(nil), io.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, cfg.Webhooks.MaxAttempts)
+	assert.Equal(t, time.Second, cfg.Webhooks.BaseDelay)
+	assert.Equal(t, int64(0), cfg.Penalty.CapBP)
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	path := writeFile(t, "me-pague.yaml", "server:\n  adr: \":7000\"\nwebhooks:\n  max_attempts: 0\n")
+	vars := map[string]string{"ME_PAGUE_FEATURES_SWAGGER": "talvez"}
+
+	_, _, err := config.Load([]string{"-config", path, "-server.read_timeout=abc", "-log.level=loud"}, env(vars), io.Discard)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "unknown option server.adr")
+		assert.Contains(t, err.Error(), "invalid value for features.swagger")
+		assert.Contains(t, err.Error(), "invalid value for server.read_timeout")
+		assert.Contains(t, err.Error(), "log.level must be debug, info, warn or error")
+		assert.Contains(t, err.Error(), "webhooks.max_attempts must be at least 1")
+	}
+}
+
+func TestLoad_RejectsBadFiles(t *testing.T) {
+	_, _, err := config.Load([]string{"-config", writeFile(t, "me-pague.json", "{}")}, env(nil), io.Discard)
+	assert.NotNil(t, err)
+
+	_, _, err = config.Load([]string{"-config", writeFile(t, "me-pague.yaml", "server: [1, 2]")}, env(nil), io.Discard)
+	assert.NotNil(t, err)
+
+	_, _, err = config.Load([]string{"-config", filepath.Join(t.TempDir(), "nada.yaml")}, env(nil), io.Discard)
+	assert.NotNil(t, err)
+
+	_, _, err = config.Load([]string{"-server.addr=8080"}, env(nil), io.Discard)
+	assert.NotNil(t, err)
+
+	_, _, err = config.Load([]string{"-h"}, env(nil), io.Discard)
+	assert.ErrorIs(t, err, flag.ErrHelp)
+}
+
+func TestPrint_MasksSecretsAndRoundTrips(t *testing.T) {
+	cfg, _, err := config.Load([]string{"-auth.secret=muito-secreto", "-database.dsn=outro.db"}, env(nil), io.Discard)
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	assert.Nil(t, config.Print(&out, cfg))
+	assert.NotContains(t, out.String(), "muito-secreto")
+	assert.Contains(t, out.String(), `secret: "********"`)
+	assert.Contains(t, out.String(), `dsn: "outro.db"`)
+
+	// A saída serve de arquivo de configuração; o segredo mascarado é
+	// sobrescrito pela flag.
+	path := writeFile(t, "impresso.yaml", out.String())
+	reloaded, _, err := config.Load([]string{"-config", path, "-auth.secret=muito-secreto"}, env(nil), io.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, cfg, reloaded)
+}
@@ -0,0 +1,110 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"me-pague/internal/audit"
+	"me-pague/internal/auth"
+	"me-pague/internal/controller"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/db/dbtest"
+	"me-pague/internal/models"
+	"me-pague/internal/penalty"
+	"me-pague/internal/repository"
+	"me-pague/internal/service"
+	"me-pague/internal/webhook"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// directPayments aplica os pagamentos na hora, sem esperar o recebedor.
+var directPayments = controller.PaymentOptions{PendingTTL: time.Hour}
+
+// confirmedPayments deixa pendentes os pagamentos registrados pelo pagador.
+var confirmedPayments = controller.PaymentOptions{RequireConfirmation: true, PendingTTL: time.Hour}
+
+// testAuth assina os tokens de todos os testes.
+var testAuth = auth.Options{Secret: []byte("segredo-de-teste"), AccessTTL: 15 * time.Minute, RefreshTTL: time.Hour}
+
+// testAPI é a API montada por controller.NewRouter sobre um banco em memória
+// novo. jobs são os handlers das rotinas periódicas, sobre as mesmas Deps.
+type testAPI struct {
+	db     *gorm.DB
+	repos  repository.Repositories
+	jobs   *controller.Handlers
+	router *gin.Engine
+}
+
+// newTestAPI monta a API com payments; cada configure ajusta as Deps antes
+// da montagem.
+func newTestAPI(payments controller.PaymentOptions, configure ...func(*controller.Deps)) *testAPI {
+	gin.SetMode(gin.TestMode)
+
+	database := dbtest.New()
+	deps := controller.Deps{
+		Repositories: repository.NewGorm(database),
+		DB:           database,
+		Payments:     payments,
+		Auth:         testAuth,
+		Webhooks:     webhook.DefaultOptions(),
+		Penalty:      penalty.DefaultPolicy(),
+	}
+	for _, f := range configure {
+		f(&deps)
+	}
+	return &testAPI{
+		db:     database,
+		repos:  deps.Repositories,
+		jobs:   controller.NewHandlers(deps),
+		router: controller.NewRouter(deps),
+	}
+}
+
+// request envia a requisição com body em JSON autenticada como userID; com
+// zero, vai sem token.
+func (api *testAPI) request(method, path string, userID int32, body interface{}) *httptest.ResponseRecorder {
+	return api.send(jsonRequest(method, path, body), userID)
+}
+
+// send envia req autenticada como userID; com zero, vai sem token.
+func (api *testAPI) send(req *http.Request, userID int32) *httptest.ResponseRecorder {
+	if userID != 0 {
+		tokens, err := testAuth.IssuePair(userID, time.Now())
+		if err != nil {
+			panic(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	}
+	w := httptest.NewRecorder()
+	api.router.ServeHTTP(w, req)
+	return w
+}
+
+func jsonRequest(method, path string, body interface{}) *http.Request {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// createUser cadastra um usuário sem senha direto no repositório.
+func (api *testAPI) createUser(name string) (models.User, error) {
+	user := models.User{Name: name}
+	if err := api.repos.Users.Create(&user, audit.System); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (api *testAPI) getOrCreateBilling(input request.BillingInput) (models.Billing, error) {
+	billings := service.BillingService{Users: api.repos.Users, Billings: api.repos.Billings, Payments: api.repos.Payments}
+	key := service.BillingKey{PayerID: input.PayerID, ReceiverID: input.ReceiverID, Currency: input.Currency}
+	return billings.GetOrCreate(key, audit.System)
+}
@@ -0,0 +1,156 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/middleware"
+	"me-pague/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAuditTestDB() *testAPI {
+	return newTestAPI(confirmedPayments)
+}
+
+func listAuditEvents(api *testAPI, userID int32, rawQuery string) *httptest.ResponseRecorder {
+	return api.request("GET", "/audit?"+rawQuery, userID, nil)
+}
+
+func decodeAuditEvents(t *testing.T, w *httptest.ResponseRecorder) []models.AuditEvent {
+	var events []models.AuditEvent
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &events))
+	return events
+}
+
+func TestAudit_RecordsPaymentWithRequestMetadata(t *testing.T) {
+	api := setupAuditTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+
+	req := jsonRequest("POST", "/payment", map[string]interface{}{"billing_id": billing.ID, "amount": 40})
+	req.Header.Set(middleware.RequestIDHeader, "req-123")
+	w := api.send(req, ana.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var payment models.Payment
+	json.Unmarshal(w.Body.Bytes(), &payment)
+
+	events := decodeAuditEvents(t, listAuditEvents(api, ana.ID, "entity_type=payment&entity_id="+strconv.Itoa(int(payment.ID))))
+	assert.Len(t, events, 1)
+	assert.Equal(t, ana.ID, events[0].ActorID)
+	assert.Equal(t, audit.Create, events[0].Action)
+	assert.Equal(t, "req-123", events[0].RequestID)
+	assert.Equal(t, "192.0.2.1", events[0].ClientIP)
+	assert.Empty(t, events[0].Before)
+	assert.Contains(t, string(events[0].After), `"status":"pending"`)
+}
+
+func TestAudit_RecordsBeforeAndAfterOnConfirmation(t *testing.T) {
+	api := setupAuditTestDB()
+
+	billing, payment := createPendingPayment(api, t)
+	assert.Equal(t, http.StatusOK, resolvePayment(api, "confirm", billing.ReceiverID, payment.ID).Code)
+
+	events := decodeAuditEvents(t, listAuditEvents(api, billing.ReceiverID, "entity_type=payment&actor_id="+strconv.Itoa(int(billing.ReceiverID))))
+	assert.Len(t, events, 1)
+	assert.Equal(t, audit.Confirm, events[0].Action)
+	assert.Contains(t, string(events[0].Before), `"status":"pending"`)
+	assert.Contains(t, string(events[0].After), `"status":"confirmed"`)
+}
+
+func TestAudit_ExpiryIsRecordedAsSystem(t *testing.T) {
+	api := setupAuditTestDB()
+
+	billing, payment := createPendingPayment(api, t)
+	expired, err := api.jobs.ExpirePendingPayments(time.Now().Add(2 * time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	events := decodeAuditEvents(t, listAuditEvents(api, billing.PayerID, "entity_type=payment&entity_id="+strconv.Itoa(int(payment.ID))))
+	assert.Len(t, events, 2)
+	assert.Equal(t, audit.Expire, events[1].Action)
+	assert.Equal(t, int32(0), events[1].ActorID)
+	assert.Contains(t, string(events[1].After), `"status":"expired"`)
+}
+
+func TestAudit_FiltersByTimeRange(t *testing.T) {
+	api := setupAuditTestDB()
+
+	api.createUser("Ana")
+
+	events := decodeAuditEvents(t, listAuditEvents(api, 1, "entity_type=user"))
+	assert.Len(t, events, 1)
+
+	tomorrow := time.Now().Add(24 * time.Hour).Format("2006-01-02")
+	events = decodeAuditEvents(t, listAuditEvents(api, 1, "from="+tomorrow))
+	assert.Len(t, events, 0)
+
+	yesterday := time.Now().Add(-24 * time.Hour).Format("2006-01-02")
+	events = decodeAuditEvents(t, listAuditEvents(api, 1, "from="+yesterday+"&to="+tomorrow))
+	assert.Len(t, events, 1)
+
+	w := listAuditEvents(api, 1, "from=ontem")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = listAuditEvents(api, 1, "from="+tomorrow+"&to="+yesterday)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAudit_EventsAreAppendOnly(t *testing.T) {
+	api := setupAuditTestDB()
+
+	api.createUser("Ana")
+
+	var event models.AuditEvent
+	assert.Nil(t, api.db.First(&event).Error)
+
+	event.Action = "edited"
+	assert.ErrorIs(t, api.db.Save(&event).Error, models.ErrAppendOnly)
+	assert.ErrorIs(t, api.db.Delete(&event).Error, models.ErrAppendOnly)
+}
+
+func TestAudit_OnlyListsEventsVisibleToTheCaller(t *testing.T) {
+	api := setupAuditTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+
+	w := postPixKey(api, ana.ID, map[string]interface{}{"type": "email", "key": "ana@example.com"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var key models.PixKey
+	json.Unmarshal(w.Body.Bytes(), &key)
+	assert.Equal(t, http.StatusNoContent, pixKeyRequest(api, "DELETE", "", ana.ID, key.ID).Code)
+
+	events := decodeAuditEvents(t, listAuditEvents(api, ana.ID, "entity_type=pix_key"))
+	assert.Len(t, events, 2)
+
+	events = decodeAuditEvents(t, listAuditEvents(api, beto.ID, "entity_type=pix_key"))
+	assert.Len(t, events, 0)
+	events = decodeAuditEvents(t, listAuditEvents(api, beto.ID, "actor_id="+strconv.Itoa(int(ana.ID))))
+	assert.Len(t, events, 0)
+
+	events = decodeAuditEvents(t, listAuditEvents(api, beto.ID, "entity_type=user"))
+	assert.Len(t, events, 1)
+	assert.Equal(t, beto.ID, events[0].EntityID)
+}
+
+func TestAudit_BillingPartiesSeeEachOthersPayments(t *testing.T) {
+	api := setupAuditTestDB()
+
+	billing, payment := createPendingPayment(api, t)
+	caio, _ := api.createUser("Caio")
+	query := "entity_type=payment&entity_id=" + strconv.Itoa(int(payment.ID))
+
+	assert.Len(t, decodeAuditEvents(t, listAuditEvents(api, billing.PayerID, query)), 1)
+	assert.Len(t, decodeAuditEvents(t, listAuditEvents(api, billing.ReceiverID, query)), 1)
+	assert.Len(t, decodeAuditEvents(t, listAuditEvents(api, caio.ID, query)), 0)
+}
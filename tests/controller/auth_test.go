@@ -0,0 +1,91 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/auth"
+	"me-pague/internal/controller/request"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAuthTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func TestLogin_Success(t *testing.T) {
+	api := setupAuthTestDB()
+
+	w := api.request("POST", "/user", 0, map[string]interface{}{"name": "Ana", "password": "segredo123"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, w.Body.String(), "segredo123")
+
+	w = api.request("POST", "/auth/login", 0, map[string]interface{}{"name": "Ana", "password": "segredo123"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var tokens auth.TokenPair
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	w = api.request("POST", "/auth/refresh", 0, map[string]interface{}{"refresh_token": tokens.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = api.request("POST", "/auth/refresh", 0, map[string]interface{}{"refresh_token": tokens.AccessToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLogin_WrongPassword(t *testing.T) {
+	api := setupAuthTestDB()
+
+	api.request("POST", "/user", 0, map[string]interface{}{"name": "Ana", "password": "segredo123"})
+
+	w := api.request("POST", "/auth/login", 0, map[string]interface{}{"name": "Ana", "password": "errada123"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid name or password")
+
+	w = api.request("POST", "/auth/login", 0, map[string]interface{}{"name": "Beto", "password": "segredo123"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid name or password")
+}
+
+func TestCreateUser_ShortPassword(t *testing.T) {
+	api := setupAuthTestDB()
+
+	w := api.request("POST", "/user", 0, map[string]interface{}{"name": "Ana", "password": "curta"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "password must have at least")
+}
+
+func TestCreateUser_LongPassword(t *testing.T) {
+	api := setupAuthTestDB()
+
+	w := api.request("POST", "/user", 0, map[string]interface{}{"name": "Ana", "password": strings.Repeat("a", 73)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "password must have at most 72 bytes")
+
+	w = api.request("POST", "/user", 0, map[string]interface{}{"name": "Ana", "password": strings.Repeat("a", 72)})
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestBilling_NonPartyIsForbidden(t *testing.T) {
+	api := setupAuthTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	caio, _ := api.createUser("Caio")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+
+	w := api.request("GET", "/billing?payer_id="+strconv.Itoa(int(ana.ID))+"&receiver_id="+strconv.Itoa(int(beto.ID)), caio.ID, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = postBalancePayment(api, caio.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 10})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "you are not a party to this billing")
+
+	w = api.request("POST", "/payment", 0, map[string]interface{}{"billing_id": billing.ID, "amount": 10})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
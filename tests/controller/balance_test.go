@@ -0,0 +1,119 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupBalanceTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func postBalancePayment(api *testAPI, userID int32, body map[string]interface{}) *httptest.ResponseRecorder {
+	return api.request("POST", "/payment", userID, body)
+}
+
+func getBalance(api *testAPI, userA, userB int32) *httptest.ResponseRecorder {
+	return api.request("GET", "/balance?user_a="+strconv.Itoa(int(userA))+"&user_b="+strconv.Itoa(int(userB)), userA, nil)
+}
+
+func TestGetBalance_NetsBothDirections(t *testing.T) {
+	api := setupBalanceTestDB()
+
+	carlos, _ := api.createUser("Carlos")
+	fernanda, _ := api.createUser("Fernanda")
+	billing1, _ := api.getOrCreateBilling(request.BillingInput{PayerID: carlos.ID, ReceiverID: fernanda.ID})
+	billing2, _ := api.getOrCreateBilling(request.BillingInput{PayerID: fernanda.ID, ReceiverID: carlos.ID})
+
+	assert.Equal(t, http.StatusOK, postBalancePayment(api, carlos.ID, map[string]interface{}{"billing_id": billing1.ID, "amount": 100}).Code)
+	assert.Equal(t, http.StatusOK, postBalancePayment(api, fernanda.ID, map[string]interface{}{"billing_id": billing2.ID, "amount": 80}).Code)
+
+	w := getBalance(api, fernanda.ID, carlos.ID)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var balance response.BalanceResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &balance))
+	assert.Equal(t, money.Amount(20), balance.Net)
+	assert.Equal(t, fernanda.ID, balance.DebtorID)
+	assert.Equal(t, carlos.ID, balance.CreditorID)
+	assert.Len(t, balance.Billings, 2)
+	assert.Len(t, balance.Payments, 2)
+}
+
+func TestGetBalance_NoBillings(t *testing.T) {
+	api := setupBalanceTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+
+	w := getBalance(api, ana.ID, beto.ID)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var balance response.BalanceResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &balance))
+	assert.Equal(t, money.Amount(0), balance.Net)
+	assert.Equal(t, int32(0), balance.DebtorID)
+	assert.Empty(t, balance.Billings)
+}
+
+func TestGetBalance_SameUser(t *testing.T) {
+	api := setupBalanceTestDB()
+
+	ana, _ := api.createUser("Ana")
+
+	w := getBalance(api, ana.ID, ana.ID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "cannot be the same")
+}
+
+func TestCreatePayment_ApplyToNet(t *testing.T) {
+	api := setupBalanceTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	anaToBeto, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	assert.Equal(t, http.StatusOK, postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": anaToBeto.ID, "amount": 100}).Code)
+
+	// Mesmo informando a cobrança Ana -> Beto, o pagamento vai para o devedor líquido (Beto).
+	w := postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": anaToBeto.ID, "amount": 60, "apply_to_net": true})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var payment models.Payment
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &payment))
+	assert.Equal(t, beto.ID, payment.PayerID)
+
+	var balance response.BalanceResponse
+	json.Unmarshal(getBalance(api, ana.ID, beto.ID).Body.Bytes(), &balance)
+	assert.Equal(t, money.Amount(40), balance.Net)
+	assert.Equal(t, beto.ID, balance.DebtorID)
+}
+
+func TestCreatePayment_ApplyToNetExceedsBalance(t *testing.T) {
+	api := setupBalanceTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	anaToBeto, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+
+	w := postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": anaToBeto.ID, "amount": 10, "apply_to_net": true})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "no net balance to settle")
+
+	assert.Equal(t, http.StatusOK, postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": anaToBeto.ID, "amount": 30}).Code)
+
+	w = postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": anaToBeto.ID, "amount": 31, "apply_to_net": true})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "amount exceeds the net balance of 30")
+}
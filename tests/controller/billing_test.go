@@ -2,43 +2,31 @@ package controller_test
 
 import (
 	"encoding/json"
-	"me-pague/internal/controller"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
 	"me-pague/internal/models"
+	"me-pague/internal/money"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"strconv"
 
-	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
-	"me-pague/internal/db"
 )
 
-func setupBillingTestDB() {
-	testDB, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	testDB.AutoMigrate(&models.User{}, &models.Billing{})
-	db.DB = testDB
+func setupBillingTestDB() *testAPI {
+	return newTestAPI(directPayments)
 }
 
 func TestGetBilling_Success(t *testing.T) {
-	setupBillingTestDB()
+	api := setupBillingTestDB()
 
-	user1, _ := controller.CreateUserHandler("Antonio")
-	user2, _ := controller.CreateUserHandler("Davi")
+	user1, _ := api.createUser("Antonio")
+	user2, _ := api.createUser("Davi")
 
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
 	request_url := "/billing?payer_id=" + strconv.Itoa(int(user1.ID)) + "&receiver_id=" + strconv.Itoa(int(user2.ID))
-	c.Request = httptest.NewRequest("GET", request_url, nil)
-	c.Request.Header.Set("Content-Type", "application/json")
-	q := c.Request.URL.Query()
-	q.Add("payer_id", "1")
-	q.Add("receiver_id", "2")
-	c.Request.URL.RawQuery = q.Encode()
-
-	controller.GetBilling(c)
+	w := api.request("GET", request_url, user1.ID, nil)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
@@ -47,41 +35,84 @@ func TestGetBilling_Success(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, int32(1), billing.PayerID)
 	assert.Equal(t, int32(2), billing.ReceiverID)
-	assert.Equal(t, int32(0), billing.Amount)
+	assert.Equal(t, money.Amount(0), billing.Amount)
 }
 
 func TestGetBilling_SameIDs(t *testing.T) {
-	setupBillingTestDB()
+	api := setupBillingTestDB()
 
-	user1, _ := controller.CreateUserHandler("Antonio")
+	user1, _ := api.createUser("Antonio")
 
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
 	request_url := "/billing?payer_id=" + strconv.Itoa(int(user1.ID)) + "&receiver_id=" + strconv.Itoa(int(user1.ID))
-	c.Request = httptest.NewRequest("GET", request_url, nil)
-	c.Request.Header.Set("Content-Type", "application/json")
-	q := c.Request.URL.Query()
-	q.Add("payer_id", "1")
-	q.Add("receiver_id", "2")
-	c.Request.URL.RawQuery = q.Encode()
-
-	controller.GetBilling(c)
+	w := api.request("GET", request_url, user1.ID, nil)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "payer_id and receiver_id cannot be the same")
 }
 
 func TestGetBilling_UserNotFound(t *testing.T) {
-	setupBillingTestDB()
-
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
-	c.Request = httptest.NewRequest("GET", "/billing?payer_id=1&receiver_id=2", nil)
-	c.Request.Header.Set("Content-Type", "application/json")
-	c.Request.URL.RawQuery = "payer_id=1&receiver_id=2"
+	api := setupBillingTestDB()
 
-	controller.GetBilling(c)
+	w := api.request("GET", "/billing?payer_id=1&receiver_id=2", 1, nil)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "Person 1 not found")
 }
+
+func getBillingAsOf(api *testAPI, userID, payerID, receiverID int32, asOf string) *httptest.ResponseRecorder {
+	q := url.Values{}
+	q.Add("payer_id", strconv.Itoa(int(payerID)))
+	q.Add("receiver_id", strconv.Itoa(int(receiverID)))
+	if asOf != "" {
+		q.Add("as_of", asOf)
+	}
+	return api.request("GET", "/billing?"+q.Encode(), userID, nil)
+}
+
+func TestGetBilling_Penalties(t *testing.T) {
+	api := setupBillingTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 10000, "date": "2026-01-01", "due_date": "2026-01-10"})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 5000, "date": "2026-01-01"})
+
+	w := getBillingAsOf(api, ana.ID, ana.ID, beto.ID, "2026-01-10")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result response.BillingResponse
+	json.Unmarshal(w.Body.Bytes(), &result)
+	assert.Equal(t, money.Amount(15000), result.OriginalAmount)
+	assert.Equal(t, money.Amount(0), result.Penalties)
+	assert.Equal(t, money.Amount(15000), result.TotalDue)
+
+	w = getBillingAsOf(api, ana.ID, ana.ID, beto.ID, "2026-01-25")
+	json.Unmarshal(w.Body.Bytes(), &result)
+	assert.Equal(t, "2026-01-25", result.AsOf)
+	assert.Equal(t, money.Amount(15000), result.Outstanding)
+	assert.Equal(t, money.Amount(200), result.LateFee)
+	assert.Equal(t, money.Amount(50), result.Interest)
+	assert.Equal(t, money.Amount(250), result.Penalties)
+	assert.Equal(t, money.Amount(15250), result.TotalDue)
+	assert.Len(t, result.Charges, 2)
+
+	assert.Equal(t, http.StatusBadRequest, getBillingAsOf(api, ana.ID, ana.ID, beto.ID, "25/01/2026").Code)
+}
+
+func TestCreateCharge_DueDateValidation(t *testing.T) {
+	api := setupBillingTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+
+	w := postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 100, "due_date": "10/01/2026"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 100, "date": "2026-01-10", "due_date": "2026-01-09"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "due_date must not be before")
+
+	w = postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 100, "date": "2026-01-10", "due_date": "2026-01-10"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"due_date":"2026-01-10T00:00:00Z"`)
+}
@@ -0,0 +1,142 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"math"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupChargeTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func postCharge(api *testAPI, userID, billingID int32, body map[string]interface{}) *httptest.ResponseRecorder {
+	return api.request("POST", "/billing/"+strconv.Itoa(int(billingID))+"/charge", userID, body)
+}
+
+func TestCreateCharge_UpdatesOutstanding(t *testing.T) {
+	api := setupChargeTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+
+	w := postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 300, "description": "Mercado", "date": "2025-03-10"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var charge models.Charge
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &charge))
+	assert.Equal(t, money.Amount(300), charge.Amount)
+	assert.Equal(t, "2025-03-10", charge.Date.Format("2006-01-02"))
+
+	assert.Equal(t, http.StatusOK, postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 120}).Code)
+
+	billing, _ = api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	assert.Equal(t, money.Amount(300), billing.TotalCharged)
+	assert.Equal(t, money.Amount(120), billing.TotalPaid)
+	assert.Equal(t, money.Amount(180), billing.Outstanding)
+	assert.Equal(t, money.Amount(0), billing.Credit)
+}
+
+func TestCreateCharge_InvalidInput(t *testing.T) {
+	api := setupChargeTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+
+	w := postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 0})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "amount must be greater than zero")
+
+	w = postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 10, "date": "10/03/2025"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postCharge(api, beto.ID, 999, map[string]interface{}{"amount": 10})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCreateCharge_DecimalAmounts(t *testing.T) {
+	api := setupChargeTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+
+	w := postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": "12.50"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var charge models.Charge
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &charge))
+	assert.Equal(t, money.Amount(1250), charge.Amount)
+
+	w = postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": "1.005"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "more than 2 decimal places")
+
+	assert.Equal(t, http.StatusOK, postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": "2.5"}).Code)
+
+	billing, _ = api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	assert.Equal(t, money.Amount(1000), billing.Outstanding)
+}
+
+func TestCreateCharge_Overflow(t *testing.T) {
+	api := setupChargeTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+
+	w := postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": int64(math.MaxInt64)})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 1})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), money.ErrOverflow.Error())
+
+	billing, _ = api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	assert.Equal(t, money.Amount(math.MaxInt64), billing.TotalCharged)
+}
+
+func TestCreatePayment_OverpaymentRecordedAsCredit(t *testing.T) {
+	api := setupChargeTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 100})
+
+	w := postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 130})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var payment models.Payment
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &payment))
+	assert.Equal(t, money.Amount(30), payment.Credit)
+
+	billing, _ = api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	assert.Equal(t, money.Amount(0), billing.Outstanding)
+	assert.Equal(t, money.Amount(30), billing.Credit)
+}
+
+func TestCreatePayment_RejectOverpayment(t *testing.T) {
+	api := setupChargeTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 100})
+
+	w := postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 101, "reject_overpayment": true})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "amount exceeds the outstanding balance of 100")
+
+	w = postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 100, "reject_overpayment": true})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,149 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupConfirmationTestDB() *testAPI {
+	return newTestAPI(confirmedPayments)
+}
+
+// resolvePayment chama /payment/{id}/{action}, que é confirm ou reject.
+func resolvePayment(api *testAPI, action string, userID, paymentID int32) *httptest.ResponseRecorder {
+	return api.request("POST", "/payment/"+strconv.Itoa(int(paymentID))+"/"+action, userID, nil)
+}
+
+func createPendingPayment(api *testAPI, t *testing.T) (models.Billing, models.Payment) {
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 100})
+
+	w := postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 80})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var payment models.Payment
+	json.Unmarshal(w.Body.Bytes(), &payment)
+	return billing, payment
+}
+
+func reloadBilling(api *testAPI, billing models.Billing) models.Billing {
+	updated, _ := api.getOrCreateBilling(request.BillingInput{PayerID: billing.PayerID, ReceiverID: billing.ReceiverID, Currency: billing.Currency})
+	return updated
+}
+
+func TestCreatePayment_StartsPending(t *testing.T) {
+	api := setupConfirmationTestDB()
+
+	billing, payment := createPendingPayment(api, t)
+
+	assert.Equal(t, models.PaymentPending, payment.Status)
+	billing = reloadBilling(api, billing)
+	assert.Equal(t, money.Amount(0), billing.Amount)
+	assert.Equal(t, money.Amount(0), billing.TotalPaid)
+	assert.Equal(t, money.Amount(100), billing.Outstanding)
+}
+
+func TestConfirmPayment_CountsTowardBilling(t *testing.T) {
+	api := setupConfirmationTestDB()
+
+	billing, payment := createPendingPayment(api, t)
+
+	w := resolvePayment(api, "confirm", billing.ReceiverID, payment.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var confirmed models.Payment
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &confirmed))
+	assert.Equal(t, models.PaymentConfirmed, confirmed.Status)
+	assert.NotNil(t, confirmed.ResolvedAt)
+
+	billing = reloadBilling(api, billing)
+	assert.Equal(t, money.Amount(80), billing.Amount)
+	assert.Equal(t, money.Amount(80), billing.TotalPaid)
+	assert.Equal(t, money.Amount(20), billing.Outstanding)
+
+	w = resolvePayment(api, "confirm", billing.ReceiverID, payment.ID)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, money.Amount(80), reloadBilling(api, billing).TotalPaid)
+}
+
+func TestRejectPayment_NeverCounts(t *testing.T) {
+	api := setupConfirmationTestDB()
+
+	billing, payment := createPendingPayment(api, t)
+
+	w := resolvePayment(api, "reject", billing.ReceiverID, payment.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"rejected"`)
+
+	w = resolvePayment(api, "confirm", billing.ReceiverID, payment.ID)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "payment is not pending")
+
+	assert.Equal(t, money.Amount(0), reloadBilling(api, billing).TotalPaid)
+}
+
+func TestPendingPayment_Expires(t *testing.T) {
+	api := setupConfirmationTestDB()
+
+	billing, payment := createPendingPayment(api, t)
+
+	expired, err := api.jobs.ExpirePendingPayments(time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), expired)
+
+	expired, err = api.jobs.ExpirePendingPayments(time.Now().Add(2 * time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	w := resolvePayment(api, "confirm", billing.ReceiverID, payment.ID)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "expired")
+}
+
+func TestReversePayment_PendingNotReversible(t *testing.T) {
+	api := setupConfirmationTestDB()
+
+	billing, payment := createPendingPayment(api, t)
+
+	w := reversePayment(api, billing.ReceiverID, payment.ID, map[string]interface{}{"reason": "Engano"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "only confirmed payments can be reversed")
+}
+
+func TestConfirmPayment_OnlyReceiver(t *testing.T) {
+	api := setupConfirmationTestDB()
+
+	billing, payment := createPendingPayment(api, t)
+
+	w := resolvePayment(api, "confirm", billing.PayerID, payment.ID)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "only the receiver can confirm or reject a payment")
+
+	assert.Equal(t, money.Amount(0), reloadBilling(api, billing).TotalPaid)
+}
+
+func TestCreatePayment_RecordedByReceiverIsConfirmed(t *testing.T) {
+	api := setupConfirmationTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 100})
+
+	w := postBalancePayment(api, beto.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 80})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"confirmed"`)
+
+	assert.Equal(t, money.Amount(80), reloadBilling(api, billing).TotalPaid)
+}
@@ -0,0 +1,64 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/controller"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/correction"
+	"me-pague/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getCorrectedBilling(api *testAPI, userID, billingID int32, query string) *httptest.ResponseRecorder {
+	return api.request("GET", "/billing/"+strconv.Itoa(int(billingID))+"/corrected?"+query, userID, nil)
+}
+
+func setupCorrectionTestDB(t *testing.T) *testAPI {
+	table, err := correction.LoadCSV(strings.NewReader("index,month,rate\nIPCA,2024-02,0.83\nIPCA,2024-03,0.16\n"))
+	assert.Nil(t, err)
+	return newTestAPI(directPayments, func(deps *controller.Deps) { deps.Indexes = table })
+}
+
+func TestGetCorrectedBilling(t *testing.T) {
+	api := setupCorrectionTestDB(t)
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 100000, "date": "2024-01-10"})
+
+	w := getCorrectedBilling(api, ana.ID, billing.ID, "index=IPCA&date=2024-04-05")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result response.CorrectedBillingResponse
+	json.Unmarshal(w.Body.Bytes(), &result)
+	assert.Equal(t, billing.ID, result.BillingID)
+	assert.Equal(t, "IPCA", result.Index)
+	assert.Len(t, result.Months, 4)
+	assert.Equal(t, money.Amount(100000), result.Original)
+	assert.Equal(t, money.Amount(100991), result.Corrected)
+}
+
+func TestGetCorrectedBilling_Errors(t *testing.T) {
+	api := setupCorrectionTestDB(t)
+
+	billing := createDebt(api, t, 10000)
+	carla, _ := api.createUser("Carla")
+
+	w := getCorrectedBilling(api, billing.PayerID, billing.ID, "date=2024-04-05")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = getCorrectedBilling(api, billing.PayerID, billing.ID, "index=SELIC")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "no rates loaded for index SELIC")
+
+	w = getCorrectedBilling(api, carla.ID, billing.ID, "index=IPCA")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,136 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/controller"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/currency"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupCurrencyTestDB cota o dólar de hoje para os pagamentos dos testes.
+func setupCurrencyTestDB(t *testing.T) *testAPI {
+	rates := currency.NewRates()
+	today := time.Now().Format("2006-01-02")
+	assert.Nil(t, rates.Set(today, "BRL", "USD", "0.17998"))
+	return newTestAPI(directPayments, func(deps *controller.Deps) { deps.Rates = rates })
+}
+
+func createTripBilling(api *testAPI, t *testing.T) models.Billing {
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, err := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID, Currency: "usd"})
+	assert.Nil(t, err)
+	return billing
+}
+
+func TestGetOrCreateBilling_OnePerCurrency(t *testing.T) {
+	api := setupInstallmentTestDB()
+
+	usd := createTripBilling(api, t)
+	brl, _ := api.getOrCreateBilling(request.BillingInput{PayerID: usd.PayerID, ReceiverID: usd.ReceiverID})
+	again, _ := api.getOrCreateBilling(request.BillingInput{PayerID: usd.PayerID, ReceiverID: usd.ReceiverID, Currency: "USD"})
+
+	assert.Equal(t, "USD", usd.Currency)
+	assert.Equal(t, currency.Default, brl.Currency)
+	assert.NotEqual(t, usd.ID, brl.ID)
+	assert.Equal(t, usd.ID, again.ID)
+
+	_, err := api.getOrCreateBilling(request.BillingInput{PayerID: usd.PayerID, ReceiverID: usd.ReceiverID, Currency: "XYZ"})
+	assert.NotNil(t, err)
+}
+
+func TestCreateCharge_CurrencyMustMatchBilling(t *testing.T) {
+	api := setupInstallmentTestDB()
+
+	billing := createTripBilling(api, t)
+
+	w := postCharge(api, billing.ReceiverID, billing.ID, map[string]interface{}{"amount": 5000, "currency": "EUR"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "charge currency EUR does not match the billing currency USD")
+
+	w = postCharge(api, billing.ReceiverID, billing.ID, map[string]interface{}{"amount": 5000})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var charge models.Charge
+	json.Unmarshal(w.Body.Bytes(), &charge)
+	assert.Equal(t, "USD", charge.Currency)
+}
+
+func TestCreatePayment_ConvertsOtherCurrency(t *testing.T) {
+	api := setupCurrencyTestDB(t)
+
+	billing := createTripBilling(api, t)
+	postCharge(api, billing.ReceiverID, billing.ID, map[string]interface{}{"amount": 5000})
+
+	// R$ 100,00 a 0,17998 dólar por real: US$ 17,998, arredondados para US$ 18,00.
+	w := postBalancePayment(api, billing.PayerID, map[string]interface{}{"billing_id": billing.ID, "amount": 10000, "currency": "BRL"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var payment models.Payment
+	json.Unmarshal(w.Body.Bytes(), &payment)
+	assert.Equal(t, money.Amount(1800), payment.Amount)
+	assert.Equal(t, "USD", payment.Currency)
+	assert.Equal(t, money.Amount(10000), payment.OriginalAmount)
+	assert.Equal(t, "BRL", payment.OriginalCurrency)
+	assert.Equal(t, "0.17998", payment.ExchangeRate)
+	assert.Equal(t, time.Now().Format("2006-01-02"), payment.RateDate.Format("2006-01-02"))
+	assert.Equal(t, "0.2", payment.Rounding)
+
+	billing = reloadBilling(api, billing)
+	assert.Equal(t, money.Amount(3200), billing.Outstanding)
+
+	w = postBalancePayment(api, billing.PayerID, map[string]interface{}{"billing_id": billing.ID, "amount": 1000})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var plain models.Payment
+	json.Unmarshal(w.Body.Bytes(), &plain)
+	assert.Equal(t, money.Amount(1000), plain.Amount)
+	assert.Empty(t, plain.OriginalCurrency)
+}
+
+func TestCreatePayment_CurrencyErrors(t *testing.T) {
+	api := setupCurrencyTestDB(t)
+
+	billing := createTripBilling(api, t)
+	postCharge(api, billing.ReceiverID, billing.ID, map[string]interface{}{"amount": 5000})
+
+	w := postBalancePayment(api, billing.PayerID, map[string]interface{}{"billing_id": billing.ID, "amount": 1000, "currency": "EUR"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "no EUR/USD exchange rate")
+
+	w = postBalancePayment(api, billing.PayerID, map[string]interface{}{"billing_id": billing.ID, "amount": 1000, "currency": "XYZ"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBalanceAndPix_ScopedByCurrency(t *testing.T) {
+	api := setupInstallmentTestDB()
+
+	usd := createTripBilling(api, t)
+	brl, _ := api.getOrCreateBilling(request.BillingInput{PayerID: usd.PayerID, ReceiverID: usd.ReceiverID})
+	postCharge(api, usd.ReceiverID, usd.ID, map[string]interface{}{"amount": 5000})
+	postCharge(api, brl.ReceiverID, brl.ID, map[string]interface{}{"amount": 700})
+
+	w := api.request("GET", "/balance?user_a="+strconv.Itoa(int(usd.PayerID))+"&user_b="+strconv.Itoa(int(usd.ReceiverID))+"&currency=USD", usd.PayerID, nil)
+
+	var balance response.BalanceResponse
+	json.Unmarshal(w.Body.Bytes(), &balance)
+	assert.Equal(t, "USD", balance.Currency)
+	assert.Equal(t, money.Amount(5000), balance.Net)
+	assert.Len(t, balance.Billings, 1)
+
+	w = getBalance(api, usd.PayerID, usd.ReceiverID)
+	json.Unmarshal(w.Body.Bytes(), &balance)
+	assert.Equal(t, money.Amount(700), balance.Net)
+
+	w = api.request("GET", "/billing/"+strconv.Itoa(int(usd.ID))+"/pix?key=ana@example.com", usd.ReceiverID, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "pix is only available for BRL billings")
+}
@@ -0,0 +1,149 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupGroupTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func createTestGroup(api *testAPI, t *testing.T, memberIDs ...int32) models.Group {
+	w := api.request("POST", "/group", memberIDs[0], map[string]interface{}{"name": "Viagem", "member_ids": memberIDs})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var group models.Group
+	json.Unmarshal(w.Body.Bytes(), &group)
+	return group
+}
+
+func postGroupExpense(api *testAPI, userID, groupID int32, body map[string]interface{}) *httptest.ResponseRecorder {
+	return api.request("POST", "/group/"+strconv.Itoa(int(groupID))+"/expense", userID, body)
+}
+
+func TestCreateGroup_UnknownMember(t *testing.T) {
+	api := setupGroupTestDB()
+
+	user, _ := api.createUser("Ana")
+
+	w := api.request("POST", "/group", user.ID, map[string]interface{}{"name": "Casa", "member_ids": []int32{user.ID, 99}})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "User 99 not found")
+}
+
+func TestCreateGroupExpense_EqualSplit(t *testing.T) {
+	api := setupGroupTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	caio, _ := api.createUser("Caio")
+	group := createTestGroup(api, t, ana.ID, beto.ID, caio.ID)
+
+	w := postGroupExpense(api, ana.ID, group.ID, map[string]interface{}{
+		"payer_id":    ana.ID,
+		"total":       1000,
+		"description": "Jantar",
+		"split":       "equal",
+	})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var expense models.GroupExpense
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &expense))
+	assert.Len(t, expense.Shares, 3)
+	assert.Equal(t, money.Amount(334), expense.Shares[0].Amount)
+	assert.Equal(t, money.Amount(333), expense.Shares[1].Amount)
+	assert.Equal(t, money.Amount(333), expense.Shares[2].Amount)
+
+	var charge models.Charge
+	api.db.First(&charge, expense.Shares[1].ChargeID)
+	assert.Equal(t, money.Amount(333), charge.Amount)
+	assert.Equal(t, "Jantar", charge.Description)
+
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: beto.ID, ReceiverID: ana.ID})
+	assert.Equal(t, expense.Shares[1].BillingID, billing.ID)
+	assert.Equal(t, money.Amount(333), billing.TotalCharged)
+	assert.Equal(t, money.Amount(333), billing.Outstanding)
+
+	var count int64
+	api.db.Model(&models.Billing{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestCreateGroupExpense_UpdatesExistingBilling(t *testing.T) {
+	api := setupGroupTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	group := createTestGroup(api, t, ana.ID, beto.ID)
+
+	for i := 0; i < 2; i++ {
+		w := postGroupExpense(api, ana.ID, group.ID, map[string]interface{}{
+			"payer_id": ana.ID,
+			"total":    500,
+			"split":    "shares",
+			"shares": []map[string]interface{}{
+				{"user_id": ana.ID, "value": 1},
+				{"user_id": beto.ID, "value": 4},
+			},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: beto.ID, ReceiverID: ana.ID})
+	assert.Equal(t, money.Amount(800), billing.TotalCharged)
+	assert.Equal(t, money.Amount(0), billing.TotalPaid)
+}
+
+func TestCreateGroupExpense_PayerNotMember(t *testing.T) {
+	api := setupGroupTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	caio, _ := api.createUser("Caio")
+	group := createTestGroup(api, t, ana.ID, beto.ID)
+
+	w := postGroupExpense(api, ana.ID, group.ID, map[string]interface{}{
+		"payer_id": caio.ID,
+		"total":    100,
+		"split":    "equal",
+	})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "is not a member of the group")
+}
+
+func TestCreateGroupExpense_InvalidPercentages(t *testing.T) {
+	api := setupGroupTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	group := createTestGroup(api, t, ana.ID, beto.ID)
+
+	w := postGroupExpense(api, ana.ID, group.ID, map[string]interface{}{
+		"payer_id": ana.ID,
+		"total":    100,
+		"split":    "percentage",
+		"shares": []map[string]interface{}{
+			{"user_id": ana.ID, "value": 5000},
+			{"user_id": beto.ID, "value": 3000},
+		},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "percentages must add up")
+
+	var count int64
+	api.db.Model(&models.GroupExpense{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,139 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupInstallmentTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func postInstallmentPlan(api *testAPI, userID, billingID int32, body map[string]interface{}) *httptest.ResponseRecorder {
+	return api.request("POST", "/billing/"+strconv.Itoa(int(billingID))+"/installments", userID, body)
+}
+
+func listInstallmentPlans(api *testAPI, t *testing.T, userID, billingID int32, asOf string) []models.InstallmentPlan {
+	w := api.request("GET", "/billing/"+strconv.Itoa(int(billingID))+"/installments?as_of="+asOf, userID, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var plans []models.InstallmentPlan
+	json.Unmarshal(w.Body.Bytes(), &plans)
+	return plans
+}
+
+func installmentStatuses(plan models.InstallmentPlan) []string {
+	var statuses []string
+	for _, item := range plan.Installments {
+		statuses = append(statuses, item.Status)
+	}
+	return statuses
+}
+
+func createDebt(api *testAPI, t *testing.T, amount int32) models.Billing {
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": amount})
+	return billing
+}
+
+func TestCreateInstallmentPlan_SplitsOutstanding(t *testing.T) {
+	api := setupInstallmentTestDB()
+
+	billing := createDebt(api, t, 100000)
+	postBalancePayment(api, billing.PayerID, map[string]interface{}{"billing_id": billing.ID, "amount": 10000})
+
+	w := postInstallmentPlan(api, billing.PayerID, billing.ID, map[string]interface{}{"count": 4, "first_due_date": "2026-01-10"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var plan models.InstallmentPlan
+	json.Unmarshal(w.Body.Bytes(), &plan)
+	assert.Equal(t, money.Amount(90000), plan.Total)
+	assert.Equal(t, money.Amount(10000), plan.PaidBefore)
+	assert.Len(t, plan.Installments, 4)
+	assert.Equal(t, money.Amount(22500), plan.Installments[0].Amount)
+	assert.Equal(t, "2026-04-10", plan.Installments[3].DueDate.Format("2006-01-02"))
+}
+
+func TestInstallmentPlan_AllocatesPaymentsOldestFirst(t *testing.T) {
+	api := setupInstallmentTestDB()
+
+	// Os pagamentos são feitos hoje, então as datas partem de hoje.
+	today := time.Now().UTC()
+	firstDue := today.AddDate(0, 0, 5)
+	billing := createDebt(api, t, 30000)
+	postInstallmentPlan(api, billing.PayerID, billing.ID, map[string]interface{}{"count": 3, "first_due_date": firstDue.Format("2006-01-02")})
+
+	plans := listInstallmentPlans(api, t, billing.PayerID, billing.ID, today.Format("2006-01-02"))
+	assert.Equal(t, []string{"open", "open", "open"}, installmentStatuses(plans[0]))
+
+	postBalancePayment(api, billing.PayerID, map[string]interface{}{"billing_id": billing.ID, "amount": 15000})
+
+	plans = listInstallmentPlans(api, t, billing.ReceiverID, billing.ID, today.Format("2006-01-02"))
+	assert.Equal(t, []string{"paid", "partial", "open"}, installmentStatuses(plans[0]))
+	assert.Equal(t, money.Amount(5000), plans[0].Installments[1].Paid)
+	assert.Equal(t, money.Amount(15000), plans[0].Paid)
+
+	plans = listInstallmentPlans(api, t, billing.ReceiverID, billing.ID, firstDue.AddDate(0, 1, 1).Format("2006-01-02"))
+	assert.Equal(t, []string{"paid", "overdue", "open"}, installmentStatuses(plans[0]))
+
+	postBalancePayment(api, billing.PayerID, map[string]interface{}{"billing_id": billing.ID, "amount": 15000})
+	plans = listInstallmentPlans(api, t, billing.ReceiverID, billing.ID, firstDue.AddDate(0, 3, 0).Format("2006-01-02"))
+	assert.Equal(t, []string{"paid", "paid", "paid"}, installmentStatuses(plans[0]))
+}
+
+func TestInstallmentPlan_IgnoresPaymentsAfterAsOf(t *testing.T) {
+	api := setupInstallmentTestDB()
+
+	today := time.Now().UTC()
+	billing := createDebt(api, t, 30000)
+	postBalancePayment(api, billing.PayerID, map[string]interface{}{"billing_id": billing.ID, "amount": 6000})
+	postInstallmentPlan(api, billing.PayerID, billing.ID, map[string]interface{}{"count": 3, "first_due_date": today.AddDate(0, 0, -20).Format("2006-01-02")})
+	postBalancePayment(api, billing.PayerID, map[string]interface{}{"billing_id": billing.ID, "amount": 8000})
+
+	// Ontem nada tinha sido pago, nem antes do plano: a primeira parcela já
+	// estava vencida.
+	plans := listInstallmentPlans(api, t, billing.PayerID, billing.ID, today.AddDate(0, 0, -1).Format("2006-01-02"))
+	assert.Equal(t, money.Amount(0), plans[0].Paid)
+	assert.Equal(t, []string{"overdue", "open", "open"}, installmentStatuses(plans[0]))
+
+	plans = listInstallmentPlans(api, t, billing.PayerID, billing.ID, today.Format("2006-01-02"))
+	assert.Equal(t, money.Amount(8000), plans[0].Paid)
+	assert.Equal(t, []string{"paid", "open", "open"}, installmentStatuses(plans[0]))
+}
+
+func TestCreateInstallmentPlan_Validation(t *testing.T) {
+	api := setupInstallmentTestDB()
+
+	billing := createDebt(api, t, 1000)
+	caio, _ := api.createUser("Caio")
+
+	cases := map[string]map[string]interface{}{
+		"exceeds the outstanding":    {"count": 2, "first_due_date": "2026-01-10", "amount": 2000},
+		"first_due_date must be":     {"count": 2, "first_due_date": "10/01/2026"},
+		"count must be between":      {"count": 500, "first_due_date": "2026-01-10"},
+		"InstallmentPlanInput.Count": {"first_due_date": "2026-01-10"},
+	}
+	for message, body := range cases {
+		w := postInstallmentPlan(api, billing.PayerID, billing.ID, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, message)
+		assert.Contains(t, w.Body.String(), message)
+	}
+
+	assert.Equal(t, http.StatusForbidden, postInstallmentPlan(api, caio.ID, billing.ID, map[string]interface{}{"count": 2, "first_due_date": "2026-01-10"}).Code)
+
+	assert.Equal(t, http.StatusCreated, postInstallmentPlan(api, billing.PayerID, billing.ID, map[string]interface{}{"count": 2, "first_due_date": "2026-01-10"}).Code)
+	w := postInstallmentPlan(api, billing.PayerID, billing.ID, map[string]interface{}{"count": 2, "first_due_date": "2026-01-10", "amount": 500})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "already has an open installment plan")
+}
@@ -0,0 +1,256 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/amortization"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupLoanTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func postLoan(api *testAPI, userID int32, body map[string]interface{}) *httptest.ResponseRecorder {
+	return api.request("POST", "/loans", userID, body)
+}
+
+func getLoan(api *testAPI, t *testing.T, userID, loanID int32, asOf string) models.Loan {
+	w := api.request("GET", "/loans/"+strconv.Itoa(int(loanID))+"?as_of="+asOf, userID, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var loan models.Loan
+	json.Unmarshal(w.Body.Bytes(), &loan)
+	return loan
+}
+
+func loanStatuses(loan models.Loan) []string {
+	var statuses []string
+	for _, item := range loan.Installments {
+		statuses = append(statuses, item.Status)
+	}
+	return statuses
+}
+
+// loanToday é a data de hoje em UTC. Os pagamentos dos testes são feitos
+// hoje e só contam nas consultas com as_of a partir de hoje.
+func loanToday() time.Time {
+	year, month, day := time.Now().UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// loanFirstDue deixa as duas primeiras parcelas vencidas hoje e a terceira
+// ainda por vencer.
+func loanFirstDue() string {
+	return loanToday().AddDate(0, -1, -5).Format("2006-01-02")
+}
+
+func createLoan(api *testAPI, t *testing.T, system string) models.Loan {
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	return createLoanBetween(api, t, system, ana.ID, beto.ID)
+}
+
+func createLoanBetween(api *testAPI, t *testing.T, system string, payerID, receiverID int32) models.Loan {
+	w := postLoan(api, receiverID, map[string]interface{}{
+		"payer_id": payerID, "receiver_id": receiverID, "principal": 100000, "monthly_rate_bp": 100,
+		"term": 12, "system": system, "first_due_date": loanFirstDue(),
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var loan models.Loan
+	json.Unmarshal(w.Body.Bytes(), &loan)
+	return loan
+}
+
+func TestCreateLoan_BuildsSchedule(t *testing.T) {
+	api := setupLoanTestDB()
+
+	price := createLoan(api, t, amortization.Price)
+	assert.Equal(t, models.LoanActive, price.Status)
+	assert.Len(t, price.Installments, 12)
+	assert.Equal(t, money.Amount(8885), price.Installments[0].Payment)
+	assert.Equal(t, money.Amount(1000), price.Installments[0].Interest)
+	assert.Equal(t, money.Amount(7885), price.Installments[0].Principal)
+	assert.Nil(t, price.Installments[0].ChargeID)
+
+	api = setupLoanTestDB()
+	sac := createLoan(api, t, amortization.SAC)
+	assert.Equal(t, money.Amount(9333), sac.Installments[0].Payment)
+	assert.Equal(t, money.Amount(8333), sac.Installments[0].Principal)
+	assert.Equal(t, money.Amount(0), sac.Installments[11].Balance)
+}
+
+func TestCreateLoan_Validation(t *testing.T) {
+	api := setupLoanTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	carla, _ := api.createUser("Carla")
+	body := map[string]interface{}{
+		"payer_id": ana.ID, "receiver_id": beto.ID, "principal": 100000, "monthly_rate_bp": 100,
+		"term": 12, "system": "german", "first_due_date": "2026-01-15",
+	}
+
+	w := postLoan(api, beto.ID, body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	body["system"] = amortization.Price
+	w = postLoan(api, carla.ID, body)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	body["first_due_date"] = "15/01/2026"
+	w = postLoan(api, beto.ID, body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateLoan_FailureDoesNotLeaveABilling(t *testing.T) {
+	api := setupLoanTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	assert.Nil(t, api.db.Exec("CREATE TRIGGER fail_loans BEFORE INSERT ON loans BEGIN SELECT RAISE(ABORT, 'loans are read-only'); END").Error)
+
+	w := postLoan(api, beto.ID, map[string]interface{}{
+		"payer_id": ana.ID, "receiver_id": beto.ID, "principal": 100000, "monthly_rate_bp": 100,
+		"term": 12, "system": amortization.Price, "first_due_date": "2026-01-15",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "loans are read-only")
+
+	var billings, events int64
+	api.db.Model(&models.Billing{}).Count(&billings)
+	api.db.Model(&models.AuditEvent{}).Where("entity_type = ?", "billing").Count(&events)
+	assert.Equal(t, int64(0), billings)
+	assert.Equal(t, int64(0), events)
+}
+
+func TestLoan_PostsDueInstallmentsAndAllocatesPayments(t *testing.T) {
+	api := setupLoanTestDB()
+
+	loan := createLoan(api, t, amortization.Price)
+	payer := int32(1)
+
+	posted, err := api.jobs.PostDueLoanInstallments(loanToday())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, posted)
+
+	posted, err = api.jobs.PostDueLoanInstallments(loanToday())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, posted)
+
+	var charges []models.Charge
+	api.db.Where("billing_id = ?", loan.BillingID).Order("id").Find(&charges)
+	assert.Len(t, charges, 2)
+	assert.Equal(t, money.Amount(8885), charges[1].Amount)
+	assert.True(t, loan.Installments[1].DueDate.Equal(*charges[1].DueDate))
+
+	postBalancePayment(api, payer, map[string]interface{}{"billing_id": loan.BillingID, "amount": 8885})
+
+	loan = getLoan(api, t, payer, loan.ID, loanToday().Format("2006-01-02"))
+	assert.Equal(t, []string{"paid", "overdue", "open"}, loanStatuses(loan)[:3])
+	assert.Equal(t, money.Amount(8885), loan.Paid)
+	assert.NotNil(t, loan.Installments[1].ChargeID)
+	assert.Nil(t, loan.Installments[2].ChargeID)
+	// Parcela 2 em aberto mais o saldo devedor depois dela.
+	assert.Equal(t, 8885+loan.Installments[1].Balance, loan.PayoffAmount)
+}
+
+func TestPayOffLoan_RecomputesRemainingBalance(t *testing.T) {
+	api := setupLoanTestDB()
+
+	loan := createLoan(api, t, amortization.Price)
+	payer := int32(1)
+	api.jobs.PostDueLoanInstallments(loanToday())
+	postBalancePayment(api, payer, map[string]interface{}{"billing_id": loan.BillingID, "amount": 2 * 8885})
+	balance := loan.Installments[1].Balance
+
+	w := api.request("POST", "/loans/"+strconv.Itoa(int(loan.ID))+"/payoff", payer, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	json.Unmarshal(w.Body.Bytes(), &loan)
+	assert.Equal(t, models.LoanPaidOff, loan.Status)
+	assert.NotNil(t, loan.PaidOffAt)
+	assert.Len(t, loan.Installments, 3)
+	assert.Equal(t, balance, loan.Installments[2].Payment)
+	assert.Equal(t, money.Amount(0), loan.Installments[2].Interest)
+	assert.Equal(t, balance, loan.PayoffAmount)
+
+	billing := reloadBilling(api, models.Billing{PayerID: payer, ReceiverID: 2})
+	assert.Equal(t, 2*8885+balance, billing.TotalCharged)
+	assert.Equal(t, balance, billing.Outstanding)
+
+	w = api.request("POST", "/loans/"+strconv.Itoa(int(loan.ID))+"/payoff", payer, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	posted, err := api.jobs.PostDueLoanInstallments(loanToday().AddDate(1, 0, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, posted)
+
+	postBalancePayment(api, payer, map[string]interface{}{"billing_id": loan.BillingID, "amount": balance})
+	loan = getLoan(api, t, payer, loan.ID, loanToday().Format("2006-01-02"))
+	assert.Equal(t, []string{"paid", "paid", "paid"}, loanStatuses(loan))
+	assert.Equal(t, money.Amount(0), loan.PayoffAmount)
+}
+
+func TestLoan_PaymentsAreAllocatedPerLoan(t *testing.T) {
+	api := setupLoanTestDB()
+
+	first := createLoan(api, t, amortization.Price)
+	second := createLoanBetween(api, t, amortization.Price, 1, 2)
+	assert.Equal(t, first.BillingID, second.BillingID)
+	posted, err := api.jobs.PostDueLoanInstallments(loanToday())
+	assert.Nil(t, err)
+	assert.Equal(t, 4, posted)
+
+	postBalancePayment(api, 1, map[string]interface{}{"billing_id": first.BillingID, "amount": 8885})
+
+	today := loanToday().Format("2006-01-02")
+	first = getLoan(api, t, 1, first.ID, today)
+	assert.Equal(t, money.Amount(8885), first.Paid)
+	assert.Equal(t, []string{"paid", "overdue", "open"}, loanStatuses(first)[:3])
+
+	second = getLoan(api, t, 1, second.ID, today)
+	assert.Equal(t, money.Amount(0), second.Paid)
+	assert.Equal(t, []string{"overdue", "overdue", "open"}, loanStatuses(second)[:3])
+	assert.Equal(t, 2*8885+second.Installments[1].Balance, second.PayoffAmount)
+
+	// Antes do pagamento, nenhuma parcela tinha sido paga.
+	first = getLoan(api, t, 1, first.ID, loanToday().AddDate(0, 0, -1).Format("2006-01-02"))
+	assert.Equal(t, money.Amount(0), first.Paid)
+}
+
+func TestPayOffLoan_OnlyTheBorrower(t *testing.T) {
+	api := setupLoanTestDB()
+
+	loan := createLoan(api, t, amortization.Price)
+	lender := int32(2)
+
+	w := api.request("POST", "/loans/"+strconv.Itoa(int(loan.ID))+"/payoff", lender, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "only the borrower")
+
+	loan = getLoan(api, t, lender, loan.ID, loanToday().Format("2006-01-02"))
+	assert.Equal(t, models.LoanActive, loan.Status)
+	assert.Len(t, loan.Installments, 12)
+}
+
+func TestGetLoan_Forbidden(t *testing.T) {
+	api := setupLoanTestDB()
+
+	loan := createLoan(api, t, amortization.SAC)
+	carla, _ := api.createUser("Carla")
+
+	w := api.request("GET", "/loans/"+strconv.Itoa(int(loan.ID)), carla.ID, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = api.request("GET", "/loans/999", carla.ID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
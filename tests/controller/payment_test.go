@@ -1,78 +1,54 @@
 package controller_test
 
 import (
-	"bytes"
 	"encoding/json"
-	"me-pague/internal/controller"
 	"me-pague/internal/controller/request"
-	"me-pague/internal/db"
+	"me-pague/internal/ledger"
 	"me-pague/internal/models"
+	"me-pague/internal/money"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
-	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
-func setupTestPaymentDB() {
-	testDB, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	testDB.AutoMigrate(&models.User{}, &models.Billing{}, &models.Payment{})
-	db.DB = testDB
+func setupTestPaymentDB() *testAPI {
+	return newTestAPI(directPayments)
 }
 
 func TestCreatePayment_Success(t *testing.T) {
-	setupTestPaymentDB()
-	gin.SetMode(gin.TestMode)
+	api := setupTestPaymentDB()
 
-	user1, _ := controller.CreateUserHandler("Antonio")
-	user2, _ := controller.CreateUserHandler("Davi")
-	billing, _ := controller.GetOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
-
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
+	user1, _ := api.createUser("Antonio")
+	user2, _ := api.createUser("Davi")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
 
 	body := map[string]interface{}{
 		"billing_id": billing.ID,
 		"amount":     50,
 	}
-	jsonBody, _ := json.Marshal(body)
-
-	req := httptest.NewRequest("POST", "/payment", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	c.Request = req
-
-	controller.CreatePayment(c)
+	w := api.request("POST", "/payment", user1.ID, body)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var payment models.Payment
-	result := db.DB.First(&payment, "billing_id = ?", billing.ID)
+	result := api.db.First(&payment, "billing_id = ?", billing.ID)
 
 	assert.Nil(t, result.Error)
-	assert.Equal(t, int32(50), payment.Amount)
+	assert.Equal(t, money.Amount(50), payment.Amount)
 }
 
 func TestCreatePayment_InvalidBillingID(t *testing.T) {
-	setupTestPaymentDB()
-	gin.SetMode(gin.TestMode)
-
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
+	api := setupTestPaymentDB()
 
 	body := map[string]interface{}{
 		"billing_id": 999,
 		"amount":     50,
 	}
-	jsonBody, _ := json.Marshal(body)
-
-	req := httptest.NewRequest("POST", "/payment", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	c.Request = req
-
-	controller.CreatePayment(c)
+	w := api.request("POST", "/payment", 1, body)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
@@ -82,45 +58,26 @@ func TestCreatePayment_InvalidBillingID(t *testing.T) {
 }
 
 func TestCreatePayment_InvalidPayload(t *testing.T) {
-	setupTestPaymentDB()
-	gin.SetMode(gin.TestMode)
-
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
+	api := setupTestPaymentDB()
 
 	body := `{"billing_id": 1, "amount": "not_a_number"}`
-
-	req := httptest.NewRequest("POST", "/payment", bytes.NewBufferString(body))
-	req.Header.Set("Content-Type", "application/json")
-	c.Request = req
-
-	controller.CreatePayment(c)
+	w := api.send(httptest.NewRequest("POST", "/payment", strings.NewReader(body)), 1)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
 func TestCreatePayment_NegativeAmount(t *testing.T) {
-	setupTestPaymentDB()
-	gin.SetMode(gin.TestMode)
-
-	user1, _ := controller.CreateUserHandler("Ana")
-	user2, _ := controller.CreateUserHandler("Beto")
-	billing, _ := controller.GetOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
+	api := setupTestPaymentDB()
 
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
+	user1, _ := api.createUser("Ana")
+	user2, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
 
 	body := map[string]interface{}{
 		"billing_id": billing.ID,
 		"amount":     -30,
 	}
-	jsonBody, _ := json.Marshal(body)
-
-	req := httptest.NewRequest("POST", "/payment", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	c.Request = req
-
-	controller.CreatePayment(c)
+	w := api.request("POST", "/payment", user1.ID, body)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	var responseBody map[string]interface{}
@@ -129,87 +86,125 @@ func TestCreatePayment_NegativeAmount(t *testing.T) {
 }
 
 func TestCreatePayment_MultiplePayments(t *testing.T) {
-	setupTestPaymentDB()
-	gin.SetMode(gin.TestMode)
+	api := setupTestPaymentDB()
 
-	user1, _ := controller.CreateUserHandler("Lara")
-	user2, _ := controller.CreateUserHandler("Rafael")
-	billing, _ := controller.GetOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
+	user1, _ := api.createUser("Lara")
+	user2, _ := api.createUser("Rafael")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
 
 	amounts := []int32{10, 20, 30}
 
 	for _, amt := range amounts {
-		w := httptest.NewRecorder()
-		c, _ := gin.CreateTestContext(w)
-
 		body := map[string]interface{}{
 			"billing_id": billing.ID,
 			"amount":     amt,
 		}
-		jsonBody, _ := json.Marshal(body)
-
-		req := httptest.NewRequest("POST", "/payment", bytes.NewBuffer(jsonBody))
-		req.Header.Set("Content-Type", "application/json")
-		c.Request = req
-
-		controller.CreatePayment(c)
+		w := api.request("POST", "/payment", user1.ID, body)
 		assert.Equal(t, http.StatusOK, w.Code)
 	}
 
 	var updatedBilling models.Billing
-	db.DB.First(&updatedBilling, billing.ID)
+	api.db.First(&updatedBilling, billing.ID)
 
-	assert.Equal(t, int32(60), updatedBilling.Amount)
+	assert.Equal(t, money.Amount(60), updatedBilling.Amount)
 }
 
 func TestCreatePayment_BalanceDifference(t *testing.T) {
-	setupTestPaymentDB()
-	gin.SetMode(gin.TestMode)
+	api := setupTestPaymentDB()
 
-	user1, _ := controller.CreateUserHandler("Carlos")
-	user2, _ := controller.CreateUserHandler("Fernanda")
-	billing1, _ := controller.GetOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
-	billing2, _ := controller.GetOrCreateBilling(request.BillingInput{PayerID: user2.ID, ReceiverID: user1.ID})
-
-	w1 := httptest.NewRecorder()
-	c1, _ := gin.CreateTestContext(w1)
+	user1, _ := api.createUser("Carlos")
+	user2, _ := api.createUser("Fernanda")
+	billing1, _ := api.getOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
+	billing2, _ := api.getOrCreateBilling(request.BillingInput{PayerID: user2.ID, ReceiverID: user1.ID})
 
 	body1 := map[string]interface{}{
 		"billing_id": billing1.ID,
 		"amount":     100,
 	}
-	jsonBody1, _ := json.Marshal(body1)
-
-	req1 := httptest.NewRequest("POST", "/payment", bytes.NewBuffer(jsonBody1))
-	req1.Header.Set("Content-Type", "application/json")
-	c1.Request = req1
-
-	controller.CreatePayment(c1)
+	w1 := api.request("POST", "/payment", user1.ID, body1)
 	assert.Equal(t, http.StatusOK, w1.Code)
 
-	w2 := httptest.NewRecorder()
-	c2, _ := gin.CreateTestContext(w2)
-
 	body2 := map[string]interface{}{
 		"billing_id": billing2.ID,
 		"amount":     80,
 	}
-	jsonBody2, _ := json.Marshal(body2)
-
-	req2 := httptest.NewRequest("POST", "/payment", bytes.NewBuffer(jsonBody2))
-	req2.Header.Set("Content-Type", "application/json")
-	c2.Request = req2
-
-	controller.CreatePayment(c2)
+	w2 := api.request("POST", "/payment", user2.ID, body2)
 	assert.Equal(t, http.StatusOK, w2.Code)
 
 	var updatedBilling1, updatedBilling2 models.Billing
-	db.DB.First(&updatedBilling1, billing1.ID)
-	db.DB.First(&updatedBilling2, billing2.ID)
+	api.db.First(&updatedBilling1, billing1.ID)
+	api.db.First(&updatedBilling2, billing2.ID)
+
+	assert.Equal(t, money.Amount(100), updatedBilling1.Amount)
+	assert.Equal(t, money.Amount(80), updatedBilling2.Amount)
+
+	assert.Equal(t, updatedBilling1.Amount - updatedBilling2.Amount, money.Amount(20))
+	assert.Equal(t, updatedBilling2.Amount - updatedBilling1.Amount, money.Amount(-20))
+}
+
+func TestCreatePayment_LedgerStaysBalanced(t *testing.T) {
+	api := setupTestPaymentDB()
+
+	user1, _ := api.createUser("Lara")
+	user2, _ := api.createUser("Rafael")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
+
+	for _, amt := range []int32{10, 20, 30} {
+		w := api.request("POST", "/payment", user1.ID, map[string]interface{}{"billing_id": billing.ID, "amount": amt})
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	report, err := ledger.Check(api.db)
+	assert.Nil(t, err)
+	assert.True(t, report.OK, report.Problems)
+	assert.Equal(t, money.Amount(60), report.TotalDebits)
+	assert.Equal(t, money.Amount(60), report.TotalCredits)
+
+	billing, _ = api.getOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
+	assert.Equal(t, money.Amount(60), billing.TotalPaid)
+	assert.Equal(t, billing.Amount, billing.TotalPaid)
+}
+
+func TestCreatePayment_ConcurrentPayments(t *testing.T) {
+	api := setupTestPaymentDB()
+
+	// Uma única conexão, como em db.Init: o banco em memória do SQLite é
+	// próprio de cada conexão.
+	sqlDB, _ := api.db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	user1, _ := api.createUser("Nina")
+	user2, _ := api.createUser("Otto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
+
+	const workers = 300
+	codes := make([]int, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			w := api.request("POST", "/payment", user1.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 5})
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	var succeeded int32
+	for _, code := range codes {
+		assert.Contains(t, []int{http.StatusOK, http.StatusConflict}, code)
+		if code == http.StatusOK {
+			succeeded++
+		}
+	}
+	assert.Greater(t, succeeded, int32(0))
 
-	assert.Equal(t, int32(100), updatedBilling1.Amount)
-	assert.Equal(t, int32(80), updatedBilling2.Amount)
+	var payments int64
+	api.db.Model(&models.Payment{}).Where("billing_id = ?", billing.ID).Count(&payments)
+	assert.Equal(t, int64(succeeded), payments)
 
-	assert.Equal(t, updatedBilling1.Amount - updatedBilling2.Amount, int32(20))
-	assert.Equal(t, updatedBilling2.Amount - updatedBilling1.Amount, int32(-20))
+	updatedBilling, _ := api.getOrCreateBilling(request.BillingInput{PayerID: user1.ID, ReceiverID: user2.ID})
+	assert.Equal(t, money.Amount(succeeded*5), updatedBilling.Amount)
+	assert.Equal(t, updatedBilling.Amount, updatedBilling.TotalPaid)
 }
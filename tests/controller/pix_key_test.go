@@ -0,0 +1,139 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupPixKeyTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func postPixKey(api *testAPI, userID int32, body map[string]interface{}) *httptest.ResponseRecorder {
+	return api.request("POST", "/pix-keys", userID, body)
+}
+
+// pixKeyRequest chama /pix-keys/{id}, seguido de /action se houver.
+func pixKeyRequest(api *testAPI, method, action string, userID, keyID int32) *httptest.ResponseRecorder {
+	path := "/pix-keys/" + strconv.Itoa(int(keyID))
+	if action != "" {
+		path += "/" + action
+	}
+	return api.request(method, path, userID, nil)
+}
+
+func listPixKeys(api *testAPI, t *testing.T, userID int32) []models.PixKey {
+	w := api.request("GET", "/pix-keys", userID, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var keys []models.PixKey
+	json.Unmarshal(w.Body.Bytes(), &keys)
+	return keys
+}
+
+func TestCreatePixKey_NormalizesAndDefaultsFirst(t *testing.T) {
+	api := setupPixKeyTestDB()
+
+	ana, _ := api.createUser("Ana")
+
+	w := postPixKey(api, ana.ID, map[string]interface{}{"type": "cpf", "key": "529.982.247-25"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var key models.PixKey
+	json.Unmarshal(w.Body.Bytes(), &key)
+	assert.Equal(t, "52998224725", key.Key)
+	assert.True(t, key.IsDefault)
+
+	w = postPixKey(api, ana.ID, map[string]interface{}{"type": "email", "key": "Ana@Example.com"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &key)
+	assert.False(t, key.IsDefault)
+
+	keys := listPixKeys(api, t, ana.ID)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, "52998224725", keys[0].Key)
+}
+
+func TestCreatePixKey_Validation(t *testing.T) {
+	api := setupPixKeyTestDB()
+
+	ana, _ := api.createUser("Ana")
+
+	cases := map[string]map[string]interface{}{
+		"invalid CPF":      {"type": "cpf", "key": "529.982.247-26"},
+		"invalid CNPJ":     {"type": "cnpj", "key": "11.222.333/0001-82"},
+		"E.164":            {"type": "phone", "key": "11999998888"},
+		"random key":       {"type": "evp", "key": "nao-e-uuid"},
+		"key type must be": {"type": "iban", "key": "BR18"},
+		"invalid email":    {"type": "email", "key": "ana"},
+	}
+	for message, body := range cases {
+		w := postPixKey(api, ana.ID, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, message)
+		assert.Contains(t, w.Body.String(), message)
+	}
+}
+
+func TestCreatePixKey_UniqueAcrossUsers(t *testing.T) {
+	api := setupPixKeyTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+
+	assert.Equal(t, http.StatusCreated, postPixKey(api, ana.ID, map[string]interface{}{"type": "phone", "key": "+5511999998888"}).Code)
+	assert.Equal(t, http.StatusConflict, postPixKey(api, beto.ID, map[string]interface{}{"type": "phone", "key": "+5511999998888"}).Code)
+	assert.Equal(t, http.StatusConflict, postPixKey(api, ana.ID, map[string]interface{}{"type": "phone", "key": "+5511999998888"}).Code)
+
+	// A mesma chave com outra grafia também é repetida.
+	assert.Equal(t, http.StatusCreated, postPixKey(api, ana.ID, map[string]interface{}{"type": "cnpj", "key": "11222333000181"}).Code)
+	assert.Equal(t, http.StatusConflict, postPixKey(api, beto.ID, map[string]interface{}{"type": "cnpj", "key": "11.222.333/0001-81"}).Code)
+}
+
+func TestPixKey_SingleDefault(t *testing.T) {
+	api := setupPixKeyTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+
+	var first, second, third models.PixKey
+	json.Unmarshal(postPixKey(api, ana.ID, map[string]interface{}{"type": "email", "key": "ana@example.com"}).Body.Bytes(), &first)
+	json.Unmarshal(postPixKey(api, ana.ID, map[string]interface{}{"type": "phone", "key": "+5511999998888", "default": true}).Body.Bytes(), &second)
+	json.Unmarshal(postPixKey(api, ana.ID, map[string]interface{}{"type": "evp", "key": "123e4567-e12b-12d1-a456-426655440000"}).Body.Bytes(), &third)
+
+	defaults := func() []int32 {
+		var ids []int32
+		for _, key := range listPixKeys(api, t, ana.ID) {
+			if key.IsDefault {
+				ids = append(ids, key.ID)
+			}
+		}
+		return ids
+	}
+	assert.Equal(t, []int32{second.ID}, defaults())
+
+	assert.Equal(t, http.StatusOK, pixKeyRequest(api, "POST", "default", ana.ID, third.ID).Code)
+	assert.Equal(t, []int32{third.ID}, defaults())
+
+	assert.Equal(t, http.StatusForbidden, pixKeyRequest(api, "POST", "default", beto.ID, first.ID).Code)
+	assert.Equal(t, http.StatusForbidden, pixKeyRequest(api, "DELETE", "", beto.ID, first.ID).Code)
+	assert.Equal(t, http.StatusNotFound, pixKeyRequest(api, "DELETE", "", ana.ID, 999).Code)
+
+	// Sem a padrão, a mais antiga assume.
+	assert.Equal(t, http.StatusNoContent, pixKeyRequest(api, "DELETE", "", ana.ID, third.ID).Code)
+	assert.Equal(t, []int32{first.ID}, defaults())
+	assert.Len(t, listPixKeys(api, t, ana.ID), 2)
+
+	// O banco também barra uma segunda padrão para o mesmo usuário.
+	err := api.db.Create(&models.PixKey{UserID: ana.ID, Type: "email", Key: "outra@example.com", IsDefault: true}).Error
+	assert.NotNil(t, err)
+
+	var events int64
+	api.db.Model(&models.AuditEvent{}).Where("entity_type = ?", "pix_key").Count(&events)
+	// 3 cadastros, 1 remoção e 4 trocas de padrão.
+	assert.Equal(t, int64(8), events)
+}
@@ -0,0 +1,206 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/pix"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupPixTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func getBillingPix(api *testAPI, userID, billingID int32, rawQuery string) *httptest.ResponseRecorder {
+	return api.request("GET", "/billing/"+strconv.Itoa(int(billingID))+"/pix?"+rawQuery, userID, nil)
+}
+
+func createPixBilling(api *testAPI, t *testing.T) models.Billing {
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto Conceição")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 12050})
+	assert.Equal(t, http.StatusOK, postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 50}).Code)
+	return billing
+}
+
+func TestGetBillingPix_Payload(t *testing.T) {
+	api := setupPixTestDB()
+
+	billing := createPixBilling(api, t)
+
+	w := getBillingPix(api, billing.PayerID, billing.ID, "key=beto@example.com&city=Recife")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var pix response.PixResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &pix))
+	assert.Equal(t, money.Amount(12000), pix.Amount)
+	assert.Equal(t, "MEPAGUE"+strconv.Itoa(int(billing.ID)), pix.TxID)
+	assert.Contains(t, pix.Payload, "0116beto@example.com")
+	assert.Contains(t, pix.Payload, "5406120.00")
+	assert.Contains(t, pix.Payload, "5914Beto Conceicao")
+	assert.Contains(t, pix.Payload, "6006Recife")
+	assert.Contains(t, pix.Payload, "62120508MEPAGUE1")
+}
+
+func TestGetBillingPix_QRCode(t *testing.T) {
+	api := setupPixTestDB()
+
+	billing := createPixBilling(api, t)
+
+	w := getBillingPix(api, billing.ReceiverID, billing.ID, "key=beto@example.com&city=Recife&format=png&scale=2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	_, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	assert.Nil(t, err)
+
+	w = getBillingPix(api, billing.ReceiverID, billing.ID, "key=beto@example.com&city=Recife&format=svg")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "<svg"))
+
+	w = getBillingPix(api, billing.ReceiverID, billing.ID, "key=beto@example.com&city=Recife&format=gif")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetBillingPix_Errors(t *testing.T) {
+	api := setupPixTestDB()
+
+	billing := createPixBilling(api, t)
+	caio, _ := api.createUser("Caio")
+
+	assert.Equal(t, http.StatusForbidden, getBillingPix(api, caio.ID, billing.ID, "key=beto@example.com&city=Recife").Code)
+	assert.Equal(t, http.StatusNotFound, getBillingPix(api, caio.ID, 999, "key=beto@example.com&city=Recife").Code)
+
+	w := getBillingPix(api, billing.PayerID, billing.ID, "city=Recife")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "pix key")
+
+	assert.Equal(t, http.StatusOK, postBalancePayment(api, billing.PayerID, map[string]interface{}{"billing_id": billing.ID, "amount": 12000}).Code)
+	w = getBillingPix(api, billing.PayerID, billing.ID, "key=beto@example.com&city=Recife")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "no outstanding amount")
+}
+
+func billingPixPayload(api *testAPI, t *testing.T, billing models.Billing) string {
+	w := getBillingPix(api, billing.PayerID, billing.ID, "key=beto@example.com&city=Recife")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var pix response.PixResponse
+	json.Unmarshal(w.Body.Bytes(), &pix)
+	return pix.Payload
+}
+
+func TestCreatePayment_PixPayload(t *testing.T) {
+	api := setupPixTestDB()
+
+	billing := createPixBilling(api, t)
+	payload := billingPixPayload(api, t, billing)
+
+	w := postBalancePayment(api, billing.PayerID, map[string]interface{}{"pix_payload": payload})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var payment models.Payment
+	json.Unmarshal(w.Body.Bytes(), &payment)
+	assert.Equal(t, billing.PayerID, payment.PayerID)
+	assert.Equal(t, money.Amount(12000), payment.Amount)
+
+	updated := reloadBilling(api, billing)
+	assert.Equal(t, money.Amount(0), updated.Outstanding)
+}
+
+func TestCreatePayment_PixPayloadMatchesInput(t *testing.T) {
+	api := setupPixTestDB()
+
+	billing := createPixBilling(api, t)
+	payload := billingPixPayload(api, t, billing)
+
+	w := postBalancePayment(api, billing.PayerID, map[string]interface{}{"pix_payload": payload, "billing_id": billing.ID, "amount": 12000})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postBalancePayment(api, billing.PayerID, map[string]interface{}{"pix_payload": payload, "amount": 5000})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "does not match the pix payload amount")
+
+	other, _ := api.getOrCreateBilling(request.BillingInput{PayerID: billing.ReceiverID, ReceiverID: billing.PayerID})
+	w = postBalancePayment(api, billing.PayerID, map[string]interface{}{"pix_payload": payload, "billing_id": other.ID})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "pix payload is for billing")
+
+	w = postBalancePayment(api, billing.PayerID, map[string]interface{}{"pix_payload": payload, "apply_to_net": true})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreatePayment_PixPayloadRejected(t *testing.T) {
+	api := setupPixTestDB()
+
+	billing := createPixBilling(api, t)
+	payload := billingPixPayload(api, t, billing)
+
+	w := postBalancePayment(api, billing.PayerID, map[string]interface{}{"pix_payload": payload[:len(payload)-4] + "0000"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "CRC")
+
+	foreign, _ := pix.Payload{Key: "beto@example.com", MerchantName: "Beto Conceição", MerchantCity: "Recife", Amount: 100}.Encode()
+	w = postBalancePayment(api, billing.PayerID, map[string]interface{}{"pix_payload": foreign})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "does not identify a billing")
+
+	impostor, _ := pix.Payload{Key: "caio@example.com", MerchantName: "Caio", MerchantCity: "Recife", Amount: 100, TxID: "MEPAGUE" + strconv.Itoa(int(billing.ID))}.Encode()
+	w = postBalancePayment(api, billing.PayerID, map[string]interface{}{"pix_payload": impostor})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "does not match the billing receiver")
+
+	caio, _ := api.createUser("Caio")
+	w = postBalancePayment(api, caio.ID, map[string]interface{}{"pix_payload": payload})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestGetBillingPix_DefaultKey(t *testing.T) {
+	api := setupPixTestDB()
+
+	billing := createPixBilling(api, t)
+
+	w := getBillingPix(api, billing.PayerID, billing.ID, "city=Recife")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "no default pix key")
+
+	postPixKey(api, billing.ReceiverID, map[string]interface{}{"type": "email", "key": "beto@example.com"})
+	postPixKey(api, billing.ReceiverID, map[string]interface{}{"type": "phone", "key": "+5581988887777", "default": true})
+
+	w = getBillingPix(api, billing.PayerID, billing.ID, "city=Recife")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "0114+5581988887777")
+}
+
+func TestCreatePayment_PixPayloadRegisteredKey(t *testing.T) {
+	api := setupPixTestDB()
+
+	billing := createPixBilling(api, t)
+	caio, _ := api.createUser("Caio")
+	postPixKey(api, billing.ReceiverID, map[string]interface{}{"type": "email", "key": "beto@example.com"})
+	postPixKey(api, caio.ID, map[string]interface{}{"type": "email", "key": "caio@example.com"})
+	txid := "MEPAGUE" + strconv.Itoa(int(billing.ID))
+
+	// Chave cadastrada do recebedor vale mesmo com outro nome no código.
+	payload, _ := pix.Payload{Key: "beto@example.com", MerchantName: "Loja do Beto", MerchantCity: "Recife", Amount: 100, TxID: txid}.Encode()
+	w := postBalancePayment(api, billing.PayerID, map[string]interface{}{"pix_payload": payload})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Chave de outro usuário é recusada mesmo com o nome do recebedor.
+	payload, _ = pix.Payload{Key: "caio@example.com", MerchantName: "Beto Conceição", MerchantCity: "Recife", Amount: 100, TxID: txid}.Encode()
+	w = postBalancePayment(api, billing.PayerID, map[string]interface{}{"pix_payload": payload})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "belongs to another user")
+}
@@ -0,0 +1,187 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// manualClock é um relógio parado que o teste adianta.
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+func (c *manualClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func setupRecurringTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func postRecurringBilling(api *testAPI, userID int32, body map[string]interface{}) *httptest.ResponseRecorder {
+	return api.request("POST", "/recurring-billings", userID, body)
+}
+
+// recurringRequest chama /recurring-billings/{id}/{action}.
+func recurringRequest(api *testAPI, method, action string, userID, templateID int32) *httptest.ResponseRecorder {
+	return api.request(method, "/recurring-billings/"+strconv.Itoa(int(templateID))+"/"+action, userID, nil)
+}
+
+func createRent(api *testAPI, t *testing.T, body map[string]interface{}) (models.User, models.User, models.RecurringBilling) {
+	inquilino, _ := api.createUser("Inquilino")
+	dono, _ := api.createUser("Dono")
+
+	input := map[string]interface{}{
+		"payer_id": inquilino.ID, "receiver_id": dono.ID, "amount": 150000, "description": "Aluguel",
+		"frequency": "monthly", "start_date": "2026-01-31",
+	}
+	for key, value := range body {
+		input[key] = value
+	}
+
+	w := postRecurringBilling(api, dono.ID, input)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var template models.RecurringBilling
+	json.Unmarshal(w.Body.Bytes(), &template)
+	return inquilino, dono, template
+}
+
+func rentCharges(api *testAPI) []models.Charge {
+	var charges []models.Charge
+	api.db.Order("date").Find(&charges)
+	return charges
+}
+
+func TestRecurringBilling_CreatesChargesOnTime(t *testing.T) {
+	api := setupRecurringTestDB()
+
+	clock := &manualClock{now: time.Date(2026, 1, 30, 12, 0, 0, 0, time.UTC)}
+	inquilino, dono, _ := createRent(api, t, nil)
+
+	created, err := api.jobs.RunRecurringBillings(clock.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, created)
+
+	clock.advance(24 * time.Hour)
+	created, _ = api.jobs.RunRecurringBillings(clock.Now())
+	assert.Equal(t, 1, created)
+
+	created, _ = api.jobs.RunRecurringBillings(clock.Now())
+	assert.Equal(t, 0, created, "o mesmo período não é lançado duas vezes")
+
+	charges := rentCharges(api)
+	assert.Len(t, charges, 1)
+	assert.Equal(t, "2026-01-31", charges[0].Date.Format("2006-01-02"))
+	assert.Equal(t, money.Amount(150000), charges[0].Amount)
+	assert.Equal(t, "Aluguel", charges[0].Description)
+	assert.Equal(t, "2026-01-31", charges[0].DueDate.Format("2006-01-02"), "vence na data do período")
+
+	var billing models.Billing
+	api.db.Where("payer_id = ? AND receiver_id = ?", inquilino.ID, dono.ID).First(&billing)
+	assert.Equal(t, money.Amount(150000), reloadBilling(api, billing).Outstanding)
+}
+
+func TestRecurringBilling_CatchesUpAfterDowntime(t *testing.T) {
+	api := setupRecurringTestDB()
+
+	clock := &manualClock{now: time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC)}
+	_, dono, template := createRent(api, t, map[string]interface{}{"end_date": "2026-06-30"})
+
+	created, _ := api.jobs.RunRecurringBillings(clock.Now())
+	assert.Equal(t, 1, created)
+
+	// Servidor parado de fevereiro até meados de abril.
+	clock.now = time.Date(2026, 4, 15, 8, 0, 0, 0, time.UTC)
+	created, _ = api.jobs.RunRecurringBillings(clock.Now())
+	assert.Equal(t, 2, created)
+
+	var dates []string
+	for _, charge := range rentCharges(api) {
+		dates = append(dates, charge.Date.Format("2006-01-02"))
+	}
+	assert.Equal(t, []string{"2026-01-31", "2026-02-28", "2026-03-31"}, dates)
+
+	// Depois do fim não sai mais nada.
+	clock.now = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	created, _ = api.jobs.RunRecurringBillings(clock.Now())
+	assert.Equal(t, 3, created)
+	created, _ = api.jobs.RunRecurringBillings(clock.Now())
+	assert.Equal(t, 0, created)
+
+	var runs []models.RecurringRun
+	json.Unmarshal(recurringRequest(api, "GET", "runs", dono.ID, template.ID).Body.Bytes(), &runs)
+	assert.Len(t, runs, 6)
+	assert.Equal(t, "2026-06-30", runs[5].PeriodDate.Format("2006-01-02"))
+
+	var stored models.RecurringBilling
+	api.db.First(&stored, template.ID)
+	assert.Equal(t, int32(6), stored.NextPeriod)
+	assert.Nil(t, stored.NextDate)
+}
+
+func TestRecurringBilling_PeriodIsUnique(t *testing.T) {
+	api := setupRecurringTestDB()
+
+	_, _, template := createRent(api, t, nil)
+	api.jobs.RunRecurringBillings(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	// Um segundo agendador com a leitura antiga não consegue repetir o período.
+	err := api.db.Create(&models.RecurringRun{RecurringBillingID: template.ID, Period: 0}).Error
+	assert.NotNil(t, err)
+	assert.Len(t, rentCharges(api), 1)
+}
+
+func TestRecurringBilling_Cancel(t *testing.T) {
+	api := setupRecurringTestDB()
+
+	inquilino, _, template := createRent(api, t, nil)
+	estranho, _ := api.createUser("Estranho")
+
+	assert.Equal(t, http.StatusForbidden, recurringRequest(api, "POST", "cancel", estranho.ID, template.ID).Code)
+	assert.Equal(t, http.StatusOK, recurringRequest(api, "POST", "cancel", inquilino.ID, template.ID).Code)
+
+	created, _ := api.jobs.RunRecurringBillings(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 0, created)
+	assert.Empty(t, rentCharges(api))
+}
+
+func TestCreateRecurringBilling_Validation(t *testing.T) {
+	api := setupRecurringTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	caio, _ := api.createUser("Caio")
+	base := func(changes map[string]interface{}) map[string]interface{} {
+		body := map[string]interface{}{"payer_id": ana.ID, "receiver_id": beto.ID, "amount": 100, "frequency": "weekly", "start_date": "2026-01-01"}
+		for key, value := range changes {
+			body[key] = value
+		}
+		return body
+	}
+
+	cases := map[string]map[string]interface{}{
+		"frequency must be":   base(map[string]interface{}{"frequency": "yearly"}),
+		"start_date must be":  base(map[string]interface{}{"start_date": "01/01/2026"}),
+		"end date must not":   base(map[string]interface{}{"end_date": "2025-12-31"}),
+		"must be different":   base(map[string]interface{}{"receiver_id": ana.ID}),
+		"amount must be":      base(map[string]interface{}{"amount": -5}),
+		"periods in the past": base(map[string]interface{}{"frequency": "daily", "start_date": "2020-01-01"}),
+	}
+	for message, body := range cases {
+		w := postRecurringBilling(api, ana.ID, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, message)
+		assert.Contains(t, w.Body.String(), message)
+	}
+
+	assert.Equal(t, http.StatusForbidden, postRecurringBilling(api, caio.ID, base(nil)).Code)
+	assert.Equal(t, http.StatusCreated, postRecurringBilling(api, ana.ID, base(nil)).Code)
+}
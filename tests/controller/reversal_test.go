@@ -0,0 +1,181 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/ledger"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupReversalTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func reversePayment(api *testAPI, userID, paymentID int32, body map[string]interface{}) *httptest.ResponseRecorder {
+	return api.request("POST", "/payment/"+strconv.Itoa(int(paymentID))+"/reverse", userID, body)
+}
+
+func createReversalFixture(api *testAPI, t *testing.T) (models.Billing, models.Payment) {
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 200})
+
+	w := postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 150})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var payment models.Payment
+	json.Unmarshal(w.Body.Bytes(), &payment)
+	return billing, payment
+}
+
+func TestReversePayment_Full(t *testing.T) {
+	api := setupReversalTestDB()
+
+	billing, payment := createReversalFixture(api, t)
+
+	w := reversePayment(api, billing.ReceiverID, payment.ID, map[string]interface{}{"reason": "Lançado por engano"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var reversal models.PaymentReversal
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reversal))
+	assert.Equal(t, payment.ID, reversal.PaymentID)
+	assert.Equal(t, money.Amount(150), reversal.Amount)
+	assert.Equal(t, "Lançado por engano", reversal.Reason)
+
+	billing, _ = api.getOrCreateBilling(request.BillingInput{PayerID: billing.PayerID, ReceiverID: billing.ReceiverID})
+	assert.Equal(t, money.Amount(0), billing.Amount)
+	assert.Equal(t, money.Amount(0), billing.TotalPaid)
+	assert.Equal(t, money.Amount(200), billing.TotalCharged)
+	assert.Equal(t, money.Amount(200), billing.Outstanding)
+}
+
+func TestReversePayment_RefusesSecondReversal(t *testing.T) {
+	api := setupReversalTestDB()
+
+	billing, payment := createReversalFixture(api, t)
+
+	assert.Equal(t, http.StatusCreated, reversePayment(api, billing.ReceiverID, payment.ID, map[string]interface{}{"reason": "Duplicado"}).Code)
+
+	w := reversePayment(api, billing.ReceiverID, payment.ID, map[string]interface{}{"reason": "Duplicado"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "already been fully reversed")
+}
+
+func TestReversePayment_PartialRefunds(t *testing.T) {
+	api := setupReversalTestDB()
+
+	billing, payment := createReversalFixture(api, t)
+
+	assert.Equal(t, http.StatusCreated, reversePayment(api, billing.ReceiverID, payment.ID, map[string]interface{}{"amount": 50, "reason": "Desconto"}).Code)
+
+	w := reversePayment(api, billing.ReceiverID, payment.ID, map[string]interface{}{"amount": 101, "reason": "Desconto"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "exceeds the 100 left to reverse")
+
+	assert.Equal(t, http.StatusCreated, reversePayment(api, billing.ReceiverID, payment.ID, map[string]interface{}{"amount": 100, "reason": "Resto"}).Code)
+
+	billing, _ = api.getOrCreateBilling(request.BillingInput{PayerID: billing.PayerID, ReceiverID: billing.ReceiverID})
+	assert.Equal(t, money.Amount(0), billing.TotalPaid)
+
+	w = api.request("GET", "/billing/"+strconv.Itoa(int(billing.ID))+"/payments", billing.PayerID, nil)
+
+	var payments []models.Payment
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &payments))
+	assert.Len(t, payments, 1)
+	assert.Equal(t, money.Amount(150), payments[0].Amount)
+	assert.Len(t, payments[0].Reversals, 2)
+}
+
+func TestReversePayment_RequiresReason(t *testing.T) {
+	api := setupReversalTestDB()
+
+	billing, payment := createReversalFixture(api, t)
+
+	w := reversePayment(api, billing.ReceiverID, payment.ID, map[string]interface{}{"amount": 10})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = reversePayment(api, billing.ReceiverID, 999, map[string]interface{}{"reason": "x"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestReversePayment_LedgerStaysBalanced(t *testing.T) {
+	api := setupReversalTestDB()
+
+	billing, payment := createReversalFixture(api, t)
+	reversePayment(api, billing.ReceiverID, payment.ID, map[string]interface{}{"amount": 30, "reason": "Ajuste"})
+
+	report, err := ledger.Check(api.db)
+	assert.Nil(t, err)
+	assert.True(t, report.OK, report.Problems)
+
+	// O razão não é exposto pela API.
+	w := api.request("GET", "/ledger/check", billing.PayerID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestReversePayment_OnlyTheReceiver(t *testing.T) {
+	api := setupReversalTestDB()
+
+	billing, payment := createReversalFixture(api, t)
+
+	w := reversePayment(api, billing.PayerID, payment.ID, map[string]interface{}{"reason": "Desisti"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "only the receiver")
+
+	billing = reloadBilling(api, billing)
+	assert.Equal(t, money.Amount(150), billing.TotalPaid)
+}
+
+func TestReversePayment_ReturnsCreditFirst(t *testing.T) {
+	api := setupReversalTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postCharge(api, beto.ID, billing.ID, map[string]interface{}{"amount": 100})
+
+	w := postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 150})
+	var payment models.Payment
+	json.Unmarshal(w.Body.Bytes(), &payment)
+	assert.Equal(t, money.Amount(50), payment.Credit)
+
+	// Os 30 estornados saem do crédito: o valor em aberto continua zerado.
+	assert.Equal(t, http.StatusCreated, reversePayment(api, beto.ID, payment.ID, map[string]interface{}{"amount": 30, "reason": "Troco"}).Code)
+	billing = reloadBilling(api, billing)
+	assert.Equal(t, money.Amount(20), billing.Credit)
+	assert.Equal(t, money.Amount(0), billing.Outstanding)
+
+	// Os 120 restantes levam os 20 de crédito e reabrem os 100 lançados.
+	assert.Equal(t, http.StatusCreated, reversePayment(api, beto.ID, payment.ID, map[string]interface{}{"reason": "Devolução"}).Code)
+	billing = reloadBilling(api, billing)
+	assert.Equal(t, money.Amount(0), billing.Credit)
+	assert.Equal(t, money.Amount(100), billing.Outstanding)
+
+	w = api.request("GET", "/billing/"+strconv.Itoa(int(billing.ID))+"/payments", ana.ID, nil)
+	var payments []models.Payment
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &payments))
+	if assert.Len(t, payments, 1) {
+		assert.Equal(t, money.Amount(0), payments[0].Credit)
+	}
+
+	var postings []models.Posting
+	api.db.Joins("JOIN journal_entries ON journal_entries.id = postings.entry_id").
+		Where("journal_entries.reference = ?", "reversal:2").Order("postings.id").Find(&postings)
+	if assert.Len(t, postings, 3) {
+		assert.Equal(t, money.Amount(120), postings[0].Amount)
+		assert.Equal(t, money.Amount(20), postings[1].Amount)
+		assert.Equal(t, money.Amount(100), postings[2].Amount)
+	}
+
+	report, err := ledger.Check(api.db)
+	assert.Nil(t, err)
+	assert.True(t, report.OK, report.Problems)
+}
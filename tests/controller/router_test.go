@@ -0,0 +1,223 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/audit"
+	"me-pague/internal/auth"
+	"me-pague/internal/controller"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/db/dbtest"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMemoryRouter monta o roteador sobre repositórios em memória. Cada
+// teste tem o seu banco; o de Deps.DB só guarda as chaves de idempotência.
+func newMemoryRouter(t *testing.T) (*gin.Engine, repository.Repositories) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	repos := repository.NewMemory()
+	return controller.NewRouter(controller.Deps{Repositories: repos, DB: dbtest.New(), Auth: testAuth}), repos
+}
+
+func serve(r *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	req := jsonRequest(method, path, body)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func signUp(t *testing.T, r *gin.Engine, name string) (models.User, string) {
+	w := serve(r, "POST", "/user", "", map[string]interface{}{"name": name, "password": "segredo123"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var user models.User
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &user))
+
+	w = serve(r, "POST", "/auth/login", "", map[string]interface{}{"name": name, "password": "segredo123"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tokens auth.TokenPair
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	return user, tokens.AccessToken
+}
+
+func TestNewRouter_PaymentFlowInMemory(t *testing.T) {
+	r, repos := newMemoryRouter(t)
+
+	ana, anaToken := signUp(t, r, "Ana")
+	beto, _ := signUp(t, r, "Beto")
+
+	billing := models.Billing{PayerID: ana.ID, ReceiverID: beto.ID, Currency: "BRL"}
+	assert.Nil(t, repos.Billings.Create(&billing, audit.System))
+	charge := models.Charge{Amount: 1000, Currency: "BRL"}
+	assert.Nil(t, repos.Billings.AddCharge(&charge, billing, audit.System))
+
+	w := serve(r, "POST", "/payment", anaToken, map[string]interface{}{"billing_id": billing.ID, "amount": "4.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	path := "/billing?payer_id=" + strconv.Itoa(int(ana.ID)) + "&receiver_id=" + strconv.Itoa(int(beto.ID))
+	w = serve(r, "GET", path, anaToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result response.BillingResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, billing.ID, result.ID)
+	assert.Equal(t, money.Amount(400), result.TotalPaid)
+	assert.Equal(t, money.Amount(600), result.Outstanding)
+
+	w = serve(r, "GET", "/user/"+strconv.Itoa(int(beto.ID)), anaToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Beto")
+}
+
+func TestNewRouter_RejectsOutsiders(t *testing.T) {
+	r, repos := newMemoryRouter(t)
+
+	ana, _ := signUp(t, r, "Ana")
+	beto, _ := signUp(t, r, "Beto")
+	_, caioToken := signUp(t, r, "Caio")
+
+	billing := models.Billing{PayerID: ana.ID, ReceiverID: beto.ID, Currency: "BRL"}
+	assert.Nil(t, repos.Billings.Create(&billing, audit.System))
+
+	w := serve(r, "POST", "/payment", caioToken, map[string]interface{}{"billing_id": billing.ID, "amount": 100})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve(r, "POST", "/payment", "", map[string]interface{}{"billing_id": billing.ID, "amount": 100})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve(r, "POST", "/user", "", map[string]interface{}{"name": "Ana", "password": "segredo123"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "User already exists")
+}
+
+func TestNewRouter_RequiresDB(t *testing.T) {
+	assert.PanicsWithValue(t, "controller: Deps.DB is required", func() {
+		controller.NewRouter(controller.Deps{Repositories: repository.NewMemory()})
+	})
+}
+
+func TestNewRouter_PixKeysAndReversalsInMemory(t *testing.T) {
+	r, repos := newMemoryRouter(t)
+
+	ana, anaToken := signUp(t, r, "Ana")
+	beto, betoToken := signUp(t, r, "Beto")
+
+	w := serve(r, "POST", "/pix-keys", betoToken, map[string]interface{}{"type": "email", "key": "beto@example.com"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var first models.PixKey
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &first))
+	assert.True(t, first.IsDefault)
+
+	w = serve(r, "POST", "/pix-keys", betoToken, map[string]interface{}{"type": "email", "key": "beto2@example.com", "default": true})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var second models.PixKey
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &second))
+	w = serve(r, "POST", "/pix-keys", anaToken, map[string]interface{}{"type": "email", "key": "BETO2@example.com"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = serve(r, "GET", "/pix-keys", betoToken, nil)
+	var keys []models.PixKey
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &keys))
+	assert.Equal(t, []int32{second.ID, first.ID}, []int32{keys[0].ID, keys[1].ID})
+	assert.False(t, keys[1].IsDefault)
+
+	w = serve(r, "DELETE", "/pix-keys/"+strconv.Itoa(int(second.ID)), betoToken, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	defaultKey, err := repos.PixKeys.Default(beto.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, first.ID, defaultKey.ID)
+
+	billing := models.Billing{PayerID: ana.ID, ReceiverID: beto.ID, Currency: "BRL"}
+	assert.Nil(t, repos.Billings.Create(&billing, audit.System))
+	charge := models.Charge{Amount: 1000, Currency: "BRL"}
+	assert.Nil(t, repos.Billings.AddCharge(&charge, billing, audit.System))
+
+	w = serve(r, "POST", "/payment", anaToken, map[string]interface{}{"billing_id": billing.ID, "amount": "4.00"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var payment models.Payment
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &payment))
+
+	reverse := "/payment/" + strconv.Itoa(int(payment.ID)) + "/reverse"
+	w = serve(r, "POST", reverse, anaToken, map[string]interface{}{"amount": "1.00", "reason": "engano"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(r, "POST", reverse, betoToken, map[string]interface{}{"amount": "1.00", "reason": "engano"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = serve(r, "GET", "/billing/"+strconv.Itoa(int(billing.ID))+"/payments", anaToken, nil)
+	var payments []models.Payment
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &payments))
+	assert.Len(t, payments, 1)
+	assert.Len(t, payments[0].Reversals, 1)
+
+	stored, err := repos.Billings.Get(billing.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(300), stored.TotalPaid)
+	assert.Equal(t, money.Amount(300), stored.Amount)
+}
+
+func TestNewRouter_GroupsLoansAndRecurringInMemory(t *testing.T) {
+	r, repos := newMemoryRouter(t)
+
+	ana, anaToken := signUp(t, r, "Ana")
+	beto, betoToken := signUp(t, r, "Beto")
+	caio, _ := signUp(t, r, "Caio")
+
+	w := serve(r, "POST", "/group", anaToken, map[string]interface{}{"name": "Viagem", "member_ids": []int32{ana.ID, beto.ID, caio.ID}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var group models.Group
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &group))
+	w = serve(r, "POST", "/group/"+strconv.Itoa(int(group.ID))+"/expense", anaToken,
+		map[string]interface{}{"payer_id": ana.ID, "total": "30.00", "description": "Jantar", "split": "equal"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	billing, err := repos.Billings.Find(beto.ID, ana.ID, "BRL")
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(1000), billing.TotalCharged)
+
+	firstDue := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+	w = serve(r, "POST", "/loans", anaToken, map[string]interface{}{
+		"payer_id": beto.ID, "receiver_id": ana.ID, "principal": "1200.00", "term": 3, "system": "sac", "first_due_date": firstDue,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var loan models.Loan
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &loan))
+	assert.Len(t, loan.Installments, 3)
+	assert.Equal(t, money.Amount(120000), loan.PayoffAmount)
+
+	w = serve(r, "POST", "/loans/"+strconv.Itoa(int(loan.ID))+"/payoff", betoToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &loan))
+	assert.Equal(t, models.LoanPaidOff, loan.Status)
+	assert.Len(t, loan.Installments, 1)
+	billing, err = repos.Billings.Find(beto.ID, ana.ID, "BRL")
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(121000), billing.TotalCharged)
+
+	startDate := time.Now().AddDate(0, 0, 7).Format("2006-01-02")
+	w = serve(r, "POST", "/recurring-billings", anaToken, map[string]interface{}{
+		"payer_id": beto.ID, "receiver_id": ana.ID, "amount": "15.00", "frequency": "monthly", "start_date": startDate,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var template models.RecurringBilling
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &template))
+
+	w = serve(r, "POST", "/recurring-billings/"+strconv.Itoa(int(template.ID))+"/cancel", betoToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(r, "GET", "/recurring-billings", betoToken, nil)
+	var templates []models.RecurringBilling
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &templates))
+	assert.Len(t, templates, 1)
+	assert.NotNil(t, templates[0].CanceledAt)
+	assert.Nil(t, templates[0].NextDate)
+}
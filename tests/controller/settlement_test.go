@@ -0,0 +1,130 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/controller/response"
+	"me-pague/internal/money"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSettlementTestDB() *testAPI {
+	return newTestAPI(directPayments)
+}
+
+func getSettlementPlan(api *testAPI, userID int32, rawQuery string) *httptest.ResponseRecorder {
+	return api.request("GET", "/settlements/plan?"+rawQuery, userID, nil)
+}
+
+func TestGetSettlementPlan_Global(t *testing.T) {
+	api := setupSettlementTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	caio, _ := api.createUser("Caio")
+
+	b1, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	b2, _ := api.getOrCreateBilling(request.BillingInput{PayerID: beto.ID, ReceiverID: caio.ID})
+	postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": b1.ID, "amount": 100})
+	postBalancePayment(api, beto.ID, map[string]interface{}{"billing_id": b2.ID, "amount": 100})
+
+	w := getSettlementPlan(api, ana.ID, "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var plan response.SettlementPlanResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &plan))
+	assert.Len(t, plan.Transfers, 1)
+	assert.Equal(t, beto.ID, plan.Transfers[0].From)
+	assert.Equal(t, ana.ID, plan.Transfers[0].To)
+	assert.Equal(t, money.Amount(100), plan.Transfers[0].Amount)
+	for _, balance := range plan.Balances {
+		assert.NotEqual(t, caio.ID, balance.UserID)
+	}
+}
+
+func TestGetSettlementPlan_WithoutGroupHidesOtherUsersBillings(t *testing.T) {
+	api := setupSettlementTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	caio, _ := api.createUser("Caio")
+
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 100})
+
+	w := getSettlementPlan(api, caio.ID, "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var plan response.SettlementPlanResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &plan))
+	assert.Empty(t, plan.Balances)
+	assert.Empty(t, plan.Transfers)
+}
+
+func TestGetSettlementPlan_WithoutAuthentication(t *testing.T) {
+	api := setupSettlementTestDB()
+
+	w := api.request("GET", "/settlements/plan", 0, nil)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestGetSettlementPlan_GroupForbiddenForNonMember(t *testing.T) {
+	api := setupSettlementTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	caio, _ := api.createUser("Caio")
+	group := createTestGroup(api, t, ana.ID, beto.ID)
+
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 40})
+
+	w := getSettlementPlan(api, caio.ID, "group_id="+strconv.Itoa(int(group.ID)))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "not a member")
+	assert.NotContains(t, w.Body.String(), "transfers")
+}
+
+func TestGetSettlementPlan_GroupScope(t *testing.T) {
+	api := setupSettlementTestDB()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	caio, _ := api.createUser("Caio")
+	group := createTestGroup(api, t, ana.ID, beto.ID)
+
+	inGroup, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	outside, _ := api.getOrCreateBilling(request.BillingInput{PayerID: caio.ID, ReceiverID: ana.ID})
+	postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": inGroup.ID, "amount": 40})
+	postBalancePayment(api, caio.ID, map[string]interface{}{"billing_id": outside.ID, "amount": 500})
+
+	w := getSettlementPlan(api, ana.ID, "group_id="+strconv.Itoa(int(group.ID)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var plan response.SettlementPlanResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &plan))
+	assert.Equal(t, group.ID, plan.GroupID)
+	assert.Len(t, plan.Transfers, 1)
+	assert.Equal(t, beto.ID, plan.Transfers[0].From)
+	assert.Equal(t, ana.ID, plan.Transfers[0].To)
+	assert.Equal(t, money.Amount(40), plan.Transfers[0].Amount)
+}
+
+func TestGetSettlementPlan_GroupNotFound(t *testing.T) {
+	api := setupSettlementTestDB()
+
+	w := getSettlementPlan(api, 1, "group_id=42")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Group not found")
+}
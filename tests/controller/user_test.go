@@ -1,43 +1,26 @@
 package controller_test
 
 import (
-	"bytes"
 	"encoding/json"
-	"me-pague/internal/controller"
-	"me-pague/internal/db"
 	"me-pague/internal/models"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 
-	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
-func setupUserTestDB() {
-	testDB, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	testDB.AutoMigrate(&models.User{})
-	db.DB = testDB
+func setupUserTestDB() *testAPI {
+	return newTestAPI(directPayments)
 }
 
 func TestCreateUser_Success(t *testing.T) {
-	setupUserTestDB()
-	gin.SetMode(gin.TestMode)
+	api := setupUserTestDB()
 
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
-
-	body := map[string]string{"name": "Antonio"}
-	jsonBody, _ := json.Marshal(body)
-
-	req := httptest.NewRequest("POST", "/user", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	c.Request = req
-
-	controller.CreateUser(c)
+	body := map[string]string{"name": "Antonio", "password": "segredo123"}
+	w := api.request("POST", "/user", 0, body)
 
 	assert.Equal(t, http.StatusCreated, w.Code)
 
@@ -48,60 +31,34 @@ func TestCreateUser_Success(t *testing.T) {
 }
 
 func TestCreateUser_AlreadyExists(t *testing.T) {
-	setupUserTestDB()
-	gin.SetMode(gin.TestMode)
+	api := setupUserTestDB()
 
-	_, err := controller.CreateUserHandler("Antonio")
+	_, err := api.createUser("Antonio")
 	assert.Nil(t, err)
 
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
-
-	body := map[string]string{"name": "Antonio"}
-	jsonBody, _ := json.Marshal(body)
-
-	req := httptest.NewRequest("POST", "/user", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	c.Request = req
-
-	controller.CreateUser(c)
+	body := map[string]string{"name": "Antonio", "password": "segredo123"}
+	w := api.request("POST", "/user", 0, body)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "User already exists")
 }
 
 func TestCreateUser_InvalidInput(t *testing.T) {
-	setupUserTestDB()
-	gin.SetMode(gin.TestMode)
-
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
+	api := setupUserTestDB()
 
-	req := httptest.NewRequest("POST", "/user", bytes.NewBuffer([]byte(`{invalid json}`)))
-	req.Header.Set("Content-Type", "application/json")
-	c.Request = req
-
-	controller.CreateUser(c)
+	w := api.send(httptest.NewRequest("POST", "/user", strings.NewReader(`{invalid json}`)), 0)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "Invalid input")
 }
 
 func TestGetUser_Success(t *testing.T) {
-	setupUserTestDB()
-	gin.SetMode(gin.TestMode)
+	api := setupUserTestDB()
 
-	user, err := controller.CreateUserHandler("Davi")
+	user, err := api.createUser("Davi")
 	assert.Nil(t, err)
 
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
-
-	c.Params = []gin.Param{{Key: "id", Value: strconv.Itoa(int(user.ID))}}
-	req := httptest.NewRequest("GET", "/user/"+strconv.Itoa(int(user.ID)), nil)
-	c.Request = req
-
-	controller.GetUser(c)
+	w := api.request("GET", "/user/"+strconv.Itoa(int(user.ID)), user.ID, nil)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
@@ -113,34 +70,20 @@ func TestGetUser_Success(t *testing.T) {
 }
 
 func TestGetUser_NotFound(t *testing.T) {
-	setupUserTestDB()
-	gin.SetMode(gin.TestMode)
-
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
+	api := setupUserTestDB()
 
-	c.Params = []gin.Param{{Key: "id", Value: "999"}}
-	req := httptest.NewRequest("GET", "/user/999", nil)
-	c.Request = req
-
-	controller.GetUser(c)
+	user, _ := api.createUser("Davi")
+	w := api.request("GET", "/user/999", user.ID, nil)
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Contains(t, w.Body.String(), "User not found")
 }
 
 func TestGetUser_InvalidID(t *testing.T) {
-	setupUserTestDB()
-	gin.SetMode(gin.TestMode)
-
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
-
-	c.Params = []gin.Param{{Key: "id", Value: "abc"}}
-	req := httptest.NewRequest("GET", "/user/abc", nil)
-	c.Request = req
+	api := setupUserTestDB()
 
-	controller.GetUser(c)
+	user, _ := api.createUser("Davi")
+	w := api.request("GET", "/user/abc", user.ID, nil)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "Invalid user ID")
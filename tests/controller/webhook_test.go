@@ -0,0 +1,153 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"io"
+	"me-pague/internal/audit"
+	"me-pague/internal/controller"
+	"me-pague/internal/controller/request"
+	"me-pague/internal/models"
+	"me-pague/internal/webhook"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testWebhooks aceita assinantes em 127.0.0.1, onde ficam os servidores
+// httptest dos testes.
+var testWebhooks = webhook.Options{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour, Timeout: time.Second, BatchSize: 10, AllowPrivateNetworks: true}
+
+func setupWebhookTestDB() *testAPI {
+	return newTestAPI(confirmedPayments, func(deps *controller.Deps) { deps.Webhooks = testWebhooks })
+}
+
+// setupPublicWebhookTestDB só aceita assinantes em endereços públicos.
+func setupPublicWebhookTestDB() *testAPI {
+	return newTestAPI(confirmedPayments, func(deps *controller.Deps) {
+		deps.Webhooks = testWebhooks
+		deps.Webhooks.AllowPrivateNetworks = false
+	})
+}
+
+func postWebhook(api *testAPI, userID int32, body map[string]interface{}) *httptest.ResponseRecorder {
+	return api.request("POST", "/webhooks", userID, body)
+}
+
+func TestCreateWebhook_Validation(t *testing.T) {
+	api := setupPublicWebhookTestDB()
+
+	ana, _ := api.createUser("Ana")
+	valid := map[string]interface{}{"url": "https://203.0.113.10/hook", "secret": "segredo-muito-longo", "event_types": []string{"payment.created"}}
+
+	w := postWebhook(api, ana.ID, valid)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, w.Body.String(), "segredo-muito-longo")
+
+	cases := map[string]map[string]interface{}{
+		"url must be an absolute":   {"url": "ftp://bot", "secret": "segredo-muito-longo", "event_types": []string{"payment.created"}},
+		"secret must have at least": {"url": "https://bot", "secret": "curto", "event_types": []string{"payment.created"}},
+		"unknown event type":        {"url": "https://bot", "secret": "segredo-muito-longo", "event_types": []string{"user.x"}},
+		"at least one event type":   {"url": "https://bot", "secret": "segredo-muito-longo", "event_types": []string{}},
+	}
+	for message, body := range cases {
+		w := postWebhook(api, ana.ID, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, message)
+		assert.Contains(t, w.Body.String(), message)
+	}
+}
+
+func TestCreateWebhook_RejectsInternalAddresses(t *testing.T) {
+	api := setupPublicWebhookTestDB()
+
+	ana, _ := api.createUser("Ana")
+	urls := []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.5/hook",
+		"http://172.16.3.4/hook",
+		"https://192.168.0.10/hook",
+		"http://[fd00::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hook",
+		"http://0.0.0.0:9000/hook",
+		"http://[::]/hook",
+	}
+	for _, url := range urls {
+		w := postWebhook(api, ana.ID, map[string]interface{}{"url": url, "secret": "segredo-muito-longo", "event_types": []string{"payment.created"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+		assert.Contains(t, w.Body.String(), webhook.ErrForbiddenAddress.Error(), url)
+	}
+
+	var count int64
+	api.db.Model(&models.WebhookSubscription{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestWebhook_PaymentIsDeliveredToParties(t *testing.T) {
+	api := setupWebhookTestDB()
+
+	var received [][]byte
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	ana, _ := api.createUser("Ana")
+	beto, _ := api.createUser("Beto")
+	caio, _ := api.createUser("Caio")
+
+	w := postWebhook(api, beto.ID, map[string]interface{}{"url": server.URL, "secret": "segredo-muito-longo", "event_types": []string{"payment.created", "billing.created"}})
+	var subscription models.WebhookSubscription
+	json.Unmarshal(w.Body.Bytes(), &subscription)
+	postWebhook(api, caio.ID, map[string]interface{}{"url": server.URL, "secret": "segredo-muito-longo", "event_types": []string{"payment.created"}})
+
+	billing, _ := api.getOrCreateBilling(request.BillingInput{PayerID: ana.ID, ReceiverID: beto.ID})
+	assert.Equal(t, http.StatusOK, postBalancePayment(api, ana.ID, map[string]interface{}{"billing_id": billing.ID, "amount": 40}).Code)
+
+	testWebhooks.DeliverDue(api.db, time.Now())
+	assert.Len(t, received, 2)
+	assert.Contains(t, string(received[0]), `"type":"billing.created"`)
+	assert.Contains(t, string(received[1]), `"type":"payment.created"`)
+	assert.Contains(t, string(received[1]), `"billing_id":`+strconv.Itoa(int(billing.ID)))
+
+	path := "/webhooks/" + strconv.Itoa(int(subscription.ID)) + "/deliveries"
+	w = api.request("GET", path, beto.ID, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var deliveries []models.WebhookDelivery
+	json.Unmarshal(w.Body.Bytes(), &deliveries)
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, models.DeliveryPending, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+
+	assert.Equal(t, http.StatusForbidden, api.request("GET", path, ana.ID, nil).Code)
+
+	status = http.StatusNoContent
+	redeliver := path + "/" + strconv.Itoa(int(deliveries[0].ID)) + "/redeliver"
+	w = api.request("POST", redeliver, beto.ID, nil)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+	assert.Len(t, received, 2)
+
+	events := decodeAuditEvents(t, listAuditEvents(api, beto.ID, "entity_type=webhook_delivery&entity_id="+strconv.Itoa(int(deliveries[0].ID))))
+	assert.Len(t, events, 1)
+	assert.Equal(t, beto.ID, events[0].ActorID)
+	assert.Equal(t, audit.Update, events[0].Action)
+	assert.Contains(t, string(events[0].Before), `"attempts":1`)
+	assert.Contains(t, string(events[0].After), `"attempts":0`)
+
+	testWebhooks.DeliverDue(api.db, time.Now())
+	assert.Len(t, received, 3)
+	w = api.request("GET", path, beto.ID, nil)
+	json.Unmarshal(w.Body.Bytes(), &deliveries)
+	assert.Equal(t, models.DeliveryDelivered, deliveries[0].Status)
+
+	assert.Equal(t, http.StatusForbidden, api.request("POST", redeliver, caio.ID, nil).Code)
+}
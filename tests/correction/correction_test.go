@@ -0,0 +1,115 @@
+package correction_test
+
+import (
+	"me-pague/internal/correction"
+	"me-pague/internal/money"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const rates = `index,month,rate
+IPCA,2024-01,0.42
+IPCA,2024-02,0.83
+IPCA,2024-03,0.16
+IPCA,2024-04,0.38
+selic,2024-02,0.80
+`
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func loadRates(t *testing.T) *correction.Table {
+	table, err := correction.LoadCSV(strings.NewReader(rates))
+	assert.Nil(t, err)
+	return table
+}
+
+func TestLoadCSV(t *testing.T) {
+	table := loadRates(t)
+	assert.Equal(t, []string{"IPCA", "SELIC"}, table.Names())
+	assert.True(t, table.Has("ipca"))
+	assert.False(t, table.Has("IGPM"))
+}
+
+func TestLoadCSV_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"header":    "name,month,value\nIPCA,2024-01,0.42\n",
+		"month":     "index,month,rate\nIPCA,01/2024,0.42\n",
+		"rate":      "index,month,rate\nIPCA,2024-01,0,42\n",
+		"fraction":  "index,month,rate\nIPCA,2024-01,1/2\n",
+		"duplicate": "index,month,rate\nIPCA,2024-01,0.42\nipca,2024-01,0.50\n",
+		"total":     "index,month,rate\nIPCA,2024-01,-100\n",
+		"empty":     "",
+	}
+	for name, csv := range cases {
+		_, err := correction.LoadCSV(strings.NewReader(csv))
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestCorrect_MonthByMonth(t *testing.T) {
+	entries := []correction.Entry{{Date: date("2024-01-10"), Amount: 100000}}
+
+	result, err := correction.Correct(loadRates(t), "ipca", entries, date("2024-04-05"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "IPCA", result.Index)
+	assert.Equal(t, []correction.Month{
+		{Month: "2024-01", Charges: 100000, Closing: 100000},
+		{Month: "2024-02", Opening: 100000, Rate: "0.83", Correction: 830, Closing: 100830},
+		{Month: "2024-03", Opening: 100830, Rate: "0.16", Correction: 161, Closing: 100991},
+		{Month: "2024-04", Opening: 100991, Closing: 100991},
+	}, result.Months)
+	assert.Equal(t, money.Amount(100000), result.Original)
+	assert.Equal(t, money.Amount(991), result.Correction)
+	assert.Equal(t, money.Amount(100991), result.Corrected)
+}
+
+func TestCorrect_PaymentsAndLaterEntries(t *testing.T) {
+	entries := []correction.Entry{
+		{Date: date("2024-01-10"), Amount: 100000},
+		{Date: date("2024-02-20"), Amount: -50000},
+		{Date: date("2024-05-01"), Amount: 99999},
+	}
+
+	result, err := correction.Correct(loadRates(t), "IPCA", entries, date("2024-04-05"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(50000), result.Months[1].Payments)
+	assert.Equal(t, money.Amount(50830), result.Months[1].Closing)
+	assert.Equal(t, money.Amount(81), result.Months[2].Correction)
+	assert.Equal(t, money.Amount(50000), result.Original)
+	assert.Equal(t, money.Amount(50911), result.Corrected)
+}
+
+func TestCorrect_NegativeBalanceRoundsAwayFromZero(t *testing.T) {
+	table := correction.NewTable()
+	table.Set("IPCA", "2024-02", "0.05")
+	entries := []correction.Entry{{Date: date("2024-01-10"), Amount: -1000}}
+
+	result, err := correction.Correct(table, "IPCA", entries, date("2024-03-01"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(-1), result.Correction)
+	assert.Equal(t, money.Amount(-1001), result.Corrected)
+}
+
+func TestCorrect_Errors(t *testing.T) {
+	entries := []correction.Entry{{Date: date("2024-01-10"), Amount: 100000}}
+
+	_, err := correction.Correct(loadRates(t), "IGPM", entries, date("2024-04-05"))
+	assert.EqualError(t, err, "no rates loaded for index IGPM")
+
+	_, err = correction.Correct(loadRates(t), "IPCA", entries, date("2024-06-01"))
+	assert.EqualError(t, err, "no IPCA rate for 2024-05")
+
+	result, err := correction.Correct(loadRates(t), "IPCA", nil, date("2024-06-01"))
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(0), result.Corrected)
+	assert.Empty(t, result.Months)
+}
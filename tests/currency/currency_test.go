@@ -0,0 +1,116 @@
+package currency_test
+
+import (
+	"math/big"
+	"me-pague/internal/currency"
+	"me-pague/internal/money"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const table = `date,base,quote,rate
+2024-07-01,USD,BRL,5.5561
+2024-07-05,usd,brl,5.4700
+2024-07-01,EUR,BRL,5.9500
+2024-07-01,JPY,BRL,0.0347
+`
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func loadRates(t *testing.T) *currency.Rates {
+	rates, err := currency.LoadRates(strings.NewReader(table))
+	assert.Nil(t, err)
+	return rates
+}
+
+func TestNormalize(t *testing.T) {
+	code, err := currency.Normalize("")
+	assert.Nil(t, err)
+	assert.Equal(t, currency.Default, code)
+
+	code, err = currency.Normalize(" usd ")
+	assert.Nil(t, err)
+	assert.Equal(t, "USD", code)
+
+	_, err = currency.Normalize("XYZ")
+	assert.NotNil(t, err)
+}
+
+func TestLoadRates_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"header":    "day,from,to,value\n2024-07-01,USD,BRL,5.5\n",
+		"date":      "date,base,quote,rate\n01/07/2024,USD,BRL,5.5\n",
+		"currency":  "date,base,quote,rate\n2024-07-01,USD,XYZ,5.5\n",
+		"same":      "date,base,quote,rate\n2024-07-01,USD,USD,1\n",
+		"rate":      "date,base,quote,rate\n2024-07-01,USD,BRL,5,5\n",
+		"zero":      "date,base,quote,rate\n2024-07-01,USD,BRL,0\n",
+		"duplicate": "date,base,quote,rate\n2024-07-01,USD,BRL,5.5\n2024-07-01,USD,BRL,5.6\n",
+	}
+	for name, csv := range cases {
+		_, err := currency.LoadRates(strings.NewReader(csv))
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestRate_UsesLatestOnOrBeforeDate(t *testing.T) {
+	rates := loadRates(t)
+
+	rate, err := rates.Rate("USD", "BRL", date("2024-07-04"))
+	assert.Nil(t, err)
+	assert.Equal(t, "5.5561", rate.Text)
+	assert.Equal(t, date("2024-07-01"), rate.Date)
+
+	rate, err = rates.Rate("USD", "BRL", date("2024-07-06"))
+	assert.Nil(t, err)
+	assert.Equal(t, "5.4700", rate.Text)
+
+	_, err = rates.Rate("USD", "BRL", date("2024-06-30"))
+	assert.EqualError(t, err, "no USD/BRL exchange rate for 2024-06-30")
+
+	_, err = rates.Rate("EUR", "BRL", date("2024-07-09"))
+	assert.NotNil(t, err, "a cotação tem mais de MaxRateAge dias")
+}
+
+func TestRate_Inverse(t *testing.T) {
+	rate, err := loadRates(t).Rate("BRL", "EUR", date("2024-07-02"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "BRL", rate.Base)
+	assert.Equal(t, "EUR", rate.Quote)
+	assert.Equal(t, "0.1680672269", rate.Text)
+	assert.Equal(t, big.NewRat(100, 595), rate.Value)
+}
+
+func TestConvert(t *testing.T) {
+	rates := loadRates(t)
+
+	// US$ 10,00 a 5,5561: R$ 55,561, arredondado para R$ 55,56.
+	conversion, err := currency.Convert(rates, 1000, "USD", "BRL", date("2024-07-01"))
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(5556), conversion.Converted)
+	assert.Equal(t, "-0.1", currency.Decimal(conversion.Rounding, 6))
+
+	// O iene não tem centavos: ¥ 1.000 a 0,0347 são R$ 34,70.
+	conversion, err = currency.Convert(rates, 1000, "JPY", "BRL", date("2024-07-01"))
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(3470), conversion.Converted)
+	assert.Equal(t, "0", currency.Decimal(conversion.Rounding, 6))
+
+	// R$ 100,00 em euros: 10000 / 5,95 = 1680,67 centavos.
+	conversion, err = currency.Convert(rates, 10000, "BRL", "EUR", date("2024-07-01"))
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(1681), conversion.Converted)
+	assert.Equal(t, "0.327731", currency.Decimal(conversion.Rounding, 6))
+
+	// Metade arredonda para longe do zero: ¥ 50 são 173,5 centavos.
+	conversion, err = currency.Convert(rates, 50, "JPY", "BRL", date("2024-07-01"))
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(174), conversion.Converted)
+	assert.Equal(t, "0.5", currency.Decimal(conversion.Rounding, 6))
+}
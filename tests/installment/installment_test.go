@@ -0,0 +1,61 @@
+package installment_test
+
+import (
+	"me-pague/internal/installment"
+	"me-pague/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestSplit_SpreadsCentsAndDueDates(t *testing.T) {
+	items, err := installment.Split(10000, 3, date("2026-01-31"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []money.Amount{3334, 3333, 3333}, []money.Amount{items[0].Amount, items[1].Amount, items[2].Amount})
+	assert.Equal(t, date("2026-02-28"), items[1].DueDate)
+	assert.Equal(t, date("2026-03-31"), items[2].DueDate)
+	assert.Equal(t, 3, items[2].Number)
+}
+
+func TestSplit_Validation(t *testing.T) {
+	_, err := installment.Split(0, 3, date("2026-01-01"))
+	assert.NotNil(t, err)
+	_, err = installment.Split(100, 0, date("2026-01-01"))
+	assert.NotNil(t, err)
+	_, err = installment.Split(100, installment.MaxCount+1, date("2026-01-01"))
+	assert.NotNil(t, err)
+	_, err = installment.Split(2, 3, date("2026-01-01"))
+	assert.NotNil(t, err)
+}
+
+func TestAllocate_OldestFirst(t *testing.T) {
+	items, _ := installment.Split(3000, 3, date("2026-01-10"))
+
+	installment.Allocate(items, 1500, date("2026-01-05"))
+	assert.Equal(t, []money.Amount{1000, 500, 0}, []money.Amount{items[0].Paid, items[1].Paid, items[2].Paid})
+	assert.Equal(t, []string{installment.Paid, installment.Partial, installment.Open},
+		[]string{items[0].Status, items[1].Status, items[2].Status})
+
+	// Em março a segunda venceu sem ser quitada.
+	installment.Allocate(items, 1500, date("2026-03-01"))
+	assert.Equal(t, []string{installment.Paid, installment.Overdue, installment.Open},
+		[]string{items[0].Status, items[1].Status, items[2].Status})
+
+	// No dia do vencimento ainda não está atrasada.
+	installment.Allocate(items, 0, date("2026-01-10"))
+	assert.Equal(t, installment.Open, items[0].Status)
+
+	installment.Allocate(items, 5000, date("2027-01-01"))
+	assert.Equal(t, []string{installment.Paid, installment.Paid, installment.Paid},
+		[]string{items[0].Status, items[1].Status, items[2].Status})
+
+	installment.Allocate(items, -100, date("2026-01-01"))
+	assert.Equal(t, money.Amount(0), items[0].Paid)
+}
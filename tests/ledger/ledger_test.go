@@ -0,0 +1,116 @@
+package ledger_test
+
+import (
+	"me-pague/internal/db/dbtest"
+	"me-pague/internal/ledger"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupLedgerTestDB() *gorm.DB {
+	return dbtest.New()
+}
+
+func TestPost_RejectsUnbalancedEntry(t *testing.T) {
+	testDB := setupLedgerTestDB()
+
+	_, err := ledger.Post(testDB, "test", "ref", "",
+		ledger.Line{Account: "a", Side: ledger.Debit, Amount: 100},
+		ledger.Line{Account: "b", Side: ledger.Credit, Amount: 90},
+	)
+	assert.NotNil(t, err)
+
+	_, err = ledger.Post(testDB, "test", "ref", "",
+		ledger.Line{Account: "a", Side: ledger.Debit, Amount: 100},
+	)
+	assert.NotNil(t, err)
+
+	var count int64
+	testDB.Model(&models.JournalEntry{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestPost_ProjectionMatchesPostings(t *testing.T) {
+	testDB := setupLedgerTestDB()
+
+	billing := models.Billing{ID: 7, PayerID: 1, ReceiverID: 2}
+	assert.Nil(t, ledger.RecordCharge(testDB, billing, models.Charge{ID: 1, Amount: 300}))
+	assert.Nil(t, ledger.RecordCharge(testDB, billing, models.Charge{ID: 2, Amount: 200}))
+	assert.Nil(t, ledger.RecordPayment(testDB, billing, models.Payment{ID: 1, Amount: 150}))
+
+	fromPostings, err := ledger.Balance(testDB, ledger.BillingAccount(7))
+	assert.Nil(t, err)
+	assert.Equal(t, ledger.Totals{Debit: 500, Credit: 150}, fromPostings)
+
+	projected, err := ledger.Balances(testDB, []string{ledger.BillingAccount(7), ledger.UserAccount(2)})
+	assert.Nil(t, err)
+	assert.Equal(t, fromPostings, projected[ledger.BillingAccount(7)])
+	assert.Equal(t, ledger.Totals{Debit: 150, Credit: 500}, projected[ledger.UserAccount(2)])
+
+	report, err := ledger.Check(testDB)
+	assert.Nil(t, err)
+	assert.True(t, report.OK)
+	assert.Equal(t, money.Amount(650), report.TotalDebits)
+	assert.Equal(t, money.Amount(650), report.TotalCredits)
+	assert.Equal(t, int64(3), report.Entries)
+}
+
+func TestPostings_AreAppendOnly(t *testing.T) {
+	testDB := setupLedgerTestDB()
+
+	entry, err := ledger.Post(testDB, "test", "ref", "",
+		ledger.Line{Account: "a", Side: ledger.Debit, Amount: 100},
+		ledger.Line{Account: "b", Side: ledger.Credit, Amount: 100},
+	)
+	assert.Nil(t, err)
+
+	posting := entry.Postings[0]
+	posting.Amount = 1
+	assert.ErrorIs(t, testDB.Save(&posting).Error, models.ErrAppendOnly)
+	assert.ErrorIs(t, testDB.Delete(&entry).Error, models.ErrAppendOnly)
+}
+
+func TestCheck_DetectsProjectionDrift(t *testing.T) {
+	testDB := setupLedgerTestDB()
+
+	_, err := ledger.Post(testDB, "test", "ref", "",
+		ledger.Line{Account: "a", Side: ledger.Debit, Amount: 100},
+		ledger.Line{Account: "b", Side: ledger.Credit, Amount: 100},
+	)
+	assert.Nil(t, err)
+
+	testDB.Exec("UPDATE account_balances SET debit = debit + 5")
+
+	report, err := ledger.Check(testDB)
+	assert.Nil(t, err)
+	assert.False(t, report.OK)
+	assert.Len(t, report.Problems, 2)
+}
+
+func TestCheck_ComparesBillingsWithTheLedger(t *testing.T) {
+	testDB := setupLedgerTestDB()
+
+	billing := models.Billing{PayerID: 1, ReceiverID: 2, Amount: 150}
+	assert.Nil(t, testDB.Create(&billing).Error)
+	assert.Nil(t, testDB.Create(&models.Charge{BillingID: billing.ID, Amount: 300}).Error)
+
+	report, err := ledger.Check(testDB)
+	assert.Nil(t, err)
+	assert.False(t, report.OK)
+	assert.Equal(t, []string{
+		"billing 1 stored paid amount 150 differs from ledger 0",
+		"billing 1 charges 300 differ from ledger 0",
+	}, report.Problems)
+
+	assert.Nil(t, ledger.RecordCharge(testDB, billing, models.Charge{ID: 1, Amount: 300}))
+	assert.Nil(t, ledger.RecordPayment(testDB, billing, models.Payment{ID: 1, Amount: 200}))
+	assert.Nil(t, ledger.RecordReversal(testDB, billing, models.PaymentReversal{ID: 1, Amount: 50}, 0))
+
+	report, err = ledger.Check(testDB)
+	assert.Nil(t, err)
+	assert.True(t, report.OK, report.Problems)
+}
@@ -0,0 +1,57 @@
+package middleware_test
+
+import (
+	"me-pague/internal/auth"
+	"me-pague/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// testAuth assina os tokens dos testes.
+var testAuth = auth.Options{Secret: []byte("segredo-de-teste"), AccessTTL: 15 * time.Minute, RefreshTTL: time.Hour}
+
+func setupAuthRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/me", middleware.Auth(testAuth), func(c *gin.Context) {
+		userID, _ := auth.UserID(c)
+		c.String(http.StatusOK, strconv.Itoa(int(userID)))
+	})
+	return r
+}
+
+func getWithToken(r *gin.Engine, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/me", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth_RequiresBearerToken(t *testing.T) {
+	r := setupAuthRouter()
+
+	assert.Equal(t, http.StatusUnauthorized, getWithToken(r, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, getWithToken(r, "Basic YW5hOnNlZ3JlZG8=").Code)
+	assert.Equal(t, http.StatusUnauthorized, getWithToken(r, "Bearer lixo").Code)
+}
+
+func TestAuth_AcceptsAccessTokenOnly(t *testing.T) {
+	r := setupAuthRouter()
+	pair, _ := testAuth.IssuePair(42, time.Now())
+
+	w := getWithToken(r, "Bearer "+pair.AccessToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "42", w.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, getWithToken(r, "Bearer "+pair.RefreshToken).Code)
+}
@@ -0,0 +1,179 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"me-pague/internal/audit"
+	"me-pague/internal/auth"
+	"me-pague/internal/controller"
+	"me-pague/internal/db/dbtest"
+	"me-pague/internal/middleware"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/repository"
+	"me-pague/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// idempotencyTest é um banco em memória novo e os handlers sobre ele.
+type idempotencyTest struct {
+	db       *gorm.DB
+	repos    repository.Repositories
+	handlers *controller.Handlers
+}
+
+func setupIdempotencyTestDB() *idempotencyTest {
+	database := dbtest.New()
+	deps := controller.Deps{
+		Repositories: repository.NewGorm(database),
+		DB:           database,
+		Payments:     controller.PaymentOptions{PendingTTL: time.Hour},
+	}
+	return &idempotencyTest{db: database, repos: deps.Repositories, handlers: controller.NewHandlers(deps)}
+}
+
+// setupIdempotencyRouter monta um roteador em que todas as requisições
+// chegam como o pagador criado em createTestBilling.
+func (it *idempotencyTest) setupIdempotencyRouter(retention time.Duration) *gin.Engine {
+	return it.setupIdempotencyRouterAs(retention, 1)
+}
+
+func (it *idempotencyTest) setupIdempotencyRouterAs(retention time.Duration, userID int32) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { auth.SetUserID(c, userID) })
+	r.POST("/payment", middleware.Idempotency(it.db, retention), it.handlers.CreatePayment)
+	return r
+}
+
+func postPaymentWithKey(r *gin.Engine, key string, body map[string]interface{}) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/payment", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyHeader, key)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func (it *idempotencyTest) createUser(name string) models.User {
+	user := models.User{Name: name}
+	it.repos.Users.Create(&user, audit.System)
+	return user
+}
+
+func (it *idempotencyTest) createTestBilling() models.Billing {
+	user1 := it.createUser("Ana")
+	user2 := it.createUser("Beto")
+	billings := service.BillingService{Users: it.repos.Users, Billings: it.repos.Billings, Payments: it.repos.Payments}
+	billing, _ := billings.GetOrCreate(service.BillingKey{PayerID: user1.ID, ReceiverID: user2.ID}, audit.System)
+	return billing
+}
+
+func (it *idempotencyTest) countPayments() int64 {
+	var count int64
+	it.db.Model(&models.Payment{}).Count(&count)
+	return count
+}
+
+func TestIdempotency_ReplayReturnsOriginalResponse(t *testing.T) {
+	it := setupIdempotencyTestDB()
+	r := it.setupIdempotencyRouter(time.Hour)
+	billing := it.createTestBilling()
+	body := map[string]interface{}{"billing_id": billing.ID, "amount": 50}
+
+	first := postPaymentWithKey(r, "abc-123", body)
+	second := postPaymentWithKey(r, "abc-123", body)
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int64(1), it.countPayments())
+
+	updated, _ := it.repos.Billings.Get(billing.ID)
+	assert.Equal(t, money.Amount(50), updated.Amount)
+}
+
+func TestIdempotency_DifferentBodyRejected(t *testing.T) {
+	it := setupIdempotencyTestDB()
+	r := it.setupIdempotencyRouter(time.Hour)
+	billing := it.createTestBilling()
+
+	postPaymentWithKey(r, "abc-123", map[string]interface{}{"billing_id": billing.ID, "amount": 50})
+	w := postPaymentWithKey(r, "abc-123", map[string]interface{}{"billing_id": billing.ID, "amount": 70})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "already used with a different request")
+	assert.Equal(t, int64(1), it.countPayments())
+}
+
+func TestIdempotency_ClientErrorsAreReplayed(t *testing.T) {
+	it := setupIdempotencyTestDB()
+	r := it.setupIdempotencyRouter(time.Hour)
+	billing := it.createTestBilling()
+	body := map[string]interface{}{"billing_id": billing.ID, "amount": -1}
+
+	first := postPaymentWithKey(r, "neg", body)
+	second := postPaymentWithKey(r, "neg", body)
+
+	assert.Equal(t, http.StatusBadRequest, first.Code)
+	assert.Equal(t, http.StatusBadRequest, second.Code)
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_ExpiredKeyIsReusable(t *testing.T) {
+	it := setupIdempotencyTestDB()
+	r := it.setupIdempotencyRouter(-time.Second)
+	billing := it.createTestBilling()
+	body := map[string]interface{}{"billing_id": billing.ID, "amount": 50}
+
+	postPaymentWithKey(r, "old", body)
+	w := postPaymentWithKey(r, "old", body)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int64(2), it.countPayments())
+}
+
+func TestIdempotency_WithoutHeader(t *testing.T) {
+	it := setupIdempotencyTestDB()
+	r := it.setupIdempotencyRouter(time.Hour)
+	billing := it.createTestBilling()
+	body := map[string]interface{}{"billing_id": billing.ID, "amount": 50}
+
+	postPaymentWithKey(r, "", body)
+	postPaymentWithKey(r, "", body)
+
+	assert.Equal(t, int64(2), it.countPayments())
+}
+
+func TestIdempotency_KeysAreScopedToTheUser(t *testing.T) {
+	it := setupIdempotencyTestDB()
+	ana := it.setupIdempotencyRouter(time.Hour)
+	billing := it.createTestBilling()
+	outsider := it.createUser("Caio")
+	caio := it.setupIdempotencyRouterAs(time.Hour, outsider.ID)
+	body := map[string]interface{}{"billing_id": billing.ID, "amount": 50}
+
+	first := postPaymentWithKey(ana, "abc-123", body)
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	// Caio reusa a chave e o corpo de Ana: a requisição é dele e passa pela
+	// checagem de parte da cobrança, sem receber a resposta de Ana.
+	w := postPaymentWithKey(caio, "abc-123", body)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.NotContains(t, w.Body.String(), first.Body.String())
+	assert.Equal(t, int64(1), it.countPayments())
+}
@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"me-pague/internal/audit"
+	"me-pague/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRequestIDRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, audit.FromContext(c).RequestID)
+	})
+	return r
+}
+
+func TestRequestID_KeepsClientValue(t *testing.T) {
+	r := setupRequestIDRouter()
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(middleware.RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "abc-123", w.Body.String())
+	assert.Equal(t, "abc-123", w.Header().Get(middleware.RequestIDHeader))
+}
+
+func TestRequestID_GeneratesWhenMissingOrTooLong(t *testing.T) {
+	r := setupRequestIDRouter()
+
+	for _, sent := range []string{"", strings.Repeat("x", 200)} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if sent != "" {
+			req.Header.Set(middleware.RequestIDHeader, sent)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Len(t, w.Body.String(), 32)
+		assert.Equal(t, w.Body.String(), w.Header().Get(middleware.RequestIDHeader))
+	}
+}
@@ -0,0 +1,257 @@
+package migrate_test
+
+import (
+	"me-pague/internal/db"
+	"me-pague/internal/db/migrations"
+	"me-pague/internal/ledger"
+	"me-pague/internal/migrate"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/repository"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	testDB, err := db.Open(":memory:")
+	assert.Nil(t, err)
+	return testDB
+}
+
+func sampleFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_items.up.sql":     {Data: []byte("CREATE TABLE items (id integer PRIMARY KEY, name text);")},
+		"0001_create_items.down.sql":   {Data: []byte("DROP TABLE items;")},
+		"0002_add_item_price.up.sql":   {Data: []byte("ALTER TABLE items ADD COLUMN price integer;")},
+		"0002_add_item_price.down.sql": {Data: []byte("ALTER TABLE items DROP COLUMN price;")},
+		"README.md":                    {Data: []byte("ignorado")},
+	}
+}
+
+func TestUp_AppliesInOrderOnce(t *testing.T) {
+	testDB := openTestDB(t)
+
+	applied, err := migrate.Up(testDB, sampleFS())
+	assert.Nil(t, err)
+	if assert.Len(t, applied, 2) {
+		assert.Equal(t, "0001_create_items", applied[0].String())
+		assert.Equal(t, "0002_add_item_price", applied[1].String())
+	}
+	assert.True(t, testDB.Migrator().HasColumn("items", "price"))
+
+	applied, err = migrate.Up(testDB, sampleFS())
+	assert.Nil(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestDown_RevertsNewestFirst(t *testing.T) {
+	testDB := openTestDB(t)
+	_, err := migrate.Up(testDB, sampleFS())
+	assert.Nil(t, err)
+
+	reverted, err := migrate.Down(testDB, sampleFS(), 1)
+	assert.Nil(t, err)
+	if assert.Len(t, reverted, 1) {
+		assert.Equal(t, 2, reverted[0].Version)
+	}
+	assert.True(t, testDB.Migrator().HasTable("items"))
+	assert.False(t, testDB.Migrator().HasColumn("items", "price"))
+
+	states, err := migrate.Status(testDB, sampleFS())
+	assert.Nil(t, err)
+	if assert.Len(t, states, 2) {
+		assert.True(t, states[0].Applied)
+		assert.False(t, states[0].AppliedAt.IsZero())
+		assert.False(t, states[1].Applied)
+	}
+
+	reverted, err = migrate.Down(testDB, sampleFS(), 5)
+	assert.Nil(t, err)
+	assert.Len(t, reverted, 1)
+	assert.False(t, testDB.Migrator().HasTable("items"))
+
+	_, err = migrate.Down(testDB, sampleFS(), 0)
+	assert.NotNil(t, err)
+}
+
+func TestUp_RejectsEditedMigration(t *testing.T) {
+	testDB := openTestDB(t)
+	_, err := migrate.Up(testDB, sampleFS())
+	assert.Nil(t, err)
+
+	edited := sampleFS()
+	edited["0001_create_items.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE items (id integer PRIMARY KEY);")}
+
+	_, err = migrate.Up(testDB, edited)
+	assert.ErrorIs(t, err, migrate.ErrChecksumMismatch)
+	_, err = migrate.Status(testDB, edited)
+	assert.ErrorIs(t, err, migrate.ErrChecksumMismatch)
+}
+
+func TestUp_RejectsAppliedMigrationWithoutFile(t *testing.T) {
+	testDB := openTestDB(t)
+	_, err := migrate.Up(testDB, sampleFS())
+	assert.Nil(t, err)
+
+	missing := sampleFS()
+	delete(missing, "0002_add_item_price.up.sql")
+	delete(missing, "0002_add_item_price.down.sql")
+
+	_, err = migrate.Up(testDB, missing)
+	assert.ErrorIs(t, err, migrate.ErrUnknownMigration)
+}
+
+func TestUp_FailedMigrationIsNotRecorded(t *testing.T) {
+	testDB := openTestDB(t)
+	broken := sampleFS()
+	broken["0002_add_item_price.up.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE items ADD COLUMN price integer; INSERT INTO nowhere VALUES (1);")}
+
+	applied, err := migrate.Up(testDB, broken)
+	assert.NotNil(t, err)
+	assert.Len(t, applied, 1)
+	assert.False(t, testDB.Migrator().HasColumn("items", "price"))
+
+	states, err := migrate.Status(testDB, broken)
+	assert.Nil(t, err)
+	assert.True(t, states[0].Applied)
+	assert.False(t, states[1].Applied)
+}
+
+func TestLoad_RejectsMalformedFiles(t *testing.T) {
+	_, err := migrate.Load(fstest.MapFS{"create_items.up.sql": {Data: []byte("SELECT 1;")}})
+	assert.NotNil(t, err)
+
+	_, err = migrate.Load(fstest.MapFS{"0001_create_items.up.sql": {Data: []byte("SELECT 1;")}})
+	assert.NotNil(t, err)
+
+	_, err = migrate.Load(fstest.MapFS{
+		"0001_a.up.sql": {Data: []byte("SELECT 1;")}, "0001_a.down.sql": {Data: []byte("SELECT 1;")},
+		"0001_b.up.sql": {Data: []byte("SELECT 1;")}, "0001_b.down.sql": {Data: []byte("SELECT 1;")},
+	})
+	assert.NotNil(t, err)
+}
+
+// As migrações embutidas precisam criar todas as tabelas e colunas que os
+// modelos usam, e desfazer tudo no down.
+func TestMigrations_MatchModels(t *testing.T) {
+	testDB := openTestDB(t)
+	_, err := migrate.Up(testDB, migrations.FS)
+	assert.Nil(t, err)
+
+	all := []interface{}{
+		&models.User{}, &models.Billing{}, &models.Charge{}, &models.Payment{}, &models.PaymentReversal{},
+		&models.Group{}, &models.GroupMember{}, &models.GroupExpense{}, &models.ExpenseShare{},
+		&models.LedgerAccount{}, &models.JournalEntry{}, &models.Posting{}, &models.AccountBalance{},
+		&models.IdempotencyKey{}, &models.AuditEvent{}, &models.WebhookSubscription{}, &models.WebhookDelivery{},
+		&models.PixKey{}, &models.RecurringBilling{}, &models.RecurringRun{},
+		&models.InstallmentPlan{}, &models.Installment{}, &models.Loan{}, &models.LoanInstallment{},
+	}
+	for _, model := range all {
+		stmt := &gorm.Statement{DB: testDB}
+		assert.Nil(t, stmt.Parse(model))
+		assert.True(t, testDB.Migrator().HasTable(stmt.Schema.Table), stmt.Schema.Table)
+		for _, column := range stmt.Schema.DBNames {
+			assert.True(t, testDB.Migrator().HasColumn(model, column), "%s.%s", stmt.Schema.Table, column)
+		}
+	}
+
+	reverted, err := migrate.Down(testDB, migrations.FS, 100)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, reverted)
+	for _, model := range all {
+		assert.False(t, testDB.Migrator().HasTable(model))
+	}
+
+	_, err = migrate.Up(testDB, migrations.FS)
+	assert.Nil(t, err)
+}
+
+// Modelos da versão sem migrações, que criava as tabelas com AutoMigrate.
+type legacyUser struct {
+	ID   int32  `gorm:"primaryKey"`
+	Name string `gorm:"unique"`
+}
+
+func (legacyUser) TableName() string { return "users" }
+
+type legacyPayment struct {
+	ID        int32 `gorm:"primaryKey"`
+	PayerID   int32
+	BillingID int32
+	Amount    int32
+	CreatedAt time.Time
+}
+
+func (legacyPayment) TableName() string { return "payments" }
+
+type legacyBilling struct {
+	ID         int32 `gorm:"primaryKey"`
+	PayerID    int32
+	ReceiverID int32
+	Amount     int32
+	CreatedAt  time.Time
+}
+
+func (legacyBilling) TableName() string { return "billings" }
+
+func TestMigrate_AdoptsAutoMigrateSchema(t *testing.T) {
+	testDB := openTestDB(t)
+	assert.Nil(t, testDB.AutoMigrate(&legacyUser{}, &legacyPayment{}, &legacyBilling{}))
+	assert.Nil(t, testDB.Create(&[]legacyUser{{Name: "Ana"}, {Name: "Beto"}}).Error)
+	assert.Nil(t, testDB.Create(&legacyBilling{PayerID: 1, ReceiverID: 2, Amount: 150, CreatedAt: time.Now()}).Error)
+	assert.Nil(t, testDB.Create(&[]legacyPayment{
+		{PayerID: 1, BillingID: 1, Amount: 100, CreatedAt: time.Now()},
+		{PayerID: 1, BillingID: 1, Amount: 50, CreatedAt: time.Now()},
+	}).Error)
+
+	applied, err := db.Migrate(testDB)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, applied)
+
+	states, err := migrate.Status(testDB, migrations.FS)
+	assert.Nil(t, err)
+	for _, s := range states {
+		assert.True(t, s.Applied, s.String())
+	}
+
+	var users []models.User
+	assert.Nil(t, testDB.Order("id").Find(&users).Error)
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, "Ana", users[0].Name)
+		assert.Empty(t, users[0].PasswordHash)
+	}
+
+	var billing models.Billing
+	assert.Nil(t, testDB.First(&billing, 1).Error)
+	assert.Equal(t, money.Amount(150), billing.Amount)
+	assert.Equal(t, "BRL", billing.Currency)
+	assert.Equal(t, int32(0), billing.Version)
+
+	var payments []models.Payment
+	assert.Nil(t, testDB.Order("id").Find(&payments).Error)
+	if assert.Len(t, payments, 2) {
+		assert.Equal(t, money.Amount(100), payments[0].Amount)
+		assert.Equal(t, models.PaymentConfirmed, payments[0].Status)
+		assert.Equal(t, "BRL", payments[0].Currency)
+		assert.Equal(t, money.Amount(0), payments[0].Credit)
+	}
+
+	// Os pagamentos anteriores ao razão são lançados nele.
+	billings := []models.Billing{billing}
+	assert.Nil(t, repository.LoadBillingTotals(testDB, billings))
+	assert.Equal(t, money.Amount(150), billings[0].TotalPaid)
+	assert.Equal(t, money.Amount(150), billings[0].Credit)
+	report, err := ledger.Check(testDB)
+	assert.Nil(t, err)
+	assert.True(t, report.OK, report.Problems)
+	assert.Equal(t, int64(2), report.Entries)
+
+	// Um banco já migrado não é adotado de novo.
+	applied, err = db.Migrate(testDB)
+	assert.Nil(t, err)
+	assert.Empty(t, applied)
+}
@@ -0,0 +1,136 @@
+package money_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"me-pague/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmount_CheckedArithmetic(t *testing.T) {
+	sum, err := money.Amount(1250).Add(750)
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(2000), sum)
+
+	_, err = money.Amount(math.MaxInt64).Add(1)
+	assert.Equal(t, money.ErrOverflow, err)
+	_, err = money.Amount(math.MinInt64).Sub(1)
+	assert.Equal(t, money.ErrOverflow, err)
+	_, err = money.Amount(math.MaxInt64 / 2).Mul(3)
+	assert.Equal(t, money.ErrOverflow, err)
+	_, err = money.Amount(math.MinInt64).Mul(-1)
+	assert.Equal(t, money.ErrOverflow, err)
+
+	product, err := money.Amount(-250).Mul(4)
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(-1000), product)
+
+	_, err = money.Sum(math.MaxInt64-10, 5, 6)
+	assert.Equal(t, money.ErrOverflow, err)
+}
+
+func TestMoney_CurrenciesDoNotMix(t *testing.T) {
+	brl := money.New(1000, "BRL")
+
+	_, err := brl.Add(money.New(1000, "USD"))
+	assert.Equal(t, money.ErrCurrencyMismatch, err)
+
+	total, err := brl.Sub(money.New(250, "BRL"))
+	assert.Nil(t, err)
+	assert.Equal(t, "7.50 BRL", total.String())
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		value    string
+		currency string
+		want     money.Amount
+	}{
+		{"12.50", "BRL", 1250},
+		{"12.5", "BRL", 1250},
+		{"12", "BRL", 1200},
+		{"-0.01", "BRL", -1},
+		{"1500", "JPY", 1500},
+	}
+	for _, c := range cases {
+		parsed, err := money.Parse(c.value, c.currency)
+		assert.Nil(t, err, c.value)
+		assert.Equal(t, c.want, parsed.Amount, c.value)
+	}
+
+	for _, value := range []string{"", "12,50", "1e3", "12.505", "99999999999999999999"} {
+		_, err := money.Parse(value, "BRL")
+		assert.NotNil(t, err, value)
+	}
+	_, err := money.Parse("12.5", "JPY")
+	assert.NotNil(t, err)
+	_, err = money.Parse("12.50", "XYZ")
+	assert.NotNil(t, err)
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "12.50", money.Amount(1250).Format("BRL"))
+	assert.Equal(t, "-0.05", money.Amount(-5).Format("BRL"))
+	assert.Equal(t, "1500", money.Amount(1500).Format("JPY"))
+}
+
+func TestRound(t *testing.T) {
+	cases := []struct {
+		num, den int64
+		mode     money.Rounding
+		want     money.Amount
+	}{
+		{5, 2, money.HalfUp, 3},
+		{-5, 2, money.HalfUp, -3},
+		{5, 2, money.HalfEven, 2},
+		{7, 2, money.HalfEven, 4},
+		{-5, 2, money.HalfEven, -2},
+		{29, 10, money.Down, 2},
+		{-29, 10, money.Down, -2},
+		{26, 10, money.HalfEven, 3},
+		{24, 10, money.HalfUp, 2},
+	}
+	for _, c := range cases {
+		got, err := money.Round(big.NewRat(c.num, c.den), c.mode)
+		assert.Nil(t, err)
+		assert.Equal(t, c.want, got, "%d/%d %s", c.num, c.den, c.mode)
+	}
+
+	huge := new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 70))
+	_, err := money.Round(huge, money.HalfUp)
+	assert.Equal(t, money.ErrOverflow, err)
+}
+
+func TestInput_JSON(t *testing.T) {
+	var body struct {
+		Amount money.Input `json:"amount"`
+	}
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"amount": 1250}`), &body))
+	amount, err := body.Amount.In("BRL")
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(1250), amount)
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"amount": "12.50"}`), &body))
+	amount, err = body.Amount.In("BRL")
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(1250), amount)
+
+	// O texto decimal segue as casas da moeda: em JPY não há centavos.
+	amount, err = body.Amount.In("JPY")
+	assert.NotNil(t, err)
+	assert.Equal(t, money.Amount(0), amount)
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"amount": 12.5}`), &body))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"amount": "12,50"}`), &body))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"amount": 99999999999999999999}`), &body))
+
+	var empty struct {
+		Amount money.Input `json:"amount"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(`{}`), &empty))
+	assert.True(t, empty.Amount.IsZero())
+}
@@ -0,0 +1,139 @@
+package penalty_test
+
+import (
+	"me-pague/internal/money"
+	"me-pague/internal/penalty"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var policy = penalty.Policy{LateFeeBP: 200, MonthlyInterestBP: 100, CapBP: 2000}
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func due(s string) *time.Time {
+	t := date(s)
+	return &t
+}
+
+func TestAssess_NotLateOnDueDate(t *testing.T) {
+	charges := []penalty.Charge{{ID: 1, Amount: 10000, DueDate: due("2026-01-10")}}
+
+	result, err := penalty.Assess(policy, charges, nil, date("2026-01-10").Add(23*time.Hour))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []penalty.Assessment{{ChargeID: 1, Amount: 10000, Unpaid: 10000}}, result)
+}
+
+func TestAssess_FeeAndDailyInterest(t *testing.T) {
+	charges := []penalty.Charge{{ID: 1, Amount: 10000, DueDate: due("2026-01-10")}}
+
+	result, err := penalty.Assess(policy, charges, nil, date("2026-01-25"))
+	assert.Nil(t, err)
+
+	// Multa de 2% e 15 dias a 1% ao mês: 10000 x 15 x 1% / 30.
+	assert.Equal(t, 15, result[0].DaysLate)
+	assert.Equal(t, money.Amount(200), result[0].LateFee)
+	assert.Equal(t, money.Amount(50), result[0].Interest)
+}
+
+func TestAssess_PartialPayments(t *testing.T) {
+	charges := []penalty.Charge{{ID: 1, Amount: 10000, DueDate: due("2026-01-10")}}
+	payments := []penalty.Payment{
+		{Amount: 3000, Date: date("2026-01-20").Add(15 * time.Hour)},
+		{Amount: 4000, Date: date("2026-01-05")},
+	}
+
+	result, err := penalty.Assess(policy, charges, payments, date("2026-01-30"))
+	assert.Nil(t, err)
+
+	// Multa sobre os 6000 em aberto no vencimento; juros de 9 dias sobre
+	// 6000 (11 a 19) e de 11 dias sobre 3000 (20 a 30).
+	assert.Equal(t, money.Amount(3000), result[0].Unpaid)
+	assert.Equal(t, money.Amount(120), result[0].LateFee)
+	assert.Equal(t, money.Amount((6000*9+3000*11)*100/300000), result[0].Interest)
+}
+
+func TestAssess_PaidInFullStopsInterest(t *testing.T) {
+	charges := []penalty.Charge{{ID: 1, Amount: 9000, DueDate: due("2026-03-01")}}
+	payments := []penalty.Payment{{Amount: 9000, Date: date("2026-03-11")}}
+
+	result, err := penalty.Assess(policy, charges, payments, date("2026-12-31"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, money.Amount(0), result[0].Unpaid)
+	assert.Equal(t, money.Amount(180), result[0].LateFee)
+	assert.Equal(t, money.Amount(9000*9*100/300000), result[0].Interest)
+}
+
+func TestAssess_Cap(t *testing.T) {
+	charges := []penalty.Charge{{ID: 1, Amount: 10000, DueDate: due("2020-01-01")}}
+
+	result, err := penalty.Assess(policy, charges, nil, date("2026-01-01"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, money.Amount(200), result[0].LateFee)
+	assert.Equal(t, money.Amount(1800), result[0].Interest)
+
+	uncapped, err := penalty.Assess(penalty.Policy{LateFeeBP: 200, MonthlyInterestBP: 100}, charges, nil, date("2026-01-01"))
+	assert.Nil(t, err)
+	assert.Greater(t, uncapped[0].Interest, money.Amount(1800))
+}
+
+func TestAssess_OldestFirstAndTruncation(t *testing.T) {
+	charges := []penalty.Charge{
+		{ID: 2, Amount: 500, DueDate: due("2026-02-10")},
+		{ID: 1, Amount: 333, DueDate: due("2026-01-10")},
+		{ID: 3, Amount: 700},
+	}
+	payments := []penalty.Payment{{Amount: 200, Date: date("2026-01-01")}}
+
+	result, err := penalty.Assess(policy, charges, payments, date("2026-02-11"))
+	assert.Nil(t, err)
+
+	// O vencimento mais antigo vem primeiro; sem vencimento, por último.
+	assert.Equal(t, []int32{1, 2, 3}, []int32{result[0].ChargeID, result[1].ChargeID, result[2].ChargeID})
+	assert.Equal(t, money.Amount(133), result[0].Unpaid)
+	assert.Equal(t, money.Amount(500), result[1].Unpaid)
+	assert.Equal(t, money.Amount(700), result[2].Unpaid)
+
+	// 133 x 2% = 2,66 e 500 x 2% = 10: truncados no centavo.
+	assert.Equal(t, money.Amount(2), result[0].LateFee)
+	assert.Equal(t, money.Amount(133*32*100/300000), result[0].Interest)
+	assert.Equal(t, money.Amount(10), result[1].LateFee)
+	assert.Equal(t, money.Amount(0), result[1].Interest)
+	assert.Equal(t, 0, result[2].DaysLate)
+}
+
+func TestAssess_PaysOverdueBeforeChargesWithoutDueDate(t *testing.T) {
+	charges := []penalty.Charge{
+		{ID: 1, Amount: 700},
+		{ID: 2, Amount: 1000, DueDate: due("2026-01-10")},
+	}
+	payments := []penalty.Payment{{Amount: 1000, Date: date("2026-01-05")}}
+
+	result, err := penalty.Assess(policy, charges, payments, date("2026-01-20"))
+	assert.Nil(t, err)
+
+	// O pagamento quita o lançamento que venceria, e nada sofre encargos.
+	assert.Equal(t, []penalty.Assessment{
+		{ChargeID: 2, Amount: 1000, Unpaid: 0, DaysLate: 10},
+		{ChargeID: 1, Amount: 700, Unpaid: 700},
+	}, result)
+}
+
+func TestAssess_IgnoresPaymentsAfterAsOf(t *testing.T) {
+	charges := []penalty.Charge{{ID: 1, Amount: 1000, DueDate: due("2026-01-10")}}
+	payments := []penalty.Payment{{Amount: 1000, Date: date("2026-02-01")}}
+
+	result, err := penalty.Assess(policy, charges, payments, date("2026-01-20"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, money.Amount(1000), result[0].Unpaid)
+	assert.Equal(t, money.Amount(20), result[0].LateFee)
+}
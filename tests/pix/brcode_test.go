@@ -0,0 +1,80 @@
+package pix_test
+
+import (
+	"fmt"
+	"me-pague/internal/pix"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode_MatchesCentralBankExample(t *testing.T) {
+	// Exemplo do Manual de Padrões para Iniciação do Pix.
+	payload, err := pix.Payload{
+		Key:          "123e4567-e12b-12d1-a456-426655440000",
+		MerchantName: "Fulano de Tal",
+		MerchantCity: "BRASILIA",
+	}.Encode()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D", payload)
+}
+
+func TestEncode_AmountAndTxID(t *testing.T) {
+	payload, err := pix.Payload{
+		Key:          "ana@example.com",
+		MerchantName: "Ana Conceição",
+		MerchantCity: "São Paulo",
+		Amount:       1250,
+		TxID:         "MEPAGUE42",
+	}.Encode()
+
+	assert.Nil(t, err)
+	assert.Contains(t, payload, "540512.50")
+	assert.Contains(t, payload, "5913Ana Conceicao")
+	assert.Contains(t, payload, "6009Sao Paulo")
+	assert.Contains(t, payload, "62130509MEPAGUE42")
+	assert.True(t, strings.HasPrefix(payload[len(payload)-8:], "6304"))
+
+	body := payload[:len(payload)-4]
+	assert.Equal(t, payload[len(payload)-4:], fmt.Sprintf("%04X", pix.CRC16(body)))
+}
+
+func TestEncode_TruncatesNameAndCity(t *testing.T) {
+	payload, err := pix.Payload{
+		Key:          "ana@example.com",
+		MerchantName: "Maria Aparecida dos Santos Oliveira",
+		MerchantCity: "Sao Jose dos Campos",
+	}.Encode()
+
+	assert.Nil(t, err)
+	assert.Contains(t, payload, "5925Maria Aparecida dos Santo")
+	assert.Contains(t, payload, "6015Sao Jose dos Ca")
+}
+
+func TestEncode_Validation(t *testing.T) {
+	valid := pix.Payload{Key: "ana@example.com", MerchantName: "Ana", MerchantCity: "Recife"}
+
+	cases := map[string]pix.Payload{
+		"pix key":     {MerchantName: "Ana", MerchantCity: "Recife"},
+		"name":        {Key: valid.Key, MerchantCity: "Recife"},
+		"city":        {Key: valid.Key, MerchantName: "Ana"},
+		"txid":        {Key: valid.Key, MerchantName: "Ana", MerchantCity: "Recife", TxID: "com-hifen"},
+		"negative":    {Key: valid.Key, MerchantName: "Ana", MerchantCity: "Recife", Amount: -1},
+		"long txid":   {Key: valid.Key, MerchantName: "Ana", MerchantCity: "Recife", TxID: strings.Repeat("A", 26)},
+		"long pixkey": {Key: strings.Repeat("a", 78), MerchantName: "Ana", MerchantCity: "Recife"},
+	}
+	for name, payload := range cases {
+		_, err := payload.Encode()
+		assert.NotNil(t, err, name)
+	}
+
+	_, err := valid.Encode()
+	assert.Nil(t, err)
+}
+
+func TestCRC16(t *testing.T) {
+	// Valor de verificação do CRC-16/CCITT-FALSE.
+	assert.Equal(t, uint16(0x29B1), pix.CRC16("123456789"))
+}
@@ -0,0 +1,49 @@
+package pix_test
+
+import (
+	"me-pague/internal/pix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeKey_Valid(t *testing.T) {
+	cases := []struct {
+		keyType, key, want string
+	}{
+		{pix.KeyCPF, "529.982.247-25", "52998224725"},
+		{pix.KeyCPF, "52998224725", "52998224725"},
+		{pix.KeyCNPJ, "11.222.333/0001-81", "11222333000181"},
+		{pix.KeyEmail, " Ana@Example.com ", "ana@example.com"},
+		{pix.KeyPhone, "+5511999998888", "+5511999998888"},
+		{pix.KeyEVP, "123E4567-E12B-12D1-A456-426655440000", "123e4567-e12b-12d1-a456-426655440000"},
+	}
+	for _, tc := range cases {
+		got, err := pix.NormalizeKey(tc.keyType, tc.key)
+		assert.Nil(t, err, tc.key)
+		assert.Equal(t, tc.want, got, tc.key)
+	}
+}
+
+func TestNormalizeKey_Invalid(t *testing.T) {
+	cases := []struct {
+		keyType, key string
+	}{
+		{pix.KeyCPF, "529.982.247-26"},
+		{pix.KeyCPF, "111.111.111-11"},
+		{pix.KeyCPF, "5299822472"},
+		{pix.KeyCPF, "529a98224725"},
+		{pix.KeyCNPJ, "11.222.333/0001-82"},
+		{pix.KeyCNPJ, "00.000.000/0000-00"},
+		{pix.KeyEmail, "ana"},
+		{pix.KeyEmail, "Ana <ana@example.com>"},
+		{pix.KeyPhone, "11999998888"},
+		{pix.KeyPhone, "+55 11 99999-8888"},
+		{pix.KeyEVP, "123e4567e12b12d1a456426655440000"},
+		{"iban", "BR1800360305000010009795493C1"},
+	}
+	for _, tc := range cases {
+		_, err := pix.NormalizeKey(tc.keyType, tc.key)
+		assert.NotNil(t, err, "%s %s", tc.keyType, tc.key)
+	}
+}
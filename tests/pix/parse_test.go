@@ -0,0 +1,84 @@
+package pix_test
+
+import (
+	"me-pague/internal/money"
+	"me-pague/internal/pix"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_RoundTrip(t *testing.T) {
+	payload, _ := pix.Payload{
+		Key:          "ana@example.com",
+		MerchantName: "Ana Conceição",
+		MerchantCity: "São Paulo",
+		Amount:       1250,
+		TxID:         "MEPAGUE42",
+	}.Encode()
+
+	parsed, err := pix.Parse(" " + payload + "\n")
+
+	assert.Nil(t, err)
+	assert.Equal(t, pix.Parsed{
+		Key:          "ana@example.com",
+		MerchantName: "Ana Conceicao",
+		MerchantCity: "Sao Paulo",
+		Amount:       1250,
+		TxID:         "MEPAGUE42",
+	}, parsed)
+}
+
+func TestParse_CentralBankExample(t *testing.T) {
+	parsed, err := pix.Parse("00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "123e4567-e12b-12d1-a456-426655440000", parsed.Key)
+	assert.Equal(t, money.Amount(0), parsed.Amount)
+	assert.Equal(t, pix.NoTxID, parsed.TxID)
+}
+
+func TestParse_LowercaseCRC(t *testing.T) {
+	_, err := pix.Parse("00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041d3d")
+	assert.Nil(t, err)
+}
+
+func TestParse_InvalidCRC(t *testing.T) {
+	payload, _ := pix.Payload{Key: "ana@example.com", MerchantName: "Ana", MerchantCity: "Recife", Amount: 1250}.Encode()
+
+	_, err := pix.Parse(payload[:len(payload)-4] + "0000")
+	assert.ErrorIs(t, err, pix.ErrInvalidCRC)
+
+	// Troca o valor sem recalcular o CRC.
+	_, err = pix.Parse(strings.Replace(payload, "540512.50", "540599.50", 1))
+	assert.ErrorIs(t, err, pix.ErrInvalidCRC)
+}
+
+func TestParse_Malformed(t *testing.T) {
+	cases := map[string]string{
+		"empty":      "",
+		"truncated":  "000201260",
+		"overflow":   "0002012699",
+		"no crc":     "000201",
+		"bad header": "00020126xx",
+	}
+	for name, payload := range cases {
+		_, err := pix.Parse(payload)
+		assert.ErrorIs(t, err, pix.ErrMalformed, name)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]money.Amount{"12.50": 1250, "12.5": 1250, "12": 1200, "0.01": 1}
+	for value, cents := range cases {
+		got, err := pix.ParseAmount(value)
+		assert.Nil(t, err, value)
+		assert.Equal(t, cents, got, value)
+	}
+
+	for _, value := range []string{"", ".50", "12.505", "12,50", "-1.00", "99999999999.00"} {
+		_, err := pix.ParseAmount(value)
+		assert.ErrorIs(t, err, pix.ErrMalformed, value)
+	}
+}
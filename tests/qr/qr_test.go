@@ -0,0 +1,303 @@
+package qr_test
+
+import (
+	"bytes"
+	"image/png"
+	"me-pague/internal/qr"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Os testes leem o código de volta com um decodificador mínimo escrito a
+// partir da norma: confere os localizadores e as informações de formato,
+// desfaz a máscara, separa os blocos, verifica as síndromes Reed-Solomon e
+// lê o segmento em modo byte.
+
+// blockSpec é a divisão em blocos do nível M (tabela 9 da norma).
+type blockSpec struct {
+	eccPerBlock int
+	dataLens    []int
+}
+
+var levelM = map[int]blockSpec{
+	1:  {10, []int{16}},
+	2:  {16, []int{28}},
+	5:  {24, []int{43, 43}},
+	8:  {22, []int{38, 38, 39, 39}},
+	10: {26, []int{43, 43, 43, 43, 44}},
+}
+
+// alignment são os centros dos padrões de alinhamento (anexo E da norma).
+var alignment = map[int][]int{
+	1: nil, 2: {6, 18}, 5: {6, 30}, 8: {6, 24, 42}, 10: {6, 28, 50},
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	cases := []struct {
+		length  int
+		version int
+	}{{5, 1}, {20, 2}, {70, 5}, {140, 8}, {200, 10}}
+
+	for _, tc := range cases {
+		data := []byte(strings.Repeat("00020126580014br.gov.bcb.pix", 10)[:tc.length])
+		code, err := qr.Encode(data, qr.M)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.version, code.Version)
+		assert.Equal(t, tc.version*4+17, code.Size)
+
+		assert.Equal(t, data, decode(t, code))
+	}
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	_, err := qr.Encode(bytes.Repeat([]byte("x"), 3000), qr.M)
+	assert.ErrorIs(t, err, qr.ErrTooLong)
+}
+
+func TestRender(t *testing.T) {
+	code, _ := qr.Encode([]byte("pix"), qr.M)
+
+	data, err := code.PNG(4)
+	assert.Nil(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, (21+2*qr.QuietZone)*4, img.Bounds().Dx())
+
+	r, _, _, _ := img.At(qr.QuietZone*4, qr.QuietZone*4).RGBA()
+	assert.Equal(t, uint32(0), r, "canto do localizador deve ser escuro")
+
+	svg := code.SVG()
+	assert.True(t, strings.HasPrefix(svg, "<svg"))
+	assert.Contains(t, svg, `viewBox="0 0 29 29"`)
+	assert.Contains(t, svg, "M4,4h1v1h-1z")
+}
+
+func decode(t *testing.T, code *qr.Code) []byte {
+	size := code.Size
+
+	for _, corner := range [][2]int{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				ring := max(abs(dx-3), abs(dy-3))
+				assert.Equal(t, ring != 2, code.Black(corner[0]+dx, corner[1]+dy), "localizador em %v", corner)
+			}
+		}
+	}
+
+	// Primeira cópia do formato, do bit 0 ao 14.
+	var format int
+	coords := [][2]int{}
+	for i := 0; i <= 5; i++ {
+		coords = append(coords, [2]int{8, i})
+	}
+	coords = append(coords, [2]int{8, 7}, [2]int{8, 8}, [2]int{7, 8})
+	for i := 9; i < 15; i++ {
+		coords = append(coords, [2]int{14 - i, 8})
+	}
+	for i, xy := range coords {
+		if code.Black(xy[0], xy[1]) {
+			format |= 1 << i
+		}
+	}
+	format ^= 0x5412
+	assert.Equal(t, 0, bchRemainder(format, 0x537, 10), "BCH do formato")
+	assert.Equal(t, 0, format>>13, "nível M")
+	mask := (format >> 10) & 7
+	assert.Equal(t, code.Mask, mask)
+
+	if code.Version >= 7 {
+		var version int
+		for i := 0; i < 18; i++ {
+			if code.Black(size-11+i%3, i/3) {
+				version |= 1 << i
+			}
+		}
+		assert.Equal(t, 0, bchRemainder(version, 0x1F25, 12), "BCH da versão")
+		assert.Equal(t, code.Version, version>>12)
+	}
+
+	reserved := functionModules(code.Version, size)
+	var bits []bool
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if !reserved[y][x] {
+					bits = append(bits, code.Black(x, y) != masked(mask, x, y))
+				}
+			}
+		}
+	}
+	codewords := make([]byte, len(bits)/8)
+	for i := range codewords {
+		for j := 0; j < 8; j++ {
+			if bits[i*8+j] {
+				codewords[i] |= 1 << (7 - j)
+			}
+		}
+	}
+
+	spec := levelM[code.Version]
+	blocks := make([][]byte, len(spec.dataLens))
+	k := 0
+	for i := 0; i < spec.dataLens[len(spec.dataLens)-1]; i++ {
+		for b, dataLen := range spec.dataLens {
+			if i < dataLen {
+				blocks[b] = append(blocks[b], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for b := range blocks {
+		data = append(data, blocks[b]...)
+	}
+	for i := 0; i < spec.eccPerBlock; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], codewords[k])
+			k++
+		}
+	}
+	for b, block := range blocks {
+		for i := 0; i < spec.eccPerBlock; i++ {
+			assert.Equal(t, byte(0), syndrome(block, i), "síndrome %d do bloco %d", i, b)
+		}
+	}
+
+	reader := bitReader{data: data}
+	assert.Equal(t, 4, reader.read(4), "modo byte")
+	countBits := 8
+	if code.Version >= 10 {
+		countBits = 16
+	}
+	length := reader.read(countBits)
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = byte(reader.read(8))
+	}
+	return out
+}
+
+func functionModules(version, size int) [][]bool {
+	reserved := make([][]bool, size)
+	for y := range reserved {
+		reserved[y] = make([]bool, size)
+	}
+	fill := func(x0, y0, x1, y1 int) {
+		for y := max(y0, 0); y <= min(y1, size-1); y++ {
+			for x := max(x0, 0); x <= min(x1, size-1); x++ {
+				reserved[y][x] = true
+			}
+		}
+	}
+
+	fill(0, 0, 8, 8)
+	fill(size-8, 0, size-1, 8)
+	fill(0, size-8, 8, size-1)
+	fill(6, 0, 6, size-1)
+	fill(0, 6, size-1, 6)
+
+	centers := alignment[version]
+	for _, cx := range centers {
+		for _, cy := range centers {
+			if (cx == 6 && cy == 6) || (cx == 6 && cy == size-7) || (cx == size-7 && cy == 6) {
+				continue
+			}
+			fill(cx-2, cy-2, cx+2, cy+2)
+		}
+	}
+
+	if version >= 7 {
+		fill(size-11, 0, size-9, 5)
+		fill(0, size-11, 5, size-9)
+	}
+	return reserved
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (y+x)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (y+x)%3 == 0
+	case 4:
+		return (y/2+x/3)%2 == 0
+	case 5:
+		return (y*x)%2+(y*x)%3 == 0
+	case 6:
+		return ((y*x)%2+(y*x)%3)%2 == 0
+	default:
+		return ((y+x)%2+(y*x)%3)%2 == 0
+	}
+}
+
+// syndrome avalia o bloco, como polinômio, em alfa^i.
+func syndrome(block []byte, i int) byte {
+	x := byte(1)
+	for j := 0; j < i; j++ {
+		x = mul(x, 2)
+	}
+	var acc byte
+	for _, c := range block {
+		acc = mul(acc, x) ^ c
+	}
+	return acc
+}
+
+func mul(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1D
+		}
+		b >>= 1
+	}
+	return p
+}
+
+func bchRemainder(value, poly, degree int) int {
+	for i := 17; i >= degree; i-- {
+		if value>>i&1 != 0 {
+			value ^= poly << (i - degree)
+		}
+	}
+	return value
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		v = v<<1 | int(r.data[r.pos>>3]>>(7-r.pos&7)&1)
+		r.pos++
+	}
+	return v
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
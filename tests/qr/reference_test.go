@@ -0,0 +1,166 @@
+package qr_test
+
+import (
+	"bufio"
+	"fmt"
+	"me-pague/internal/qr"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Respostas conhecidas, que não dependem do decodificador destes testes.
+//
+// testdata/reference.txt traz matrizes geradas por github.com/skip2/go-qrcode
+// (versão da1b6568686e), sem a margem, com a máscara fixada na que o Encode
+// escolheu: a norma aceita qualquer uma das oito, e a escolha por
+// penalidade varia entre implementações. Cada caso é uma linha
+// "level=<L|M|Q|H> version=<n> mask=<n> data=<texto entre aspas>" seguida
+// das linhas da matriz, com # para módulo escuro e . para claro.
+
+type reference struct {
+	level   qr.Level
+	version int
+	mask    int
+	data    string
+	rows    []string
+}
+
+var levels = map[string]qr.Level{"L": qr.L, "M": qr.M, "Q": qr.Q, "H": qr.H}
+
+func loadReferences(t *testing.T) []reference {
+	file, err := os.Open("testdata/reference.txt")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer file.Close()
+
+	var references []reference
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "level="):
+			var level, data string
+			var ref reference
+			_, err := fmt.Sscanf(line, "level=%s version=%d mask=%d data=%q", &level, &ref.version, &ref.mask, &data)
+			if !assert.Nil(t, err, line) {
+				t.FailNow()
+			}
+			ref.level, ref.data = levels[level], data
+			references = append(references, ref)
+		default:
+			last := &references[len(references)-1]
+			last.rows = append(last.rows, line)
+		}
+	}
+	assert.Nil(t, scanner.Err())
+	return references
+}
+
+func TestEncode_MatchesReferenceMatrices(t *testing.T) {
+	references := loadReferences(t)
+	assert.Len(t, references, 10)
+
+	for _, ref := range references {
+		code, err := qr.Encode([]byte(ref.data), ref.level)
+		assert.Nil(t, err)
+		assert.Equal(t, ref.version, code.Version, ref.data)
+		assert.Equal(t, ref.mask, code.Mask, ref.data)
+		if !assert.Equal(t, len(ref.rows), code.Size, ref.data) {
+			continue
+		}
+
+		for y, row := range ref.rows {
+			var got strings.Builder
+			for x := 0; x < code.Size; x++ {
+				if code.Black(x, y) {
+					got.WriteByte('#')
+				} else {
+					got.WriteByte('.')
+				}
+			}
+			assert.Equal(t, row, got.String(), "version %d, row %d", ref.version, y)
+		}
+	}
+}
+
+// formatInformation é a tabela C.1 da norma: os 15 bits de formato, já com
+// a máscara 101010000010010, por nível e máscara.
+var formatInformation = map[qr.Level][8]string{
+	qr.L: {"111011111000100", "111001011110011", "111110110101010", "111100010011101", "110011000101111", "110001100011000", "110110001000001", "110100101110110"},
+	qr.M: {"101010000010010", "101000100100101", "101111001111100", "101101101001011", "100010111111001", "100000011001110", "100111110010111", "100101010100000"},
+	qr.Q: {"011010101011111", "011000001101000", "011111100110001", "011101000000110", "010010010110100", "010000110000011", "010111011011010", "010101111101101"},
+	qr.H: {"001011010001001", "001001110111110", "001110011100111", "001100111010000", "000011101100010", "000001001010101", "000110100001100", "000100000111011"},
+}
+
+// versionInformation é a tabela D.1 da norma: os 18 bits de versão.
+var versionInformation = map[int]string{
+	7:  "000111110010010100",
+	8:  "001000010110111100",
+	10: "001010010011010011",
+	17: "010001010001011101",
+}
+
+func TestEncode_FormatAndVersionInformation(t *testing.T) {
+	for _, ref := range loadReferences(t) {
+		code, err := qr.Encode([]byte(ref.data), ref.level)
+		assert.Nil(t, err)
+
+		expected, _ := strconv.ParseInt(formatInformation[code.Level][code.Mask], 2, 32)
+		first, second := 0, 0
+		for i := 0; i < 15; i++ {
+			// Primeira cópia, em volta do localizador superior esquerdo.
+			var x, y int
+			switch {
+			case i < 6:
+				x, y = 8, i
+			case i < 8:
+				x, y = 8, i+1
+			case i == 8:
+				x, y = 7, 8
+			default:
+				x, y = 14-i, 8
+			}
+			if code.Black(x, y) {
+				first |= 1 << i
+			}
+			// Segunda cópia, dividida entre os outros dois localizadores.
+			if i < 8 {
+				x, y = code.Size-1-i, 8
+			} else {
+				x, y = 8, code.Size-15+i
+			}
+			if code.Black(x, y) {
+				second |= 1 << i
+			}
+		}
+		assert.Equal(t, int(expected), first, "version %d", code.Version)
+		assert.Equal(t, int(expected), second, "version %d", code.Version)
+		// Módulo escuro fixo, ao lado da segunda cópia.
+		assert.True(t, code.Black(8, code.Size-8))
+
+		bits, ok := versionInformation[code.Version]
+		if !ok {
+			assert.Less(t, code.Version, 7)
+			continue
+		}
+		expected, _ = strconv.ParseInt(bits, 2, 32)
+		lowerLeft, upperRight := 0, 0
+		for i := 0; i < 18; i++ {
+			a, b := code.Size-11+i%3, i/3
+			if code.Black(b, a) {
+				lowerLeft |= 1 << i
+			}
+			if code.Black(a, b) {
+				upperRight |= 1 << i
+			}
+		}
+		assert.Equal(t, int(expected), lowerLeft, "version %d", code.Version)
+		assert.Equal(t, int(expected), upperRight, "version %d", code.Version)
+	}
+}
@@ -0,0 +1,108 @@
+package recurring_test
+
+import (
+	"me-pague/internal/recurring"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(recurring.DateLayout, s)
+	return t
+}
+
+func occurrences(rule recurring.Rule, n int) []string {
+	var dates []string
+	for i := 0; i < n; i++ {
+		dates = append(dates, rule.Occurrence(i).Format(recurring.DateLayout))
+	}
+	return dates
+}
+
+func TestOccurrence_MonthlyClampsToMonthEnd(t *testing.T) {
+	rule := recurring.Rule{Frequency: recurring.Monthly, Interval: 1, Start: date("2024-01-31")}
+
+	assert.Equal(t, []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30", "2024-05-31"}, occurrences(rule, 5))
+	assert.Equal(t, "2025-02-28", rule.Occurrence(13).Format(recurring.DateLayout))
+}
+
+func TestOccurrence_Intervals(t *testing.T) {
+	weekly := recurring.Rule{Frequency: recurring.Weekly, Interval: 2, Start: date("2025-12-22")}
+	assert.Equal(t, []string{"2025-12-22", "2026-01-05", "2026-01-19"}, occurrences(weekly, 3))
+
+	daily := recurring.Rule{Frequency: recurring.Daily, Interval: 3, Start: date("2026-02-27")}
+	assert.Equal(t, []string{"2026-02-27", "2026-03-02", "2026-03-05"}, occurrences(daily, 3))
+
+	quarterly := recurring.Rule{Frequency: recurring.Monthly, Interval: 3, Start: date("2026-11-15")}
+	assert.Equal(t, []string{"2026-11-15", "2027-02-15", "2027-05-15"}, occurrences(quarterly, 3))
+}
+
+func TestDue_CatchesUpAndStopsAtEnd(t *testing.T) {
+	end := date("2026-05-10")
+	rule := recurring.Rule{Frequency: recurring.Monthly, Interval: 1, Start: date("2026-01-10"), End: &end}
+
+	assert.Empty(t, rule.Due(0, date("2026-01-09")))
+	assert.Equal(t, []int{0}, rule.Due(0, date("2026-01-10").Add(23*time.Hour)))
+	assert.Equal(t, []int{1, 2, 3}, rule.Due(1, date("2026-04-20")))
+	assert.Equal(t, []int{4}, rule.Due(4, date("2027-01-01")))
+	assert.Empty(t, rule.Due(5, date("2027-01-01")))
+	assert.True(t, rule.Ended(5))
+	assert.False(t, rule.Ended(4))
+}
+
+func TestDue_CapsTheCatchUp(t *testing.T) {
+	rule := recurring.Rule{Frequency: recurring.Daily, Interval: 1, Start: date("2026-01-01")}
+
+	due := rule.Due(0, date("2027-12-31"))
+	assert.Len(t, due, recurring.MaxCatchUp)
+	assert.Equal(t, recurring.MaxCatchUp-1, due[len(due)-1])
+	assert.Equal(t, recurring.MaxCatchUp, rule.Due(recurring.MaxCatchUp, date("2027-12-31"))[0])
+}
+
+func TestCheckStart(t *testing.T) {
+	daily := recurring.Rule{Frequency: recurring.Daily, Interval: 1, Start: date("2026-01-01")}
+
+	// No dia 366 depois do início, 367 períodos já teriam vencido.
+	assert.Nil(t, daily.CheckStart(date("2026-01-01").AddDate(0, 0, recurring.MaxCatchUp-1)))
+	assert.ErrorContains(t, daily.CheckStart(date("2026-01-01").AddDate(0, 0, recurring.MaxCatchUp)), "start_date must not be more than 366 periods in the past")
+
+	// Uma regra que terminou antes do limite nunca passa dele.
+	end := date("2026-01-31")
+	ended := recurring.Rule{Frequency: recurring.Daily, Interval: 1, Start: date("2026-01-01"), End: &end}
+	assert.Nil(t, ended.CheckStart(date("2030-01-01")))
+
+	monthly := recurring.Rule{Frequency: recurring.Monthly, Interval: 1, Start: date("2000-01-31")}
+	assert.Nil(t, monthly.CheckStart(date("2026-10-18")))
+}
+
+func TestDue_UsesTheUTCDate(t *testing.T) {
+	rule := recurring.Rule{Frequency: recurring.Daily, Interval: 1, Start: date("2026-10-05")}
+	saoPaulo := time.FixedZone("BRT", -3*3600)
+
+	// 22h do dia 4 em São Paulo já é dia 5 em UTC.
+	assert.Equal(t, []int{0}, rule.Due(0, time.Date(2026, 10, 4, 22, 0, 0, 0, saoPaulo)))
+}
+
+func TestValidate(t *testing.T) {
+	end := date("2025-12-31")
+	cases := map[string]recurring.Rule{
+		"frequency": {Frequency: "yearly", Interval: 1, Start: date("2026-01-01")},
+		"interval":  {Frequency: recurring.Monthly, Interval: 0, Start: date("2026-01-01")},
+		"end date":  {Frequency: recurring.Monthly, Interval: 1, Start: date("2026-01-01"), End: &end},
+	}
+	for message, rule := range cases {
+		err := rule.Validate()
+		if assert.NotNil(t, err, message) {
+			assert.Contains(t, err.Error(), message)
+		}
+	}
+
+	assert.Nil(t, recurring.Rule{Frequency: recurring.Weekly, Interval: 1, Start: date("2026-01-01")}.Validate())
+}
+
+func TestSystemClock(t *testing.T) {
+	var clock recurring.Clock = recurring.SystemClock{}
+	assert.WithinDuration(t, time.Now(), clock.Now(), time.Second)
+}
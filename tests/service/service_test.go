@@ -0,0 +1,213 @@
+package service_test
+
+import (
+	"me-pague/internal/audit"
+	"me-pague/internal/auth"
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/penalty"
+	"me-pague/internal/repository"
+	"me-pague/internal/service"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fixture struct {
+	users    service.UserService
+	billings service.BillingService
+	payments service.PaymentService
+	ana      models.User
+	beto     models.User
+}
+
+func newFixture(t *testing.T) fixture {
+	repos := repository.NewMemory()
+	f := fixture{
+		users:    service.UserService{Users: repos.Users},
+		billings: service.BillingService{Users: repos.Users, Billings: repos.Billings, Payments: repos.Payments, Penalty: penalty.DefaultPolicy()},
+		payments: service.PaymentService{Payments: repos.Payments},
+	}
+	var err error
+	f.ana, err = f.users.Create("Ana", "segredo123", audit.System)
+	assert.Nil(t, err)
+	f.beto, err = f.users.Create("Beto", "segredo123", audit.System)
+	assert.Nil(t, err)
+	return f
+}
+
+// newBilling abre uma cobrança de Ana para Beto com um lançamento de charged
+// e a devolve relida, com os totais e a versão atuais.
+func newBilling(t *testing.T, f fixture, charged money.Amount) models.Billing {
+	billing := models.Billing{PayerID: f.ana.ID, ReceiverID: f.beto.ID, Currency: "BRL"}
+	assert.Nil(t, f.billings.Billings.Create(&billing, audit.System))
+	charge := models.Charge{Amount: charged, Currency: "BRL", Date: time.Now()}
+	assert.Nil(t, f.billings.Billings.AddCharge(&charge, billing, audit.System))
+
+	billing, err := f.billings.Get(billing.ID)
+	assert.Nil(t, err)
+	return billing
+}
+
+func TestUserService_CreateAndAuthenticate(t *testing.T) {
+	t.Parallel()
+	f := newFixture(t)
+
+	_, err := f.users.Create("Ana", "outrasenha", audit.System)
+	assert.Equal(t, service.ErrUserExists, err)
+
+	user, err := f.users.Authenticate("Ana", "segredo123")
+	assert.Nil(t, err)
+	assert.Equal(t, f.ana.ID, user.ID)
+	assert.NotContains(t, user.PasswordHash, "segredo123")
+
+	_, err = f.users.Authenticate("Ana", "errada123")
+	assert.Equal(t, service.ErrInvalidCredentials, err)
+	_, err = f.users.Authenticate("Caio", "segredo123")
+	assert.Equal(t, service.ErrInvalidCredentials, err)
+}
+
+func TestUserService_SetPassword(t *testing.T) {
+	t.Parallel()
+	repos := repository.NewMemory()
+	users := service.UserService{Users: repos.Users}
+
+	// Usuário de antes da autenticação, sem senha: não consegue entrar.
+	legacy := models.User{Name: "Ana"}
+	assert.Nil(t, repos.Users.Create(&legacy, audit.System))
+	_, err := users.Authenticate("Ana", "segredo123")
+	assert.Equal(t, service.ErrInvalidCredentials, err)
+
+	_, err = users.SetPassword("Ana", strings.Repeat("a", 73), audit.System)
+	assert.ErrorIs(t, err, auth.ErrPasswordTooLong)
+	_, err = users.SetPassword("Caio", "segredo123", audit.System)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	user, err := users.SetPassword("Ana", "segredo123", audit.System)
+	assert.Nil(t, err)
+	assert.Equal(t, legacy.ID, user.ID)
+
+	user, err = users.Authenticate("Ana", "segredo123")
+	assert.Nil(t, err)
+	assert.Equal(t, legacy.ID, user.ID)
+}
+
+func TestBillingService_Validate(t *testing.T) {
+	t.Parallel()
+	f := newFixture(t)
+
+	input, err := f.billings.Validate(service.BillingKey{PayerID: f.ana.ID, ReceiverID: f.beto.ID, Currency: "usd"})
+	assert.Nil(t, err)
+	assert.Equal(t, "USD", input.Currency)
+
+	_, err = f.billings.Validate(service.BillingKey{PayerID: f.ana.ID, ReceiverID: f.ana.ID})
+	assert.EqualError(t, err, "payer_id and receiver_id cannot be the same")
+	_, err = f.billings.Validate(service.BillingKey{PayerID: 99, ReceiverID: f.beto.ID})
+	assert.EqualError(t, err, "Person 1 not found")
+	_, err = f.billings.Validate(service.BillingKey{PayerID: f.ana.ID, ReceiverID: 99})
+	assert.EqualError(t, err, "Person 2 not found")
+}
+
+func TestBillingService_GetOrCreateReusesBilling(t *testing.T) {
+	t.Parallel()
+	f := newFixture(t)
+
+	first, err := f.billings.GetOrCreate(service.BillingKey{PayerID: f.ana.ID, ReceiverID: f.beto.ID}, audit.System)
+	assert.Nil(t, err)
+	assert.Equal(t, "BRL", first.Currency)
+
+	again, err := f.billings.GetOrCreate(service.BillingKey{PayerID: f.ana.ID, ReceiverID: f.beto.ID, Currency: "BRL"}, audit.System)
+	assert.Nil(t, err)
+	assert.Equal(t, first.ID, again.ID)
+
+	other, err := f.billings.GetOrCreate(service.BillingKey{PayerID: f.ana.ID, ReceiverID: f.beto.ID, Currency: "USD"}, audit.System)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.ID, other.ID)
+}
+
+func TestPaymentService_Create(t *testing.T) {
+	t.Parallel()
+	f := newFixture(t)
+	billing := newBilling(t, f, 1000)
+
+	payment, err := f.payments.Create(service.NewPayment{Amount: money.Minor(1500)}, billing, nil, audit.System)
+	assert.Nil(t, err)
+	assert.Equal(t, models.PaymentConfirmed, payment.Status)
+	assert.Equal(t, money.Amount(500), payment.Credit)
+
+	updated, err := f.billings.Get(billing.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(1500), updated.TotalPaid)
+	assert.Equal(t, money.Amount(500), updated.Credit)
+
+	// A cobrança lida antes do pagamento está desatualizada.
+	_, err = f.payments.Create(service.NewPayment{Amount: money.Minor(100)}, billing, nil, audit.System)
+	assert.Equal(t, repository.ErrBillingConflict, err)
+
+	_, err = f.payments.Create(service.NewPayment{Amount: money.Minor(0)}, updated, nil, audit.System)
+	assert.EqualError(t, err, "amount must be greater than zero")
+}
+
+func TestPaymentService_RejectOverpayment(t *testing.T) {
+	t.Parallel()
+	f := newFixture(t)
+	billing := newBilling(t, f, 1000)
+
+	_, err := f.payments.Create(service.NewPayment{Amount: money.Minor(1001), RejectOverpayment: true}, billing, nil, audit.System)
+	assert.EqualError(t, err, "amount exceeds the outstanding balance of 1000")
+}
+
+func TestPaymentService_PendingUntilConfirmed(t *testing.T) {
+	t.Parallel()
+	f := newFixture(t)
+	f.payments.RequireConfirmation = true
+	billing := newBilling(t, f, 1000)
+
+	payment, err := f.payments.Create(service.NewPayment{Amount: money.Minor(400)}, billing, nil, audit.Meta{ActorID: f.ana.ID})
+	assert.Nil(t, err)
+	assert.Equal(t, models.PaymentPending, payment.Status)
+	assert.Equal(t, money.Amount(0), payment.Credit)
+
+	payment, err = f.payments.Create(service.NewPayment{Amount: money.Minor(400)}, billing, nil, audit.Meta{ActorID: f.beto.ID})
+	assert.Nil(t, err)
+	assert.Equal(t, models.PaymentConfirmed, payment.Status)
+
+	updated, _ := f.billings.Get(billing.ID)
+	assert.Equal(t, money.Amount(600), updated.Outstanding)
+}
+
+func TestBillingService_TotalsComeFromCharges(t *testing.T) {
+	t.Parallel()
+	f := newFixture(t)
+	billing := newBilling(t, f, 1000)
+	assert.Equal(t, money.Amount(1000), billing.TotalCharged)
+	assert.Equal(t, money.Amount(1000), billing.Outstanding)
+
+	dueDate := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	charge := models.Charge{Amount: 500, Currency: "BRL", Date: dueDate, DueDate: &dueDate}
+	assert.Nil(t, f.billings.Billings.AddCharge(&charge, billing, audit.System))
+
+	// O lançamento invalida as leituras anteriores da cobrança.
+	_, err := f.payments.Create(service.NewPayment{Amount: money.Minor(100)}, billing, nil, audit.System)
+	assert.Equal(t, repository.ErrBillingConflict, err)
+
+	billing, err = f.billings.Get(billing.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(1500), billing.TotalCharged)
+	assert.Equal(t, money.Amount(1500), billing.Outstanding)
+
+	charges, err := f.billings.Billings.Charges(billing.ID)
+	assert.Nil(t, err)
+	assert.Len(t, charges, 2)
+	assert.Equal(t, charge.ID, charges[1].ID)
+
+	// Só o lançamento vencido sofre multa de 2% e juros de 1% ao mês pro
+	// rata: 31 dias de atraso sobre 500.
+	statement, err := f.billings.WithPenalties(billing, dueDate.AddDate(0, 0, 31))
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(1500), statement.OriginalAmount)
+	assert.Equal(t, money.Amount(10), statement.LateFee)
+	assert.Len(t, statement.Charges, 2)
+}
@@ -0,0 +1,114 @@
+package settlement_test
+
+import (
+	"me-pague/internal/models"
+	"me-pague/internal/money"
+	"me-pague/internal/settlement"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func applyPlan(balances []settlement.Balance, transfers []settlement.Transfer) map[int32]money.Amount {
+	net := make(map[int32]money.Amount)
+	for _, b := range balances {
+		net[b.UserID] = b.Amount
+	}
+	for _, t := range transfers {
+		net[t.From] += t.Amount
+		net[t.To] -= t.Amount
+	}
+	return net
+}
+
+func TestNetBalances(t *testing.T) {
+	balances, err := settlement.NetBalances([]models.Billing{
+		{PayerID: 1, ReceiverID: 2, TotalPaid: 100},
+		{PayerID: 2, ReceiverID: 1, TotalPaid: 80},
+		{PayerID: 3, ReceiverID: 1, TotalCharged: 50, TotalPaid: 20},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []settlement.Balance{
+		{UserID: 1, Amount: 50},
+		{UserID: 2, Amount: -20},
+		{UserID: 3, Amount: -30},
+	}, balances)
+}
+
+func TestPlan_SettlesEveryone(t *testing.T) {
+	balances := []settlement.Balance{
+		{UserID: 1, Amount: 50},
+		{UserID: 2, Amount: -20},
+		{UserID: 3, Amount: -30},
+		{UserID: 4, Amount: 10},
+		{UserID: 5, Amount: -10},
+	}
+
+	transfers, err := settlement.Plan(balances)
+	assert.Nil(t, err)
+
+	for userID, amount := range applyPlan(balances, transfers) {
+		assert.Equal(t, money.Amount(0), amount, "user %d", userID)
+	}
+	assert.Len(t, transfers, 3)
+}
+
+func TestPlan_UsesZeroSumSubgroups(t *testing.T) {
+	// O guloso puro faria 4 transferências; separando {1,4} e {2,3,5} bastam 3.
+	balances := []settlement.Balance{
+		{UserID: 1, Amount: 70},
+		{UserID: 2, Amount: 60},
+		{UserID: 3, Amount: -40},
+		{UserID: 4, Amount: -70},
+		{UserID: 5, Amount: -20},
+	}
+
+	transfers, err := settlement.Plan(balances)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []settlement.Transfer{
+		{From: 3, To: 2, Amount: 40},
+		{From: 4, To: 1, Amount: 70},
+		{From: 5, To: 2, Amount: 20},
+	}, transfers)
+}
+
+func TestPlan_Deterministic(t *testing.T) {
+	balances := []settlement.Balance{
+		{UserID: 3, Amount: -10},
+		{UserID: 1, Amount: 10},
+		{UserID: 2, Amount: -10},
+		{UserID: 4, Amount: 10},
+	}
+
+	first, err := settlement.Plan(balances)
+	assert.Nil(t, err)
+	for i := 0; i < 20; i++ {
+		again, _ := settlement.Plan(balances)
+		assert.Equal(t, first, again)
+	}
+	assert.Equal(t, []settlement.Transfer{
+		{From: 2, To: 1, Amount: 10},
+		{From: 3, To: 4, Amount: 10},
+	}, first)
+}
+
+func TestPlan_Empty(t *testing.T) {
+	transfers, err := settlement.Plan(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, transfers)
+}
+
+func TestPlan_SubsetSumOverflow(t *testing.T) {
+	// O total é zero, mas a soma dos dois credores não cabe em Amount.
+	balances := []settlement.Balance{
+		{UserID: 1, Amount: money.Amount(1 << 62)},
+		{UserID: 2, Amount: money.Amount(1 << 62)},
+		{UserID: 3, Amount: -money.Amount(1 << 62)},
+		{UserID: 4, Amount: -money.Amount(1 << 62)},
+	}
+
+	_, err := settlement.Plan(balances)
+	assert.ErrorIs(t, err, money.ErrOverflow)
+}
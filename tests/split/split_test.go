@@ -0,0 +1,83 @@
+package split_test
+
+import (
+	"me-pague/internal/money"
+	"me-pague/internal/split"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sum(values []money.Amount) money.Amount {
+	var total money.Amount
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func TestSplit_EqualLeftoverGoesToLowestIDs(t *testing.T) {
+	parts := []split.Part{{UserID: 1}, {UserID: 2}, {UserID: 3}}
+
+	amounts, err := split.Split(1000, split.Equal, parts)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []money.Amount{334, 333, 333}, amounts)
+}
+
+func TestSplit_PercentageLargestRemainder(t *testing.T) {
+	parts := []split.Part{{UserID: 1, Value: 3333}, {UserID: 2, Value: 3333}, {UserID: 3, Value: 3334}}
+
+	amounts, err := split.Split(100, split.Percentage, parts)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []money.Amount{33, 33, 34}, amounts)
+}
+
+func TestSplit_PercentageMustAddUp(t *testing.T) {
+	parts := []split.Part{{UserID: 1, Value: 5000}, {UserID: 2, Value: 4000}}
+
+	_, err := split.Split(100, split.Percentage, parts)
+
+	assert.NotNil(t, err)
+}
+
+func TestSplit_Shares(t *testing.T) {
+	parts := []split.Part{{UserID: 1, Value: 2}, {UserID: 2, Value: 1}, {UserID: 3, Value: 1}}
+
+	amounts, err := split.Split(1001, split.Shares, parts)
+
+	assert.Nil(t, err)
+	assert.Equal(t, money.Amount(1001), sum(amounts))
+	assert.Equal(t, []money.Amount{501, 250, 250}, amounts)
+}
+
+func TestSplit_ExactMustMatchTotal(t *testing.T) {
+	_, err := split.Split(100, split.Exact, []split.Part{{UserID: 1, Value: 60}, {UserID: 2, Value: 30}})
+	assert.NotNil(t, err)
+
+	amounts, err := split.Split(100, split.Exact, []split.Part{{UserID: 1, Value: 60}, {UserID: 2, Value: 40}})
+	assert.Nil(t, err)
+	assert.Equal(t, []money.Amount{60, 40}, amounts)
+}
+
+func TestSplit_AlwaysAddsUpToTotal(t *testing.T) {
+	parts := []split.Part{{UserID: 4, Value: 7}, {UserID: 9, Value: 3}, {UserID: 2, Value: 5}}
+
+	for total := money.Amount(1); total < 500; total++ {
+		amounts, err := split.Split(total, split.Shares, parts)
+		assert.Nil(t, err)
+		assert.Equal(t, total, sum(amounts))
+	}
+}
+
+func TestSplit_InvalidInput(t *testing.T) {
+	_, err := split.Split(0, split.Equal, []split.Part{{UserID: 1}})
+	assert.NotNil(t, err)
+
+	_, err = split.Split(100, "unknown", []split.Part{{UserID: 1}})
+	assert.NotNil(t, err)
+
+	_, err = split.Split(100, split.Equal, []split.Part{{UserID: 1}, {UserID: 1}})
+	assert.NotNil(t, err)
+}
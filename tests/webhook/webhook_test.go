@@ -0,0 +1,207 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"me-pague/internal/db/dbtest"
+	"me-pague/internal/models"
+	"me-pague/internal/webhook"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const secret = "segredo-do-assinante"
+
+// options aceita assinantes em 127.0.0.1, onde ficam os servidores httptest.
+var options = webhook.Options{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute, Timeout: time.Second, BatchSize: 10, AllowPrivateNetworks: true}
+
+// receiver é um assinante local que guarda o que recebeu e responde com o
+// próximo status da fila, ou 200 quando ela acaba.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, body)
+	r.headers = append(r.headers, req.Header.Clone())
+
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func setupWebhookTest(t *testing.T, statuses ...int) (*gorm.DB, *receiver, models.WebhookSubscription) {
+	testDB := dbtest.New()
+
+	recv := &receiver{statuses: statuses}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	subscription := models.WebhookSubscription{OwnerID: 1, URL: server.URL, Secret: secret, EventTypes: []string{webhook.PaymentCreated}}
+	testDB.Create(&subscription)
+	return testDB, recv, subscription
+}
+
+func enqueuePayment(t *testing.T, testDB *gorm.DB) {
+	billing := models.Billing{ID: 7, PayerID: 1, ReceiverID: 2}
+	err := webhook.Enqueue(testDB, webhook.PaymentCreated, billing, map[string]interface{}{"billing_id": 7, "amount": 50})
+	assert.Nil(t, err)
+}
+
+func loadDelivery(testDB *gorm.DB) models.WebhookDelivery {
+	var delivery models.WebhookDelivery
+	testDB.First(&delivery)
+	return delivery
+}
+
+func TestEnqueue_OnlyMatchingSubscriptions(t *testing.T) {
+	testDB, _, _ := setupWebhookTest(t)
+	testDB.Create(&models.WebhookSubscription{OwnerID: 2, URL: "http://example.com", Secret: secret, EventTypes: []string{webhook.BillingCreated}})
+	testDB.Create(&models.WebhookSubscription{OwnerID: 3, URL: "http://example.com", Secret: secret, EventTypes: []string{webhook.PaymentCreated}})
+
+	enqueuePayment(t, testDB)
+
+	var deliveries []models.WebhookDelivery
+	testDB.Find(&deliveries)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, int32(1), deliveries[0].SubscriptionID)
+	assert.Equal(t, models.DeliveryPending, deliveries[0].Status)
+}
+
+func TestDeliverDue_SignsPayload(t *testing.T) {
+	testDB, recv, _ := setupWebhookTest(t)
+	enqueuePayment(t, testDB)
+
+	now := time.Now()
+	delivered, err := options.DeliverDue(testDB, now)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, delivered)
+
+	assert.Len(t, recv.bodies, 1)
+	body, header := recv.bodies[0], recv.headers[0]
+	expected := "t=" + strconv.FormatInt(now.Unix(), 10) + ",v1=" + webhook.Sign(secret, now.Unix(), body)
+	assert.Equal(t, expected, header.Get(webhook.SignatureHeader))
+	assert.Equal(t, webhook.PaymentCreated, header.Get(webhook.EventHeader))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+
+	var event webhook.Event
+	assert.Nil(t, json.Unmarshal(body, &event))
+	assert.Equal(t, webhook.PaymentCreated, event.Type)
+	assert.NotEmpty(t, event.ID)
+
+	delivery := loadDelivery(testDB)
+	assert.Equal(t, models.DeliveryDelivered, delivery.Status)
+	assert.Equal(t, strconv.Itoa(int(delivery.ID)), header.Get(webhook.DeliveryHeader))
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.NotNil(t, delivery.DeliveredAt)
+
+	delivered, _ = options.DeliverDue(testDB, now.Add(time.Hour))
+	assert.Equal(t, 0, delivered)
+	assert.Len(t, recv.bodies, 1)
+}
+
+func TestDeliverDue_RetriesWithBackoffThenDeadLetters(t *testing.T) {
+	testDB, recv, _ := setupWebhookTest(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable)
+	enqueuePayment(t, testDB)
+
+	now := time.Now()
+	options.DeliverDue(testDB, now)
+	delivery := loadDelivery(testDB)
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+	assert.WithinDuration(t, now.Add(time.Minute), delivery.NextAttemptAt, time.Second)
+
+	// Antes da próxima tentativa nada é enviado.
+	options.DeliverDue(testDB, now.Add(30*time.Second))
+	assert.Len(t, recv.bodies, 1)
+
+	now = now.Add(time.Minute)
+	options.DeliverDue(testDB, now)
+	delivery = loadDelivery(testDB)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.WithinDuration(t, now.Add(2*time.Minute), delivery.NextAttemptAt, time.Second)
+
+	options.DeliverDue(testDB, now.Add(2*time.Minute))
+	delivery = loadDelivery(testDB)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, models.DeliveryDead, delivery.Status)
+	assert.Contains(t, delivery.LastError, "503")
+
+	options.DeliverDue(testDB, now.Add(24*time.Hour))
+	assert.Len(t, recv.bodies, 3)
+
+	// A reentrega manual tira a entrega de dead, e o envio fica com o worker.
+	now = now.Add(24 * time.Hour)
+	assert.Nil(t, webhook.Redeliver(testDB, &delivery, now))
+	delivery = loadDelivery(testDB)
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+	assert.Equal(t, 0, delivery.Attempts)
+	assert.WithinDuration(t, now, delivery.NextAttemptAt, time.Second)
+	assert.Len(t, recv.bodies, 3)
+
+	delivered, err := options.DeliverDue(testDB, now)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, models.DeliveryDelivered, loadDelivery(testDB).Status)
+	assert.Len(t, recv.bodies, 4)
+}
+
+func TestDeliverDue_RefusesInternalAddressesAtDialTime(t *testing.T) {
+	// A assinatura aponta para 127.0.0.1, como se o DNS tivesse mudado de
+	// resposta depois da verificação feita ao assiná-la.
+	testDB, recv, _ := setupWebhookTest(t)
+	enqueuePayment(t, testDB)
+
+	public := options
+	public.AllowPrivateNetworks = false
+	delivered, err := public.DeliverDue(testDB, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.Len(t, recv.bodies, 0)
+
+	delivery := loadDelivery(testDB)
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+	assert.Contains(t, delivery.LastError, webhook.ErrForbiddenAddress.Error())
+}
+
+func TestCheckHost(t *testing.T) {
+	var public webhook.Options
+	ctx := context.Background()
+
+	for _, host := range []string{"127.0.0.1", "localhost", "::1", "10.1.2.3", "172.31.0.1", "192.168.1.1", "fc00::1", "169.254.169.254", "fe80::1", "0.0.0.0", "::"} {
+		assert.ErrorIs(t, public.CheckHost(ctx, host), webhook.ErrForbiddenAddress, host)
+	}
+	for _, host := range []string{"203.0.113.10", "8.8.8.8", "2001:db8::1"} {
+		assert.Nil(t, public.CheckHost(ctx, host), host)
+	}
+
+	assert.Nil(t, options.CheckHost(ctx, "127.0.0.1"))
+}
+
+func TestBackoff_IsCapped(t *testing.T) {
+	backoff := webhook.Options{BaseDelay: time.Minute, MaxDelay: 10 * time.Minute}.Backoff
+
+	assert.Equal(t, time.Minute, backoff(1))
+	assert.Equal(t, 2*time.Minute, backoff(2))
+	assert.Equal(t, 8*time.Minute, backoff(4))
+	assert.Equal(t, 10*time.Minute, backoff(5))
+	assert.Equal(t, 10*time.Minute, backoff(60))
+}
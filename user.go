@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"me-pague/internal/audit"
+	"me-pague/internal/config"
+	"me-pague/internal/db"
+	"me-pague/internal/repository"
+	"me-pague/internal/service"
+	"os"
+	"strings"
+)
+
+const userUsage = "usage: me-pague [flags] user set-password <name> (reads the password from stdin)"
+
+// runUser trata "me-pague user set-password <name>", que troca a senha do
+// usuário pela primeira linha da entrada padrão. Os usuários criados antes
+// da autenticação não têm senha e só entram depois de passar por aqui.
+func runUser(cfg config.Config, args []string) {
+	if len(args) != 2 || args[0] != "set-password" {
+		log.Fatal(userUsage)
+	}
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		log.Fatalf("failed to read the password from stdin: %v", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	database := db.Init(cfg.Database.DSN)
+	users := service.UserService{Users: repository.NewGorm(database).Users}
+	user, err := users.SetPassword(args[1], password, audit.System)
+	if err != nil {
+		log.Fatalf("failed to set the password of %s: %v", args[1], err)
+	}
+	fmt.Printf("password set for user %d (%s)\n", user.ID, user.Name)
+}